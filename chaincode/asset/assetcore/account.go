@@ -0,0 +1,100 @@
+package assetcore
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// Asset 资产，既用于帐户持有的资产，也用于cc2以Asset~issuer~code为key的资产记录
+type Asset struct {
+	DocType string `json:"docType,omitempty"` //文档类型
+	Issuer  string `json:"issuer"`            //资产发行机构
+	Code    string `json:"code"`              //资产代码
+	Amount  int64  `json:"amount"`            //资产数量，资产记录中为未发行数量
+
+	Issued          int64 `json:"issued,omitempty"`          //累计发行数量
+	RedemptionPrice int64 `json:"redemptionPrice,omitempty"` //赎回价格，0表示不可赎回
+	Redeemed        int64 `json:"redeemed,omitempty"`        //已赎回销毁的数量
+}
+
+// Account 账户，cc0、cc1以帐户id为key保存，持有的资产保存在Assets中
+// 早期版本以AccountId为字段名保存，JSON解析不区分大小写，仍可读取
+type Account struct {
+	AccountId string   `json:"accountId"` //帐户id
+	Assets    []*Asset `json:"assets"`    //该帐户的资产列表
+	Owner     Identity `json:"owner"`     //帐户所有者身份
+}
+
+// Amount 获取帐户某类资产的持有数量
+func (a *Account) Amount(issuer, code string) int64 {
+	for _, v := range a.Assets {
+		if v.Issuer == issuer && v.Code == code {
+			return v.Amount
+		}
+	}
+	return 0
+}
+
+// Credit 增加帐户中的资产，返回增加后的数量
+// 帐户中不存在该资产时新增该资产
+func (a *Account) Credit(issuer, code string, amount int64) int64 {
+	for k, v := range a.Assets {
+		if v.Issuer == issuer && v.Code == code {
+			a.Assets[k].Amount = v.Amount + amount
+			return a.Assets[k].Amount
+		}
+	}
+	a.Assets = append(a.Assets, &Asset{Issuer: issuer, Code: code, Amount: amount})
+	return amount
+}
+
+// CheckAccount 获取帐户信息，并判断是否存在
+func CheckAccount(stub shim.ChaincodeStubInterface, id string) (b []byte, a Account, isExist bool, err error) {
+	b, _, err = Load(stub, id, &a)
+	return b, a, a.AccountId != "", err
+}
+
+// CashAccount cc2帐户，以帐户id为key保存，持有量单独保存，帐户中只有现金余额
+type CashAccount struct {
+	DocType string   `json:"docType,omitempty"` //文档类型
+	ID      string   `json:"id"`                //帐户id
+	Balance int64    `json:"balance"`           //账户余额
+	Owner   Identity `json:"owner"`             //帐户所有者身份
+}
+
+// CheckCashAccount 获取cc2帐户信息，并判断是否存在
+func CheckCashAccount(stub shim.ChaincodeStubInterface, id string) (b []byte, a CashAccount, isExist bool, err error) {
+	b, _, err = Load(stub, id, &a)
+	if len(b) > 0 {
+		a.DocType = AccountDocType
+	}
+	return b, a, a.ID != "", err
+}
+
+// HasAccount 判断帐户是否存在，兼容cc0、cc1及cc2的帐户文档
+func HasAccount(stub shim.ChaincodeStubInterface, id string) (bool, error) {
+	var a struct {
+		AccountId string `json:"accountId"` //cc0、cc1帐户id
+		ID        string `json:"id"`        //cc2帐户id
+	}
+	b, err := stub.GetState(id)
+	if err != nil || len(b) == 0 {
+		return false, err
+	}
+	err = json.Unmarshal(b, &a)
+	return a.AccountId != "" || a.ID != "", err
+}
+
+// CheckAsset 获取资产记录，并判断是否存在
+func CheckAsset(stub shim.ChaincodeStubInterface, issuer, code string) (b []byte, a Asset, isExist bool, key string, err error) {
+	key, err = stub.CreateCompositeKey(AssetObjectType, []string{issuer, code})
+	if err != nil {
+		return b, a, false, key, err
+	}
+	b, _, err = Load(stub, key, &a)
+	if len(b) > 0 {
+		a.DocType = AssetDocType
+	}
+	return b, a, a.Code != "", key, err
+}
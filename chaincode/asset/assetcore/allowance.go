@@ -0,0 +1,40 @@
+package assetcore
+
+import (
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// Allowance 授权额度，允许代理帐户从所有者帐户转出不超过额度的资产
+type Allowance struct {
+	DocType string `json:"docType,omitempty"` //文档类型
+	Owner   string `json:"owner"`             //资产所有者帐户
+	Spender string `json:"spender"`           //代理帐户
+	Issuer  string `json:"issuer"`            //资产发行机构
+	Code    string `json:"code"`              //资产代码
+	Amount  int64  `json:"amount"`            //剩余额度
+}
+
+// CheckAllowance 获取授权额度，不存在时返回额度为0的授权
+func CheckAllowance(stub shim.ChaincodeStubInterface, owner, spender, issuer, code string) (a Allowance, isExist bool, err error) {
+	a = Allowance{DocType: AllowanceDocType, Owner: owner, Spender: spender, Issuer: issuer, Code: code}
+	key, err := stub.CreateCompositeKey(AllowanceObjectType, []string{owner, spender, issuer, code})
+	if err != nil {
+		return a, false, err
+	}
+	_, isExist, err = Load(stub, key, &a)
+	a.DocType = AllowanceDocType
+	return a, isExist, err
+}
+
+// SaveAllowance 保存授权额度，额度为0时删除
+func SaveAllowance(stub shim.ChaincodeStubInterface, a Allowance) error {
+	key, err := stub.CreateCompositeKey(AllowanceObjectType, []string{a.Owner, a.Spender, a.Issuer, a.Code})
+	if err != nil {
+		return err
+	}
+	if a.Amount == 0 {
+		return stub.DelState(key)
+	}
+	a.DocType = AllowanceDocType
+	return Save(stub, key, a)
+}
@@ -0,0 +1,174 @@
+package assetcore
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// ApprovalPolicy 大额转移审批策略
+// 转移数量超过阈值时需要Quorum个签名人审批后才执行
+type ApprovalPolicy struct {
+	DocType   string     `json:"docType,omitempty"` //文档类型
+	Issuer    string     `json:"issuer"`            //资产发行机构
+	Code      string     `json:"code"`              //资产代码
+	Threshold int64      `json:"threshold"`         //需要审批的转移数量阈值
+	Quorum    int        `json:"quorum"`            //执行所需的审批数
+	Duration  int64      `json:"duration"`          //待审批转移的有效时长（秒）
+	Signers   []Identity `json:"signers"`           //签名人列表
+}
+
+// PendingTransfer 待审批的转移
+type PendingTransfer struct {
+	DocType   string     `json:"docType,omitempty"` //文档类型
+	ID        string     `json:"id"`                //编号（发起交易ID）
+	From      string     `json:"from"`              //转出帐户
+	To        string     `json:"to"`                //转入帐户
+	Issuer    string     `json:"issuer"`            //资产发行机构
+	Code      string     `json:"code"`              //资产代码
+	Amount    int64      `json:"amount"`            //转移数量
+	Quorum    int        `json:"quorum"`            //执行所需的审批数
+	Signers   []Identity `json:"signers"`           //签名人列表，发起时从审批策略复制
+	Approvals []Identity `json:"approvals"`         //已审批的签名人
+	Expiry    int64      `json:"expiry"`            //到期时间（秒）
+}
+
+// CheckPolicySigners 校验审批策略的阈值、有效时长及签名人设置
+func CheckPolicySigners(p ApprovalPolicy) error {
+	if p.Threshold <= 0 || p.Duration <= 0 {
+		return fmt.Errorf("threshold and duration must be greater than 0")
+	}
+	if p.Quorum > len(p.Signers) {
+		return fmt.Errorf("quorum=%v is greater than signers count=%v", p.Quorum, len(p.Signers))
+	}
+	seen := map[Identity]bool{}
+	for _, s := range p.Signers {
+		if s.MSPID == "" || s.Subject == "" {
+			return fmt.Errorf("signer mspId and subject can't be nil")
+		}
+		if seen[s] {
+			return fmt.Errorf("duplicate signer mspId=%s&subject=%s", s.MSPID, s.Subject)
+		}
+		seen[s] = true
+	}
+	return nil
+}
+
+// SetApprovalPolicy 校验发行机构身份并保存审批策略，审批数为0时取消审批
+func SetApprovalPolicy(stub shim.ChaincodeStubInterface, p ApprovalPolicy) error {
+	err := CheckIssuer(stub, p.Issuer)
+	if err != nil {
+		return NewError(CodeUnauthorized, "Permission denied:%s", err)
+	}
+
+	key, err := stub.CreateCompositeKey(ApprovalPolicyObjectType, []string{p.Issuer, p.Code})
+	if err != nil {
+		return err
+	}
+	if p.Quorum == 0 {
+		err = stub.DelState(key)
+	} else {
+		p.DocType = ApprovalPolicyDocType
+		err = Save(stub, key, p)
+	}
+	if err != nil {
+		return fmt.Errorf("save approval policy=%+v error:%s", p, err)
+	}
+	return nil
+}
+
+// CheckApproval 获取资产的审批策略，并判断转移数量是否需要审批
+func CheckApproval(stub shim.ChaincodeStubInterface, issuer, code string, amount int64) (p ApprovalPolicy, required bool, err error) {
+	key, err := stub.CreateCompositeKey(ApprovalPolicyObjectType, []string{issuer, code})
+	if err != nil {
+		return p, false, err
+	}
+	_, _, err = Load(stub, key, &p)
+	return p, p.Quorum > 0 && amount > p.Threshold, err
+}
+
+// SavePendingTransfer 按审批策略保存待审批的转移，编号为当前交易ID
+func SavePendingTransfer(stub shim.ChaincodeStubInterface, p ApprovalPolicy, from, to, issuer, code string, amount int64) (t PendingTransfer, err error) {
+	now, err := TxTime(stub)
+	if err != nil {
+		return t, fmt.Errorf("GetTxTimestamp error:%s", err)
+	}
+	t = PendingTransfer{
+		DocType:   PendingTransferDocType,
+		ID:        stub.GetTxID(),
+		From:      from,
+		To:        to,
+		Issuer:    issuer,
+		Code:      code,
+		Amount:    amount,
+		Quorum:    p.Quorum,
+		Signers:   p.Signers,
+		Approvals: []Identity{},
+		Expiry:    now + p.Duration,
+	}
+	key, err := stub.CreateCompositeKey(PendingTransferObjectType, []string{t.ID})
+	if err != nil {
+		return t, err
+	}
+	err = Save(stub, key, t)
+	if err != nil {
+		return t, fmt.Errorf("save pending transfer=%+v error:%s", t, err)
+	}
+	return t, nil
+}
+
+// LoadPendingTransfer 获取待审批的转移及当前交易时间，不存在时返回错误
+func LoadPendingTransfer(stub shim.ChaincodeStubInterface, id string) (t PendingTransfer, key string, now int64, err error) {
+	if id == "" {
+		return t, key, now, NewError(CodeInvalidArgument, "pending transfer arguments error: id can't be nil")
+	}
+
+	key, err = stub.CreateCompositeKey(PendingTransferObjectType, []string{id})
+	if err != nil {
+		return t, key, now, err
+	}
+	_, isExist, err := Load(stub, key, &t)
+	if err != nil {
+		return t, key, now, fmt.Errorf("Check pending transfer=%s error:%s", id, err)
+	} else if !isExist {
+		return t, key, now, NewError(CodeNotFound, "Pending transfer=%s not exists", id)
+	}
+	t.DocType = PendingTransferDocType
+
+	now, err = TxTime(stub)
+	if err != nil {
+		return t, key, now, fmt.Errorf("GetTxTimestamp error:%s", err)
+	}
+	return t, key, now, nil
+}
+
+// ApprovePendingTransfer 记录交易提交者对待审批转移的审批
+// 转移已到期、提交者不是签名人或已审批过时返回错误
+func ApprovePendingTransfer(stub shim.ChaincodeStubInterface, t *PendingTransfer, now int64) error {
+	if now >= t.Expiry {
+		return NewError(CodeFailedPrecondition, "Pending transfer=%s expired at %v.", t.ID, t.Expiry)
+	}
+
+	caller, err := Caller(stub)
+	if err != nil {
+		return NewError(CodeUnauthorized, "Permission denied:get caller identity error:%s", err)
+	}
+	if !ContainsIdentity(t.Signers, caller) {
+		return NewError(CodeUnauthorized, "Permission denied:caller mspId=%s&subject=%s is not a signer of pending transfer=%s", caller.MSPID, caller.Subject, t.ID)
+	}
+	if ContainsIdentity(t.Approvals, caller) {
+		return NewError(CodeFailedPrecondition, "Caller mspId=%s&subject=%s already approved pending transfer=%s.", caller.MSPID, caller.Subject, t.ID)
+	}
+	t.Approvals = append(t.Approvals, caller)
+	return nil
+}
+
+// ContainsIdentity 判断身份是否在列表中
+func ContainsIdentity(ids []Identity, id Identity) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+// Package assetcore cc0、cc1、cc2共用的帐户及资产存储、身份校验、错误及JSON编码
+package assetcore
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// Save 以JSON保存state
+func Save(stub shim.ChaincodeStubInterface, key string, v interface{}) error {
+	val, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return stub.PutState(key, val)
+}
+
+// Load 读取state并解析JSON到v，返回原始内容及是否存在
+func Load(stub shim.ChaincodeStubInterface, key string, v interface{}) (b []byte, isExist bool, err error) {
+	b, err = stub.GetState(key)
+	if err != nil {
+		return b, false, err
+	}
+	if len(b) == 0 {
+		return b, false, nil
+	}
+	return b, true, json.Unmarshal(b, v)
+}
+
+// TxTime 获取交易时间（秒）
+func TxTime(stub shim.ChaincodeStubInterface) (int64, error) {
+	ts, err := stub.GetTxTimestamp()
+	if err != nil {
+		return 0, err
+	}
+	return ts.GetSeconds(), nil
+}
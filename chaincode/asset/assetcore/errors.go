@@ -0,0 +1,170 @@
+package assetcore
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// 错误码，各版本chaincode共用
+const (
+	CodeInvalidArgument    = "INVALID_ARGUMENT"    //函数名或参数错误
+	CodeUnauthorized       = "UNAUTHORIZED"        //调用者无权限
+	CodeAccountNotFound    = "ACCOUNT_NOT_FOUND"   //帐户不存在
+	CodeAssetNotFound      = "ASSET_NOT_FOUND"     //资产不存在
+	CodeNotFound           = "NOT_FOUND"           //其他记录不存在，如冻结、订单
+	CodeAlreadyExists      = "ALREADY_EXISTS"      //记录已存在
+	CodeInsufficientFunds  = "INSUFFICIENT_FUNDS"  //余额、持有量或代理额度不足
+	CodeRestricted         = "RESTRICTED"          //帐户冻结、资产停牌或资产未解锁
+	CodeNotEligible        = "NOT_ELIGIBLE"        //KYC认证不满足要求
+	CodeFailedPrecondition = "FAILED_PRECONDITION" //记录状态不允许该操作，如已过期
+	CodeInternal           = "INTERNAL"            //读写状态等内部错误
+)
+
+// CodedError 带错误码的错误，错误响应的Message为其JSON
+type CodedError struct {
+	Code    string                 `json:"code"`              //错误码
+	Message string                 `json:"message"`           //错误信息
+	Details map[string]interface{} `json:"details,omitempty"` //错误详情，如帐户id
+}
+
+func (e *CodedError) Error() string {
+	return e.Message
+}
+
+// NewError 以错误码及格式化的错误信息创建错误
+func NewError(code, format string, a ...interface{}) *CodedError {
+	return &CodedError{Code: code, Message: fmt.Sprintf(format, a...)}
+}
+
+// With 增加一项错误详情
+func (e *CodedError) With(key string, value interface{}) *CodedError {
+	if e.Details == nil {
+		e.Details = map[string]interface{}{}
+	}
+	e.Details[key] = value
+	return e
+}
+
+// Wrapf 格式化错误信息，错误码及详情取自参数中第一个带错误码的错误
+func Wrapf(format string, a ...interface{}) error {
+	e := NewError("", format, a...)
+	for _, v := range a {
+		if err, ok := v.(error); ok {
+			if c := AsCodedError(err); c.Code != CodeInternal {
+				e.Code, e.Details = c.Code, c.Details
+				break
+			}
+		}
+	}
+	if e.Code == "" {
+		e.Code = CodeInternal
+	}
+	return e
+}
+
+// AsCodedError 转换为带错误码的错误，没有错误码的为INTERNAL
+func AsCodedError(err error) *CodedError {
+	switch e := err.(type) {
+	case *CodedError:
+		return e
+	case *NotFoundError:
+		code := e.Code
+		if code == "" {
+			code = CodeNotFound
+		}
+		return &CodedError{Code: code, Message: e.Error(), Details: e.Details}
+	case *ExistsError:
+		return &CodedError{Code: CodeAlreadyExists, Message: e.Error(), Details: e.Details}
+	case *ArgError:
+		c := NewError(CodeInvalidArgument, "%s", e.Message)
+		if e.Function != "" {
+			c.With("function", e.Function)
+		}
+		if e.Arg != "" {
+			c.With("arg", e.Arg)
+		}
+		return c
+	}
+	return &CodedError{Code: CodeInternal, Message: err.Error()}
+}
+
+// NotFoundError 帐户、资产等记录不存在
+type NotFoundError struct {
+	Name    string                 //记录名称，如Account=xiaozhang
+	Code    string                 //错误码，为空时为NOT_FOUND
+	Details map[string]interface{} //错误详情
+}
+
+func (e *NotFoundError) Error() string {
+	return e.Name + " not exists."
+}
+
+// ExistsError 帐户、资产等记录已存在
+type ExistsError struct {
+	Name    string                 //记录名称，如Account=xiaozhang
+	Details map[string]interface{} //错误详情
+}
+
+func (e *ExistsError) Error() string {
+	return e.Name + " already exists."
+}
+
+// AccountNotFound 帐户不存在
+func AccountNotFound(id string) error {
+	return &NotFoundError{Name: "Account=" + id, Code: CodeAccountNotFound, Details: map[string]interface{}{"accountId": id}}
+}
+
+// AccountExists 帐户已存在
+func AccountExists(id string) error {
+	return &ExistsError{Name: "Account=" + id, Details: map[string]interface{}{"accountId": id}}
+}
+
+// AssetNotFound 资产不存在
+func AssetNotFound(issuer, code string) error {
+	return &NotFoundError{Name: fmt.Sprintf("Asset issuer=%s&code=%s", issuer, code), Code: CodeAssetNotFound, Details: map[string]interface{}{"issuer": issuer, "code": code}}
+}
+
+// InsufficientFunds 余额或持有量不足，issuer、code为空时为余额
+func InsufficientFunds(id, issuer, code string, available, required int64, format string, a ...interface{}) *CodedError {
+	e := NewError(CodeInsufficientFunds, format, a...).With("accountId", id)
+	if issuer != "" || code != "" {
+		e.With("issuer", issuer).With("code", code)
+	}
+	return e.With("available", available).With("required", required)
+}
+
+// 错误码对应的响应状态，均不小于shim.ERRORTHRESHOLD
+func status(code string) int32 {
+	switch code {
+	case CodeUnauthorized:
+		return 403
+	case CodeAccountNotFound, CodeAssetNotFound, CodeNotFound:
+		return 404
+	case CodeAlreadyExists:
+		return 409
+	case CodeInternal:
+		return shim.ERROR
+	}
+	return shim.ERRORTHRESHOLD
+}
+
+// Error 打印错误并返回错误响应
+func Error(err error) pb.Response {
+	e := AsCodedError(err)
+	fmt.Println(e.Message)
+	b, _ := json.Marshal(e)
+	return pb.Response{Status: status(e.Code), Message: string(b)}
+}
+
+// Errorf 格式化错误信息，打印并返回错误响应，错误码取自参数中的错误，默认为INTERNAL
+func Errorf(format string, a ...interface{}) pb.Response {
+	return Error(Wrapf(format, a...))
+}
+
+// Fail 以错误码及格式化的错误信息返回错误响应
+func Fail(code, format string, a ...interface{}) pb.Response {
+	return Error(NewError(code, format, a...))
+}
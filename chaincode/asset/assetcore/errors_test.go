@@ -0,0 +1,57 @@
+package assetcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+func TestError(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		code    string
+		status  int32
+		message string
+	}{
+		{"帐户不存在", AccountNotFound("xiaoli"), CodeAccountNotFound, 404, "Account=xiaoli not exists."},
+		{"资产不存在", AssetNotFound("AAA", "A1"), CodeAssetNotFound, 404, "Asset issuer=AAA&code=A1 not exists."},
+		{"帐户已存在", AccountExists("xiaozhang"), CodeAlreadyExists, 409, "Account=xiaozhang already exists."},
+		{"参数错误", &ArgError{Function: "Buy", Arg: "count", Message: "count must be an integer"}, CodeInvalidArgument, shim.ERRORTHRESHOLD, "count must be an integer"},
+		{"无权限", NewError(CodeUnauthorized, "Permission denied:%s", "not an admin"), CodeUnauthorized, 403, "Permission denied:not an admin"},
+		{"内部错误", fmt.Errorf("PutState error"), CodeInternal, shim.ERROR, "PutState error"},
+		{"保留错误码", Wrapf("leg %d error: %s", 1, AccountNotFound("xiaoli")), CodeAccountNotFound, 404, "leg 1 error: Account=xiaoli not exists."},
+		{"没有错误码", Wrapf("Check account=%s error:%s", "xiaoli", fmt.Errorf("timeout")), CodeInternal, shim.ERROR, "Check account=xiaoli error:timeout"},
+	}
+	for _, tt := range tests {
+		resp := Error(tt.err)
+		var e CodedError
+		if err := json.Unmarshal([]byte(resp.Message), &e); err != nil {
+			t.Errorf("%s: message %q is not JSON: %s", tt.name, resp.Message, err)
+			continue
+		}
+		if resp.Status != tt.status || e.Code != tt.code || e.Message != tt.message {
+			t.Errorf("%s: got status=%d %+v, want status=%d code=%s message=%q", tt.name, resp.Status, e, tt.status, tt.code, tt.message)
+		}
+	}
+}
+
+func TestErrorDetails(t *testing.T) {
+	resp := Error(Wrapf("%s.", InsufficientFunds("xiaozhang", "AAA", "A1", 10, 50, "Account=%s count=%v < %v", "xiaozhang", 10, 50)))
+	var e CodedError
+	if err := json.Unmarshal([]byte(resp.Message), &e); err != nil {
+		t.Fatalf("message %q is not JSON: %s", resp.Message, err)
+	}
+	if e.Code != CodeInsufficientFunds || e.Details["accountId"] != "xiaozhang" || e.Details["issuer"] != "AAA" || e.Details["available"] != float64(10) || e.Details["required"] != float64(50) {
+		t.Errorf("got %s", resp.Message)
+	}
+
+	resp = Error(AccountNotFound("xiaoli"))
+	e = CodedError{}
+	json.Unmarshal([]byte(resp.Message), &e)
+	if e.Details["accountId"] != "xiaoli" {
+		t.Errorf("got %s", resp.Message)
+	}
+}
@@ -0,0 +1,92 @@
+package assetcore
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// 手续费收费方式
+const (
+	FeeModeFlat = "flat" //每笔固定数量
+	FeeModeBps  = "bps"  //按转移数量的万分比，向下取整
+)
+
+// FeeSchedule 资产转移手续费设置
+// 手续费以被转移的资产支付，由转出帐户在转移数量之外另外承担，接收帐户收到的数量不变
+type FeeSchedule struct {
+	DocType   string `json:"docType,omitempty"` //文档类型
+	Issuer    string `json:"issuer"`            //资产发行机构
+	Code      string `json:"code"`              //资产代码
+	Mode      string `json:"mode"`              //收费方式：flat或bps
+	Rate      int64  `json:"rate"`              //flat为每笔数量，bps为万分比
+	Collector string `json:"collector"`         //收费帐户
+}
+
+// SetTransferFee 校验管理员身份及收费帐户，保存资产的转移手续费设置，费率为0时取消收费
+func SetTransferFee(stub shim.ChaincodeStubInterface, f FeeSchedule) error {
+	err := CheckAdmin(stub)
+	if err != nil {
+		return NewError(CodeUnauthorized, "Permission denied:%s", err)
+	}
+
+	// 收费帐户必须存在
+	if f.Rate > 0 {
+		isExist, err := HasAccount(stub, f.Collector)
+		if err != nil {
+			return fmt.Errorf("Check account=%s error:%s", f.Collector, err)
+		} else if !isExist {
+			return AccountNotFound(f.Collector)
+		}
+	}
+
+	key, err := stub.CreateCompositeKey(FeeObjectType, []string{f.Issuer, f.Code})
+	if err != nil {
+		return err
+	}
+	if f.Rate == 0 {
+		err = stub.DelState(key)
+	} else {
+		f.DocType = FeeDocType
+		err = Save(stub, key, f)
+	}
+	if err != nil {
+		return fmt.Errorf("save transfer fee=%+v error:%s", f, err)
+	}
+	return nil
+}
+
+// CheckTransferFee 获取资产的转移手续费设置，不存在时返回费率为0的设置
+func CheckTransferFee(stub shim.ChaincodeStubInterface, issuer, code string) (f FeeSchedule, err error) {
+	f = FeeSchedule{DocType: FeeDocType, Issuer: issuer, Code: code}
+	key, err := stub.CreateCompositeKey(FeeObjectType, []string{issuer, code})
+	if err != nil {
+		return f, err
+	}
+	_, _, err = Load(stub, key, &f)
+	f.DocType = FeeDocType
+	return f, err
+}
+
+// TransferFee 计算转出帐户转移该数量资产需支付的手续费，收费帐户自己转出时不收费
+func TransferFee(stub shim.ChaincodeStubInterface, from, issuer, code string, amount int64) (fee int64, collector string, err error) {
+	f, err := CheckTransferFee(stub, issuer, code)
+	if err != nil {
+		return 0, "", fmt.Errorf("Check transfer fee of issuer=%s&code=%s error:%s", issuer, code, err)
+	}
+	if f.Rate == 0 || f.Collector == from {
+		return 0, "", nil
+	}
+
+	if f.Mode == FeeModeBps {
+		// 拆分计算，避免amount*rate溢出
+		fee = amount/10000*f.Rate + amount%10000*f.Rate/10000
+	} else {
+		fee = f.Rate
+	}
+	if fee > math.MaxInt64-amount {
+		return 0, "", NewError(CodeInvalidArgument, "transfer amount=%v plus fee=%v overflows", amount, fee)
+	}
+	return fee, f.Collector, nil
+}
@@ -0,0 +1,70 @@
+package assetcore
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ChainNova/samples/chaincode/asset/memstub"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+func TestTransferFee(t *testing.T) {
+	cc := &funcChaincode{}
+	stub := memstub.New("assetcore", cc)
+	tx(t, stub, cc, func(stub shim.ChaincodeStubInterface) error {
+		key, _ := stub.CreateCompositeKey(FeeObjectType, []string{"AAA", "A1"})
+		if err := Save(stub, key, FeeSchedule{Issuer: "AAA", Code: "A1", Mode: FeeModeBps, Rate: 25, Collector: "exchange"}); err != nil {
+			return err
+		}
+		key, _ = stub.CreateCompositeKey(FeeObjectType, []string{"BBB", "B1"})
+		return Save(stub, key, FeeSchedule{Issuer: "BBB", Code: "B1", Mode: FeeModeFlat, Rate: 2, Collector: "exchange"})
+	})
+
+	tests := []struct {
+		from, issuer, code string
+		amount             int64
+		want               int64
+	}{
+		{"xiaozhang", "AAA", "A1", 10000, 25},
+		{"xiaozhang", "AAA", "A1", 399, 0},
+		{"xiaozhang", "AAA", "A1", 9000000000000000000, 22500000000000000},
+		{"xiaozhang", "BBB", "B1", 1, 2},
+		{"exchange", "BBB", "B1", 1, 0},
+		{"xiaozhang", "CCC", "C1", 100, 0},
+	}
+	tx(t, stub, cc, func(stub shim.ChaincodeStubInterface) error {
+		for _, tt := range tests {
+			fee, _, err := TransferFee(stub, tt.from, tt.issuer, tt.code, tt.amount)
+			if err != nil || fee != tt.want {
+				t.Errorf("TransferFee(%s, %s/%s, %d) = %d, %v, want %d", tt.from, tt.issuer, tt.code, tt.amount, fee, err, tt.want)
+			}
+		}
+		_, _, err := TransferFee(stub, "xiaozhang", "BBB", "B1", math.MaxInt64)
+		if e, ok := err.(*CodedError); !ok || e.Code != CodeInvalidArgument {
+			t.Errorf("TransferFee overflow: got %v, want %s", err, CodeInvalidArgument)
+		}
+		return nil
+	})
+}
+
+func TestVestedAmount(t *testing.T) {
+	v := Vesting{Total: 1000, Start: 100, Cliff: 10, Duration: 40}
+	tests := []struct {
+		now, vested, unlocked int64
+	}{
+		{100, 0, 0},
+		{109, 0, 0},
+		{110, 250, 50},
+		{130, 750, 550},
+		{140, 1000, 800},
+	}
+	for _, tt := range tests {
+		if got := VestedAmount(v, tt.now); got != tt.vested {
+			t.Errorf("VestedAmount(%d) = %d, want %d", tt.now, got, tt.vested)
+		}
+		// 帐户已转出200，解锁数量为持有量减去锁定数量
+		if s := NewVestingStatus(v, 800, tt.now); s.Unlocked != tt.unlocked {
+			t.Errorf("NewVestingStatus(%d).Unlocked = %d, want %d", tt.now, s.Unlocked, tt.unlocked)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package assetcore
+
+import (
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// Hold 资产冻结
+// 冻结的资产从账户持有量中扣除，只能由受益帐户收取或释放，到期后账户所有者可以收回
+type Hold struct {
+	DocType string `json:"docType,omitempty"` //文档类型
+	Name    string `json:"name"`              //冻结名称，同一帐户下唯一
+	Account string `json:"account"`           //被冻结帐户
+	To      string `json:"to"`                //受益帐户
+	Issuer  string `json:"issuer"`            //资产发行机构
+	Code    string `json:"code"`              //资产代码
+	Amount  int64  `json:"amount"`            //冻结数量
+	Expiry  int64  `json:"expiry"`            //到期时间（秒）
+}
+
+// CheckHold 获取冻结信息，并判断是否存在
+func CheckHold(stub shim.ChaincodeStubInterface, id, name string) (h Hold, isExist bool, key string, err error) {
+	key, err = stub.CreateCompositeKey(HoldObjectType, []string{id, name})
+	if err != nil {
+		return h, false, key, err
+	}
+	_, _, err = Load(stub, key, &h)
+	return h, h.Name != "", key, err
+}
@@ -0,0 +1,83 @@
+package assetcore
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/chaincode/lib/cid"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// Identity 调用者身份
+type Identity struct {
+	MSPID   string `json:"mspId"`   //所属组织MSP ID
+	Subject string `json:"subject"` //证书主题
+}
+
+// Role 由MSP ID及证书属性确定的角色
+type Role struct {
+	MSPID     string `json:"mspId"`     //所属组织MSP ID
+	AttrName  string `json:"attrName"`  //证书属性名（可选）
+	AttrValue string `json:"attrValue"` //证书属性值（可选）
+}
+
+// Caller 获取交易提交者的身份
+func Caller(stub shim.ChaincodeStubInterface) (Identity, error) {
+	var id Identity
+
+	ci, err := cid.New(stub)
+	if err != nil {
+		return id, err
+	}
+	id.MSPID, err = ci.GetMSPID()
+	if err != nil {
+		return id, err
+	}
+	cert, err := ci.GetX509Certificate()
+	if err != nil {
+		return id, err
+	}
+	if cert == nil {
+		return id, fmt.Errorf("creator has no x509 certificate")
+	}
+	id.Subject = cert.Subject.String()
+
+	return id, nil
+}
+
+// CheckOwner 校验交易提交者是否为帐户所有者
+func CheckOwner(stub shim.ChaincodeStubInterface, owner Identity, accountId string) error {
+	if owner.MSPID == "" || owner.Subject == "" {
+		return fmt.Errorf("account=%s has no owner", accountId)
+	}
+
+	caller, err := Caller(stub)
+	if err != nil {
+		return fmt.Errorf("get caller identity error:%s", err)
+	}
+	if caller != owner {
+		return fmt.Errorf("caller mspId=%s&subject=%s is not the owner of account=%s", caller.MSPID, caller.Subject, accountId)
+	}
+
+	return nil
+}
+
+// CheckRole 校验交易提交者是否属于该角色
+func CheckRole(stub shim.ChaincodeStubInterface, role Role) error {
+	caller, err := Caller(stub)
+	if err != nil {
+		return fmt.Errorf("get caller identity error:%s", err)
+	}
+	if role.MSPID != "" && role.MSPID != caller.MSPID {
+		return fmt.Errorf("caller mspId=%s is not %s", caller.MSPID, role.MSPID)
+	}
+	if role.AttrName != "" {
+		val, found, err := cid.GetAttributeValue(stub, role.AttrName)
+		if err != nil {
+			return fmt.Errorf("get caller attribute=%s error:%s", role.AttrName, err)
+		}
+		if !found || val != role.AttrValue {
+			return fmt.Errorf("caller attribute %s=%s is not %s", role.AttrName, val, role.AttrValue)
+		}
+	}
+	return nil
+}
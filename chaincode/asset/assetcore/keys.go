@@ -0,0 +1,37 @@
+package assetcore
+
+// 复合键的对象类型，cc1、cc2使用同一组key，升级后无需改写即可读取
+const (
+	AssetObjectType           = "Asset~issuer~code"
+	IssuerObjectType          = "Issuer~name"
+	ConfigObjectType          = "Config~name"
+	SupplyObjectType          = "Supply~issuer~code"
+	HoldObjectType            = "Hold~id~name"
+	FreezeObjectType          = "Freeze~id"
+	HaltObjectType            = "Halt~issuer~code"
+	AllowanceObjectType       = "Allowance~owner~spender~issuer~code"
+	ApprovalPolicyObjectType  = "ApprovalPolicy~issuer~code"
+	PendingTransferObjectType = "PendingTransfer~id"
+	VestingObjectType         = "Vesting~id~issuer~code"
+	FeeObjectType             = "Fee~issuer~code"
+	KYCObjectType             = "KYC~id"
+	EligibilityObjectType     = "Eligibility~issuer~code"
+)
+
+// 文档类型，保存在每个文档的docType字段中，用于CouchDB富查询
+const (
+	AccountDocType         = "account"
+	AssetDocType           = "asset"
+	IssuerDocType          = "issuer"
+	ConfigDocType          = "config"
+	SupplyDocType          = "supply"
+	HoldDocType            = "hold"
+	RestrictionDocType     = "restriction"
+	AllowanceDocType       = "allowance"
+	ApprovalPolicyDocType  = "approvalPolicy"
+	PendingTransferDocType = "pendingTransfer"
+	VestingDocType         = "vesting"
+	FeeDocType             = "fee"
+	KYCDocType             = "kyc"
+	EligibilityDocType     = "eligibility"
+)
@@ -0,0 +1,135 @@
+package assetcore
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// KYC认证状态
+const (
+	KYCVerified  = "verified"  //已认证
+	KYCSuspended = "suspended" //暂停
+	KYCRevoked   = "revoked"   //撤销
+)
+
+// KYC 帐户的KYC登记，由合规角色维护
+// 可以在开户前按帐户id登记
+type KYC struct {
+	DocType string   `json:"docType,omitempty"` //文档类型
+	ID      string   `json:"id"`                //帐户id
+	Status  string   `json:"status"`            //认证状态：verified、suspended或revoked
+	Tier    int      `json:"tier"`              //认证等级
+	Expiry  int64    `json:"expiry"`            //到期时间（秒），0为不过期
+	By      Identity `json:"by"`                //操作者身份
+	Time    int64    `json:"time"`              //操作时间（秒）
+}
+
+// Eligibility 资产持有资格，接收该资产的帐户必须已认证且等级不低于MinTier
+// Issuer和Code为空时为开户资格
+type Eligibility struct {
+	DocType string `json:"docType,omitempty"` //文档类型
+	Issuer  string `json:"issuer"`            //资产发行机构
+	Code    string `json:"code"`              //资产代码
+	MinTier int    `json:"minTier"`           //最低认证等级
+}
+
+// SetKYC 校验合规角色，记录操作者及时间并保存帐户的KYC登记
+func SetKYC(stub shim.ChaincodeStubInterface, k *KYC) error {
+	err := CheckCompliance(stub)
+	if err != nil {
+		return NewError(CodeUnauthorized, "Permission denied:%s", err)
+	}
+
+	k.DocType = KYCDocType
+	k.By, err = Caller(stub)
+	if err != nil {
+		return fmt.Errorf("Get caller identity error:%s", err)
+	}
+	k.Time, err = TxTime(stub)
+	if err != nil {
+		return fmt.Errorf("GetTxTimestamp error:%s", err)
+	}
+
+	key, err := stub.CreateCompositeKey(KYCObjectType, []string{k.ID})
+	if err != nil {
+		return err
+	}
+	err = Save(stub, key, k)
+	if err != nil {
+		return fmt.Errorf("save KYC=%+v error:%s", *k, err)
+	}
+	return nil
+}
+
+// CheckKYC 获取帐户的KYC登记，并判断是否存在
+func CheckKYC(stub shim.ChaincodeStubInterface, id string) (k KYC, isExist bool, err error) {
+	key, err := stub.CreateCompositeKey(KYCObjectType, []string{id})
+	if err != nil {
+		return k, false, err
+	}
+	_, isExist, err = Load(stub, key, &k)
+	if isExist {
+		k.DocType = KYCDocType
+	}
+	return k, isExist, err
+}
+
+// SetEligibility 校验合规角色并保存资产的持有资格，等级为0时取消限制
+func SetEligibility(stub shim.ChaincodeStubInterface, r Eligibility) error {
+	err := CheckCompliance(stub)
+	if err != nil {
+		return NewError(CodeUnauthorized, "Permission denied:%s", err)
+	}
+
+	key, err := stub.CreateCompositeKey(EligibilityObjectType, []string{r.Issuer, r.Code})
+	if err != nil {
+		return err
+	}
+	if r.MinTier == 0 {
+		err = stub.DelState(key)
+	} else {
+		r.DocType = EligibilityDocType
+		err = Save(stub, key, r)
+	}
+	if err != nil {
+		return fmt.Errorf("save eligibility=%+v error:%s", r, err)
+	}
+	return nil
+}
+
+// CheckEligible 校验帐户均有资格持有该资产，未设置持有资格时不限制
+// issuer和code为空时校验开户资格
+func CheckEligible(stub shim.ChaincodeStubInterface, issuer, code string, ids ...string) error {
+	key, err := stub.CreateCompositeKey(EligibilityObjectType, []string{issuer, code})
+	if err != nil {
+		return err
+	}
+	var r Eligibility
+	_, isExist, err := Load(stub, key, &r)
+	if err != nil {
+		return fmt.Errorf("Check eligibility of issuer=%s&code=%s error:%s", issuer, code, err)
+	} else if !isExist {
+		return nil
+	}
+
+	now, err := TxTime(stub)
+	if err != nil {
+		return fmt.Errorf("GetTxTimestamp error:%s", err)
+	}
+	for _, id := range ids {
+		k, isExist, err := CheckKYC(stub, id)
+		if err != nil {
+			return fmt.Errorf("Check KYC of account=%s error:%s", id, err)
+		} else if !isExist {
+			return NewError(CodeNotEligible, "Account=%s has no KYC, tier %v required", id, r.MinTier)
+		} else if k.Status != KYCVerified {
+			return NewError(CodeNotEligible, "Account=%s KYC is %s", id, k.Status)
+		} else if k.Expiry != 0 && now >= k.Expiry {
+			return NewError(CodeNotEligible, "Account=%s KYC expired at %v", id, k.Expiry)
+		} else if k.Tier < r.MinTier {
+			return NewError(CodeNotEligible, "Account=%s KYC tier %v < required tier %v", id, k.Tier, r.MinTier)
+		}
+	}
+	return nil
+}
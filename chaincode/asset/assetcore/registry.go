@@ -0,0 +1,144 @@
+package assetcore
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// Issuer 资产发行机构
+type Issuer struct {
+	DocType string `json:"docType,omitempty"` //文档类型
+	Name    string `json:"name"`              //发行机构名称，如AAA
+	Account string `json:"account,omitempty"` //资金帐户，cc2派息时从该帐户扣款
+	Role
+}
+
+// InitIssuers 初始化发行机构注册表
+// 参数：第1个Init参数为发行机构列表（JSON数组），为空时不设置
+func InitIssuers(stub shim.ChaincodeStubInterface, args []string) error {
+	if len(args) < 1 || args[0] == "" {
+		return nil
+	}
+
+	var issuers []Issuer
+	err := json.Unmarshal([]byte(args[0]), &issuers)
+	if err != nil {
+		return fmt.Errorf("issuers arguments error:%s", err)
+	}
+
+	for _, v := range issuers {
+		if v.Name == "" || (v.MSPID == "" && v.AttrName == "") {
+			return fmt.Errorf("issuer=%+v error: name can't be nil; mspId or attrName is required", v)
+		}
+		key, err := stub.CreateCompositeKey(IssuerObjectType, []string{v.Name})
+		if err != nil {
+			return err
+		}
+		v.DocType = IssuerDocType
+		err = Save(stub, key, v)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// InitAdmin 初始化管理员角色
+// 参数：第2个Init参数为管理员角色（JSON）
+func InitAdmin(stub shim.ChaincodeStubInterface, args []string) error {
+	return initRole(stub, "admin", args, 1)
+}
+
+// InitCompliance 初始化合规角色，合规角色维护KYC登记及资产持有资格
+// 参数：第3个Init参数为合规角色（JSON）
+func InitCompliance(stub shim.ChaincodeStubInterface, args []string) error {
+	return initRole(stub, "compliance", args, 2)
+}
+
+// 保存第index个Init参数中的角色，参数为空时不设置
+func initRole(stub shim.ChaincodeStubInterface, name string, args []string, index int) error {
+	if len(args) <= index || args[index] == "" {
+		return nil
+	}
+
+	var role Role
+	err := json.Unmarshal([]byte(args[index]), &role)
+	if err != nil {
+		return fmt.Errorf("%s arguments error:%s", name, err)
+	}
+	if role.MSPID == "" && role.AttrName == "" {
+		return fmt.Errorf("%s=%+v error: mspId or attrName is required", name, role)
+	}
+
+	key, err := stub.CreateCompositeKey(ConfigObjectType, []string{name})
+	if err != nil {
+		return err
+	}
+	return Save(stub, key, struct {
+		DocType string `json:"docType"` //文档类型
+		Role
+	}{ConfigDocType, role})
+}
+
+// CheckIssuerInfo 获取发行机构信息，并判断是否存在
+func CheckIssuerInfo(stub shim.ChaincodeStubInterface, name string) (i Issuer, isExist bool, err error) {
+	key, err := stub.CreateCompositeKey(IssuerObjectType, []string{name})
+	if err != nil {
+		return i, false, err
+	}
+	_, _, err = Load(stub, key, &i)
+	return i, i.Name != "", err
+}
+
+// CheckIssuer 校验交易提交者是否有权代表该发行机构发行资产
+func CheckIssuer(stub shim.ChaincodeStubInterface, name string) error {
+	issuer, isExist, err := CheckIssuerInfo(stub, name)
+	if err != nil {
+		return fmt.Errorf("check issuer=%s error:%s", name, err)
+	} else if !isExist {
+		return fmt.Errorf("issuer=%s not registered", name)
+	}
+
+	err = CheckRole(stub, issuer.Role)
+	if err != nil {
+		return fmt.Errorf("%s, not authorized to issue for issuer=%s", err, name)
+	}
+	return nil
+}
+
+// CheckAdmin 校验交易提交者是否为管理员
+func CheckAdmin(stub shim.ChaincodeStubInterface) error {
+	err := checkConfigRole(stub, "admin")
+	if err != nil {
+		return fmt.Errorf("%s, not an admin", err)
+	}
+	return nil
+}
+
+// CheckCompliance 校验交易提交者是否为合规角色
+func CheckCompliance(stub shim.ChaincodeStubInterface) error {
+	err := checkConfigRole(stub, "compliance")
+	if err != nil {
+		return fmt.Errorf("%s, not a compliance officer", err)
+	}
+	return nil
+}
+
+// 校验交易提交者是否属于Init时配置的角色
+func checkConfigRole(stub shim.ChaincodeStubInterface, name string) error {
+	key, err := stub.CreateCompositeKey(ConfigObjectType, []string{name})
+	if err != nil {
+		return err
+	}
+	var role Role
+	_, isExist, err := Load(stub, key, &role)
+	if err != nil {
+		return fmt.Errorf("get %s role error:%s", name, err)
+	} else if !isExist {
+		return fmt.Errorf("%s role not configured", name)
+	}
+	return CheckRole(stub, role)
+}
@@ -0,0 +1,72 @@
+package assetcore
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// Restriction 帐户冻结或资产停牌状态
+// 解除时保留记录并置为失效，历史可通过GetHistoryForKey查询
+type Restriction struct {
+	DocType string   `json:"docType,omitempty"` //文档类型
+	Active  bool     `json:"active"`            //是否生效
+	Reason  string   `json:"reason"`            //原因
+	By      Identity `json:"by"`                //操作者身份
+	Time    int64    `json:"time"`              //操作时间（秒）
+}
+
+// SetRestriction 校验管理员身份，并记录冻结（FreezeObjectType）或停牌（HaltObjectType）状态
+func SetRestriction(stub shim.ChaincodeStubInterface, objectType string, attributes []string, active bool, reason string) error {
+	err := CheckAdmin(stub)
+	if err != nil {
+		return NewError(CodeUnauthorized, "Permission denied:%s", err)
+	}
+
+	by, err := Caller(stub)
+	if err != nil {
+		return fmt.Errorf("get caller identity error:%s", err)
+	}
+	now, err := TxTime(stub)
+	if err != nil {
+		return fmt.Errorf("GetTxTimestamp error:%s", err)
+	}
+
+	key, err := stub.CreateCompositeKey(objectType, attributes)
+	if err != nil {
+		return err
+	}
+	return Save(stub, key, Restriction{DocType: RestrictionDocType, Active: active, Reason: reason, By: by, Time: now})
+}
+
+// CheckRestriction 获取冻结或停牌状态
+func CheckRestriction(stub shim.ChaincodeStubInterface, objectType string, attributes []string) (r Restriction, err error) {
+	key, err := stub.CreateCompositeKey(objectType, attributes)
+	if err != nil {
+		return r, err
+	}
+	_, _, err = Load(stub, key, &r)
+	return r, err
+}
+
+// CheckActive 校验资产未停牌且帐户均未冻结
+// issuer为空时只校验帐户
+func CheckActive(stub shim.ChaincodeStubInterface, issuer, code string, ids ...string) error {
+	if issuer != "" {
+		r, err := CheckRestriction(stub, HaltObjectType, []string{issuer, code})
+		if err != nil {
+			return fmt.Errorf("Check asset issuer=%s&code=%s halt error:%s", issuer, code, err)
+		} else if r.Active {
+			return NewError(CodeRestricted, "Asset issuer=%s&code=%s is halted: %s", issuer, code, r.Reason)
+		}
+	}
+	for _, id := range ids {
+		r, err := CheckRestriction(stub, FreezeObjectType, []string{id})
+		if err != nil {
+			return fmt.Errorf("Check account=%s freeze error:%s", id, err)
+		} else if r.Active {
+			return NewError(CodeRestricted, "Account=%s is frozen: %s", id, r.Reason)
+		}
+	}
+	return nil
+}
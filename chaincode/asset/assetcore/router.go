@@ -0,0 +1,226 @@
+package assetcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// 参数类型
+const (
+	ArgString = "string" //字符串
+	ArgInt    = "int"    //整数
+	ArgJSON   = "json"   //JSON对象，或每个元素均为对象的JSON数组
+)
+
+// Arg 函数参数说明
+type Arg struct {
+	Name     string   `json:"name"`               //参数名
+	Type     string   `json:"type"`               //参数类型：string、int或json
+	Desc     string   `json:"desc,omitempty"`     //说明
+	Optional bool     `json:"optional,omitempty"` //可以省略，只能位于必填参数之后
+	Empty    bool     `json:"empty,omitempty"`    //字符串可以为空
+	Min      *int64   `json:"min,omitempty"`      //整数最小值
+	Max      *int64   `json:"max,omitempty"`      //整数最大值
+	Fields   []string `json:"fields,omitempty"`   //JSON参数的必填字段，嵌套字段以.分隔
+}
+
+// Str 字符串参数，默认不能为空
+func Str(name, desc string) Arg {
+	return Arg{Name: name, Type: ArgString, Desc: desc}
+}
+
+// Int 整数参数
+func Int(name, desc string) Arg {
+	return Arg{Name: name, Type: ArgInt, Desc: desc}
+}
+
+// JSON JSON参数，fields为必填字段
+func JSON(name, desc string, fields ...string) Arg {
+	return Arg{Name: name, Type: ArgJSON, Desc: desc, Fields: fields}
+}
+
+// Opt 参数可以省略
+func (a Arg) Opt() Arg {
+	a.Optional = true
+	return a
+}
+
+// AllowEmpty 字符串参数可以为空
+func (a Arg) AllowEmpty() Arg {
+	a.Empty = true
+	return a
+}
+
+// AtLeast 整数参数不小于min
+func (a Arg) AtLeast(min int64) Arg {
+	a.Min = &min
+	return a
+}
+
+// Range 整数参数在[min, max]之间
+func (a Arg) Range(min, max int64) Arg {
+	a.Min = &min
+	a.Max = &max
+	return a
+}
+
+// 校验一个参数
+func (a Arg) check(value string) error {
+	switch a.Type {
+	case ArgInt:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("must be an integer")
+		}
+		if a.Min != nil && n < *a.Min {
+			return fmt.Errorf("must not be less than %d", *a.Min)
+		}
+		if a.Max != nil && n > *a.Max {
+			return fmt.Errorf("must not be greater than %d", *a.Max)
+		}
+	case ArgJSON:
+		var v interface{}
+		err := json.Unmarshal([]byte(value), &v)
+		if err != nil {
+			return fmt.Errorf("must be JSON: %s", err)
+		}
+		list, isList := v.([]interface{})
+		if !isList {
+			list = []interface{}{v}
+		}
+		for i, o := range list {
+			for _, field := range a.Fields {
+				if hasField(o, field) {
+					continue
+				}
+				if isList {
+					return fmt.Errorf("[%d].%s is required", i, field)
+				}
+				return fmt.Errorf("%s is required", field)
+			}
+		}
+	default:
+		if value == "" && !a.Empty {
+			return fmt.Errorf("can't be nil")
+		}
+	}
+	return nil
+}
+
+// 判断JSON对象是否包含非空字段，path以.分隔嵌套字段
+func hasField(v interface{}, path string) bool {
+	for _, name := range strings.Split(path, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		v = m[name]
+	}
+	return v != nil && v != ""
+}
+
+// Handler 函数的处理方法
+type Handler func(stub shim.ChaincodeStubInterface, args []string) pb.Response
+
+// Function 可调用的函数
+type Function struct {
+	Name    string  `json:"name"`            //函数名
+	Desc    string  `json:"desc"`            //说明
+	Args    []Arg   `json:"args"`            //参数
+	Query   bool    `json:"query,omitempty"` //只读查询
+	Handler Handler `json:"-"`               //处理方法
+}
+
+// ArgError 函数名或参数错误，错误码为INVALID_ARGUMENT
+type ArgError struct {
+	Function string //函数名
+	Arg      string //出错的参数
+	Message  string //错误信息
+}
+
+func (e *ArgError) Error() string {
+	return e.Message
+}
+
+// Check 校验参数个数及每个参数
+func (f *Function) Check(args []string) error {
+	required := 0
+	for _, a := range f.Args {
+		if !a.Optional {
+			required++
+		}
+	}
+	if len(args) < required || len(args) > len(f.Args) {
+		return &ArgError{
+			Function: f.Name,
+			Message:  fmt.Sprintf("Incorrect number of arguments. Expecting %s, got %d", arity(required, len(f.Args)), len(args)),
+		}
+	}
+	for i, v := range args {
+		err := f.Args[i].check(v)
+		if err != nil {
+			return &ArgError{Function: f.Name, Arg: f.Args[i].Name, Message: fmt.Sprintf("%s %s", f.Args[i].Name, err)}
+		}
+	}
+	return nil
+}
+
+func arity(min, max int) string {
+	if min == max {
+		return strconv.Itoa(min)
+	}
+	return fmt.Sprintf("%d to %d", min, max)
+}
+
+// Router 按函数名分发调用，调用前统一校验参数
+type Router struct {
+	functions []*Function
+	byName    map[string]*Function
+}
+
+// NewRouter 创建路由，并注册列出所有函数的ListFunctions
+func NewRouter(functions []Function) *Router {
+	r := &Router{byName: map[string]*Function{}}
+	for i := range functions {
+		r.add(&functions[i])
+	}
+	r.add(&Function{Name: "ListFunctions", Desc: "列出可调用的函数及参数", Query: true, Handler: r.listFunctions})
+	return r
+}
+
+func (r *Router) add(f *Function) {
+	if _, ok := r.byName[f.Name]; ok {
+		panic("duplicate function " + f.Name)
+	}
+	if f.Args == nil {
+		f.Args = []Arg{}
+	}
+	r.functions = append(r.functions, f)
+	r.byName[f.Name] = f
+}
+
+// Invoke 校验参数并调用函数
+func (r *Router) Invoke(stub shim.ChaincodeStubInterface, function string, args []string) pb.Response {
+	f, ok := r.byName[function]
+	if !ok {
+		return Error(&ArgError{Function: function, Message: "Invalid invoke function name."})
+	}
+	err := f.Check(args)
+	if err != nil {
+		return Error(err)
+	}
+	return f.Handler(stub, args)
+}
+
+func (r *Router) listFunctions(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	b, err := json.Marshal(r.functions)
+	if err != nil {
+		return Errorf("Marshal functions error:%s", err)
+	}
+	return shim.Success(b)
+}
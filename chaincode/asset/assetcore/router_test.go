@@ -0,0 +1,110 @@
+package assetcore
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ChainNova/samples/chaincode/asset/memstub"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+func echo(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	return shim.Success([]byte(strings.Join(args, ",")))
+}
+
+func TestFunctionCheck(t *testing.T) {
+	f := Function{Name: "Transfer", Handler: echo, Args: []Arg{
+		Str("from", ""),
+		Str("memo", "").AllowEmpty(),
+		Int("count", "").Range(1, 100),
+		JSON("legs", "", "from", "asset.code").Opt(),
+	}}
+	tests := []struct {
+		args []string
+		ok   bool
+		arg  string //出错的参数
+	}{
+		{[]string{"xiaozhang", "", "10"}, true, ""},
+		{[]string{"xiaozhang", "", "10", `{"from":"a","asset":{"code":"A1"}}`}, true, ""},
+		{[]string{"xiaozhang", "", "10", `[{"from":"a","asset":{"code":"A1"}}]`}, true, ""},
+		{[]string{"xiaozhang", ""}, false, ""},
+		{[]string{"xiaozhang", "", "10", "{}", "extra"}, false, ""},
+		{[]string{"", "", "10"}, false, "from"},
+		{[]string{"xiaozhang", "", "ten"}, false, "count"},
+		{[]string{"xiaozhang", "", "0"}, false, "count"},
+		{[]string{"xiaozhang", "", "101"}, false, "count"},
+		{[]string{"xiaozhang", "", "10", `{"from":"a"}`}, false, "legs"},
+		{[]string{"xiaozhang", "", "10", `[{"from":"a","asset":{"code":"A1"}},{"from":"b"}]`}, false, "legs"},
+		{[]string{"xiaozhang", "", "10", `legs`}, false, "legs"},
+	}
+	for _, tt := range tests {
+		err := f.Check(tt.args)
+		if (err == nil) != tt.ok {
+			t.Errorf("Check(%q): got %v, want ok=%v", tt.args, err, tt.ok)
+			continue
+		}
+		if err == nil {
+			continue
+		}
+		e, isArgError := err.(*ArgError)
+		if !isArgError || e.Function != "Transfer" || e.Arg != tt.arg {
+			t.Errorf("Check(%q): got %v, want ArgError on %q", tt.args, err, tt.arg)
+		}
+	}
+}
+
+// routerChaincode 以函数名及参数调用路由
+type routerChaincode struct {
+	router *Router
+}
+
+func (c *routerChaincode) Init(stub shim.ChaincodeStubInterface) pb.Response {
+	return shim.Success(nil)
+}
+
+func (c *routerChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
+	function, args := stub.GetFunctionAndParameters()
+	return c.router.Invoke(stub, function, args)
+}
+
+func TestRouter(t *testing.T) {
+	cc := &routerChaincode{router: NewRouter([]Function{
+		{Name: "Echo", Desc: "回显参数", Handler: echo, Args: []Arg{Str("a", ""), Int("b", "").Opt()}},
+	})}
+	stub := memstub.New("assetcore", cc)
+
+	if resp := stub.Invoke("Echo", "x", "1"); resp.Status != shim.OK || string(resp.Payload) != "x,1" {
+		t.Errorf("Echo: got %d %q %q", resp.Status, resp.Message, resp.Payload)
+	}
+
+	var e CodedError
+	resp := stub.Invoke("Echo", "x", "y")
+	if resp.Status != shim.ERRORTHRESHOLD || json.Unmarshal([]byte(resp.Message), &e) != nil || e.Code != CodeInvalidArgument || e.Details["function"] != "Echo" || e.Details["arg"] != "b" {
+		t.Errorf("Echo bad arg: got %d %q", resp.Status, resp.Message)
+	}
+	e = CodedError{}
+	resp = stub.Invoke("Unknown")
+	if resp.Status != shim.ERRORTHRESHOLD || json.Unmarshal([]byte(resp.Message), &e) != nil || e.Code != CodeInvalidArgument || e.Details["function"] != "Unknown" {
+		t.Errorf("Unknown: got %d %q", resp.Status, resp.Message)
+	}
+
+	resp = stub.Invoke("ListFunctions")
+	var functions []Function
+	if resp.Status != shim.OK || json.Unmarshal(resp.Payload, &functions) != nil {
+		t.Fatalf("ListFunctions: got %d %q", resp.Status, resp.Message)
+	}
+	if len(functions) != 2 || functions[0].Name != "Echo" || len(functions[0].Args) != 2 || !functions[0].Args[1].Optional || functions[1].Name != "ListFunctions" || !functions[1].Query {
+		t.Errorf("ListFunctions: got %s", resp.Payload)
+	}
+}
+
+func TestRouterDuplicate(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("NewRouter: want panic on duplicate function")
+		}
+	}()
+	NewRouter([]Function{{Name: "ListFunctions", Handler: echo}})
+}
@@ -0,0 +1,157 @@
+package assetcore
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// HoldingObjectType cc2持有量的复合键
+const HoldingObjectType = "AccountAsset~id~issuer~code"
+
+// HoldingDocType cc2持有量的文档类型
+const HoldingDocType = "holding"
+
+// Store 帐户持有量的存储模型
+// Fabric的交易内读不到本交易的写入，同一交易中多次修改同一帐户时应先在内存中合并
+type Store interface {
+	// Holding 获取帐户某类资产的持有量
+	Holding(stub shim.ChaincodeStubInterface, id, issuer, code string) (int64, error)
+	// Holdings 获取帐户持有的全部资产
+	Holdings(stub shim.ChaincodeStubInterface, id string) ([]*Asset, error)
+	// SetHolding 保存帐户某类资产的持有量
+	SetHolding(stub shim.ChaincodeStubInterface, id, issuer, code string, amount int64) error
+}
+
+// AccountStore cc0、cc1的存储模型：持有量保存在以帐户id为key的帐户文档中
+type AccountStore struct{}
+
+// Holding 获取帐户某类资产的持有量，帐户不存在时返回错误
+func (AccountStore) Holding(stub shim.ChaincodeStubInterface, id, issuer, code string) (int64, error) {
+	_, a, isExist, err := CheckAccount(stub, id)
+	if err != nil {
+		return 0, err
+	} else if !isExist {
+		return 0, AccountNotFound(id)
+	}
+	return a.Amount(issuer, code), nil
+}
+
+// Holdings 获取帐户持有的全部资产，帐户不存在时返回错误
+func (AccountStore) Holdings(stub shim.ChaincodeStubInterface, id string) ([]*Asset, error) {
+	_, a, isExist, err := CheckAccount(stub, id)
+	if err != nil {
+		return nil, err
+	} else if !isExist {
+		return nil, AccountNotFound(id)
+	}
+	return a.Assets, nil
+}
+
+// SetHolding 修改帐户文档中某类资产的持有量，帐户不存在时返回错误
+// 帐户文档中的其他字段原样保留
+func (AccountStore) SetHolding(stub shim.ChaincodeStubInterface, id, issuer, code string, amount int64) error {
+	var doc map[string]json.RawMessage
+	_, isExist, err := Load(stub, id, &doc)
+	if err != nil {
+		return err
+	} else if !isExist {
+		return AccountNotFound(id)
+	}
+
+	var assets []*Asset
+	if raw, ok := doc["assets"]; ok {
+		err = json.Unmarshal(raw, &assets)
+		if err != nil {
+			return err
+		}
+	}
+	a := Account{Assets: assets}
+	a.Credit(issuer, code, amount-a.Amount(issuer, code))
+	doc["assets"], err = json.Marshal(a.Assets)
+	if err != nil {
+		return err
+	}
+	return Save(stub, id, doc)
+}
+
+// Holding cc2持有量文档，以AccountAsset~id~issuer~code为key
+type Holding struct {
+	DocType string `json:"docType,omitempty"` //文档类型
+	ID      string `json:"id"`                //帐户id
+	Issuer  string `json:"issuer"`            //资产发行机构
+	Code    string `json:"code"`              //资产代码
+	Amount  int64  `json:"amount"`            //持有数量
+}
+
+// HoldingStore cc2的存储模型：每类资产的持有量单独保存在复合键下
+type HoldingStore struct{}
+
+// Holding 获取帐户某类资产的持有量，没有持有时为0
+func (HoldingStore) Holding(stub shim.ChaincodeStubInterface, id, issuer, code string) (int64, error) {
+	key, err := stub.CreateCompositeKey(HoldingObjectType, []string{id, issuer, code})
+	if err != nil {
+		return 0, err
+	}
+	b, err := stub.GetState(key)
+	if err != nil || len(b) == 0 {
+		return 0, err
+	}
+	return ParseHolding(b)
+}
+
+// Holdings 获取帐户持有的全部资产，无法解析的持有量被跳过
+func (HoldingStore) Holdings(stub shim.ChaincodeStubInterface, id string) ([]*Asset, error) {
+	it, err := stub.GetStateByPartialCompositeKey(HoldingObjectType, []string{id})
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+
+	assets := []*Asset{}
+	for it.HasNext() {
+		kv, err := it.Next()
+		if err != nil {
+			return nil, err
+		}
+		amount, err := ParseHolding(kv.Value)
+		if err != nil {
+			fmt.Println("ParseHolding error:", err, string(kv.Value))
+			continue
+		}
+		_, parts, err := stub.SplitCompositeKey(kv.Key)
+		if err != nil {
+			fmt.Println("SplitCompositeKey error:", err)
+			continue
+		}
+		assets = append(assets, &Asset{Issuer: parts[1], Code: parts[2], Amount: amount})
+	}
+	return assets, nil
+}
+
+// SetHolding 保存帐户某类资产的持有量
+func (HoldingStore) SetHolding(stub shim.ChaincodeStubInterface, id, issuer, code string, amount int64) error {
+	key, err := stub.CreateCompositeKey(HoldingObjectType, []string{id, issuer, code})
+	if err != nil {
+		return err
+	}
+	return Save(stub, key, Holding{
+		DocType: HoldingDocType,
+		ID:      id,
+		Issuer:  issuer,
+		Code:    code,
+		Amount:  amount,
+	})
+}
+
+// ParseHolding 解析持有量文档，兼容早期版本保存的整数
+func ParseHolding(b []byte) (int64, error) {
+	if len(b) > 0 && b[0] == '{' {
+		var h Holding
+		err := json.Unmarshal(b, &h)
+		return h.Amount, err
+	}
+	return strconv.ParseInt(string(b), 10, 64)
+}
@@ -0,0 +1,139 @@
+package assetcore
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ChainNova/samples/chaincode/asset/memstub"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// funcChaincode 每笔交易执行f
+type funcChaincode struct {
+	f func(stub shim.ChaincodeStubInterface) error
+}
+
+func (c *funcChaincode) Init(stub shim.ChaincodeStubInterface) pb.Response {
+	return shim.Success(nil)
+}
+
+func (c *funcChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
+	if err := c.f(stub); err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success(nil)
+}
+
+// 在一笔交易中执行f
+func tx(t *testing.T, stub *memstub.Stub, cc *funcChaincode, f func(stub shim.ChaincodeStubInterface) error) {
+	cc.f = f
+	if resp := stub.Invoke("tx"); resp.Status != shim.OK {
+		t.Fatal(resp.Message)
+	}
+}
+
+func TestStores(t *testing.T) {
+	tests := []struct {
+		name  string
+		store Store
+	}{
+		{"AccountStore", AccountStore{}},
+		{"HoldingStore", HoldingStore{}},
+	}
+	for _, tt := range tests {
+		cc := &funcChaincode{}
+		stub := memstub.New("assetcore", cc)
+
+		tx(t, stub, cc, func(stub shim.ChaincodeStubInterface) error {
+			return Save(stub, "xiaozhang", map[string]interface{}{"accountId": "xiaozhang", "assets": []*Asset{}, "owner": Identity{MSPID: "Org1MSP"}, "balance": 1000})
+		})
+		tx(t, stub, cc, func(stub shim.ChaincodeStubInterface) error {
+			return tt.store.SetHolding(stub, "xiaozhang", "AAA", "A1", 100)
+		})
+		tx(t, stub, cc, func(stub shim.ChaincodeStubInterface) error {
+			return tt.store.SetHolding(stub, "xiaozhang", "BBB", "B1", 200)
+		})
+		tx(t, stub, cc, func(stub shim.ChaincodeStubInterface) error {
+			return tt.store.SetHolding(stub, "xiaozhang", "AAA", "A1", 50)
+		})
+
+		tx(t, stub, cc, func(stub shim.ChaincodeStubInterface) error {
+			if n, err := tt.store.Holding(stub, "xiaozhang", "AAA", "A1"); err != nil || n != 50 {
+				t.Errorf("%s: Holding A1 = %d, %v, want 50", tt.name, n, err)
+			}
+			if n, err := tt.store.Holding(stub, "xiaozhang", "CCC", "C1"); err != nil || n != 0 {
+				t.Errorf("%s: Holding C1 = %d, %v, want 0", tt.name, n, err)
+			}
+			assets, err := tt.store.Holdings(stub, "xiaozhang")
+			if err != nil || len(assets) != 2 {
+				t.Fatalf("%s: Holdings = %v, %v", tt.name, assets, err)
+			}
+			got := map[string]int64{}
+			for _, a := range assets {
+				got[a.Issuer+"/"+a.Code] = a.Amount
+			}
+			if got["AAA/A1"] != 50 || got["BBB/B1"] != 200 {
+				t.Errorf("%s: Holdings = %v", tt.name, got)
+			}
+			return nil
+		})
+
+		// 帐户文档中的其他字段不受影响
+		var doc struct {
+			Owner   Identity `json:"owner"`
+			Balance int      `json:"balance"`
+		}
+		if err := json.Unmarshal(stub.State("xiaozhang"), &doc); err != nil || doc.Owner.MSPID != "Org1MSP" || doc.Balance != 1000 {
+			t.Errorf("%s: account = %s", tt.name, stub.State("xiaozhang"))
+		}
+	}
+}
+
+func TestAccountStoreNotFound(t *testing.T) {
+	cc := &funcChaincode{}
+	stub := memstub.New("assetcore", cc)
+	tx(t, stub, cc, func(stub shim.ChaincodeStubInterface) error {
+		err := AccountStore{}.SetHolding(stub, "xiaoli", "AAA", "A1", 10)
+		if _, ok := err.(*NotFoundError); !ok {
+			t.Errorf("SetHolding: got %v, want NotFoundError", err)
+		}
+		_, err = AccountStore{}.Holding(stub, "xiaoli", "AAA", "A1")
+		if err == nil || err.Error() != "Account=xiaoli not exists." {
+			t.Errorf("Holding: got %v", err)
+		}
+		return nil
+	})
+}
+
+func TestParseHolding(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int64
+		ok    bool
+	}{
+		{`{"docType":"holding","id":"xiaozhang","issuer":"AAA","code":"A1","amount":100}`, 100, true},
+		{`100`, 100, true},
+		{`abc`, 0, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseHolding([]byte(tt.value))
+		if got != tt.want || (err == nil) != tt.ok {
+			t.Errorf("ParseHolding(%s) = %d, %v", tt.value, got, err)
+		}
+	}
+}
+
+func TestAccountLegacyField(t *testing.T) {
+	// 早期版本以AccountId为字段名保存
+	var a Account
+	if err := json.Unmarshal([]byte(`{"AccountId":"xiaozhang","assets":[]}`), &a); err != nil || a.AccountId != "xiaozhang" {
+		t.Errorf("legacy account: got %+v, %v", a, err)
+	}
+	b, _ := json.Marshal(Account{AccountId: "xiaozhang"})
+	var m map[string]interface{}
+	json.Unmarshal(b, &m)
+	if m["accountId"] != "xiaozhang" {
+		t.Errorf("Marshal account: got %s", b)
+	}
+}
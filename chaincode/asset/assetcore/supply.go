@@ -0,0 +1,32 @@
+package assetcore
+
+import (
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// Supply cc1的资产发行总量记录，以Supply~issuer~code为key，由增加资产及赎回资产维护
+// cc2的发行总量保存在资产记录中
+type Supply struct {
+	DocType  string `json:"docType,omitempty"` //文档类型
+	Issuer   string `json:"issuer"`            //资产发行机构
+	Code     string `json:"code"`              //资产代码
+	Issued   int64  `json:"issued"`            //累计发行数量
+	Redeemed int64  `json:"redeemed"`          //累计赎回销毁数量
+}
+
+// AddSupply 修改资产发行总量记录
+func AddSupply(stub shim.ChaincodeStubInterface, issuer, code string, issued, redeemed int64) error {
+	key, err := stub.CreateCompositeKey(SupplyObjectType, []string{issuer, code})
+	if err != nil {
+		return err
+	}
+	s := Supply{Issuer: issuer, Code: code}
+	_, _, err = Load(stub, key, &s)
+	if err != nil {
+		return err
+	}
+	s.DocType = SupplyDocType
+	s.Issued = s.Issued + issued
+	s.Redeemed = s.Redeemed + redeemed
+	return Save(stub, key, s)
+}
@@ -0,0 +1,85 @@
+package assetcore
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// Vesting 归属计划
+// 授予的资产在Start+Cliff之前全部锁定，之后按时间线性解锁，Start+Duration时全部解锁
+type Vesting struct {
+	DocType  string `json:"docType,omitempty"` //文档类型
+	ID       string `json:"id"`                //帐户id
+	Issuer   string `json:"issuer"`            //资产发行机构
+	Code     string `json:"code"`              //资产代码
+	Total    int64  `json:"total"`             //授予数量
+	Start    int64  `json:"start"`             //开始时间（秒）
+	Cliff    int64  `json:"cliff"`             //锁定期（秒）
+	Duration int64  `json:"duration"`          //归属总时长（秒）
+}
+
+// VestingStatus 归属状态
+type VestingStatus struct {
+	Vesting
+	Vested   int64 `json:"vested"`   //已解锁的授予数量
+	Locked   int64 `json:"locked"`   //锁定数量
+	Balance  int64 `json:"balance"`  //帐户持有数量
+	Unlocked int64 `json:"unlocked"` //可转出数量
+}
+
+// CheckVesting 获取归属计划，不存在时返回空计划
+func CheckVesting(stub shim.ChaincodeStubInterface, id, issuer, code string) (v Vesting, key string, err error) {
+	key, err = stub.CreateCompositeKey(VestingObjectType, []string{id, issuer, code})
+	if err != nil {
+		return v, key, err
+	}
+	_, isExist, err := Load(stub, key, &v)
+	if isExist {
+		v.DocType = VestingDocType
+	}
+	return v, key, err
+}
+
+// NewVestingStatus 计算某时间的归属状态，balance为帐户持有数量
+func NewVestingStatus(v Vesting, balance, now int64) VestingStatus {
+	s := VestingStatus{Vesting: v, Vested: VestedAmount(v, now), Balance: balance}
+	s.Locked = v.Total - s.Vested
+	s.Unlocked = s.Balance - s.Locked
+	if s.Unlocked < 0 {
+		s.Unlocked = 0
+	}
+	return s
+}
+
+// CheckUnlocked 校验转出后的持有量不少于归属计划锁定的数量，sum为转出前的持有量
+func CheckUnlocked(stub shim.ChaincodeStubInterface, id, issuer, code string, sum, amount int64) error {
+	v, _, err := CheckVesting(stub, id, issuer, code)
+	if err != nil {
+		return fmt.Errorf("Check vesting of account=%s, asset issuer=%s&code=%s error:%s", id, issuer, code, err)
+	} else if v.Total == 0 {
+		return nil
+	}
+	now, err := TxTime(stub)
+	if err != nil {
+		return fmt.Errorf("GetTxTimestamp error:%s", err)
+	}
+	locked := v.Total - VestedAmount(v, now)
+	if sum-amount < locked {
+		return NewError(CodeRestricted, "Account=%s issuer=%s&code=%s&count=%v has %v locked by vesting, unlocked count=%v < transfer count=%v", id, issuer, code, sum, locked, sum-locked, amount)
+	}
+	return nil
+}
+
+// VestedAmount 计算到某时间已解锁的授予数量
+func VestedAmount(v Vesting, now int64) int64 {
+	elapsed := now - v.Start
+	if v.Duration <= 0 || elapsed >= v.Duration {
+		return v.Total
+	}
+	if elapsed < v.Cliff {
+		return 0
+	}
+	// 拆分计算，避免Total*elapsed溢出
+	return v.Total/v.Duration*elapsed + v.Total%v.Duration*elapsed/v.Duration
+}
@@ -4,26 +4,28 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/ChainNova/samples/chaincode/asset/assetcore"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 	pb "github.com/hyperledger/fabric/protos/peer"
 )
 
 // SimpleChaincode ...
 type SimpleChaincode struct {
+	router *assetcore.Router //函数路由表，创建时构建一次
 }
 
-// Asset 资产
-type Asset struct {
-	Issuer string `json:"issuer"` //资产发行机构
-	Code   string `json:"code"`   //资产代码
-	Amount int64  `json:"amount"` //资产数量
+// 创建链码并构建函数路由表
+func newChaincode() *SimpleChaincode {
+	c := &SimpleChaincode{}
+	c.router = assetcore.NewRouter(c.functions())
+	return c
 }
 
+// Asset 资产
+type Asset = assetcore.Asset
+
 // Account 账户
-type Account struct {
-	AccountId string   `json:""accountId` //帐户id
-	Assets    []*Asset `json:"assets"`    //该帐户的资产列表
-}
+type Account = assetcore.Account
 
 // Init ...
 func (c *SimpleChaincode) Init(stub shim.ChaincodeStubInterface) pb.Response {
@@ -39,13 +41,20 @@ func (c *SimpleChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
 	fmt.Println("########### Invoke chaincode ###########")
 	_, args := stub.GetFunctionAndParameters()
 	// 由于前面PPT中第一个参数总是“invoke”，真正的方法名是第二个参数。其实“invoke”不是必需的
-	function := args[0]
-
-	if function == "CreateAccount" {
-		return c.createAccount(stub, args[1:])
+	if len(args) < 1 {
+		return assetcore.Error(&assetcore.ArgError{Message: "Incorrect number of arguments. Expecting function name"})
 	}
 
-	return shim.Error("Invalid invoke function name.")
+	return c.router.Invoke(stub, args[0], args[1:])
+}
+
+// 可调用的函数及参数
+func (c *SimpleChaincode) functions() []assetcore.Function {
+	return []assetcore.Function{
+		{Name: "CreateAccount", Desc: "创建帐户", Handler: c.createAccount, Args: []assetcore.Arg{
+			assetcore.JSON("account", `{"accountId":"帐户id"}`, "accountId"),
+		}},
+	}
 }
 
 // 创建账户
@@ -53,7 +62,7 @@ func (c *SimpleChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
 func (c *SimpleChaincode) createAccount(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 	fmt.Println("=========== create account ==========")
 	if len(args) < 1 {
-		return shim.Error("Incorrect number of arguments. Expecting atleast 1")
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 1")
 	}
 
 	var prarm struct {
@@ -62,20 +71,15 @@ func (c *SimpleChaincode) createAccount(stub shim.ChaincodeStubInterface, args [
 	// 解析参数
 	err := json.Unmarshal([]byte(args[0]), &prarm)
 	if prarm.AccountId == "" || err != nil {
-		fmt.Println("create account arguments error: AccountId can't be nil.")
-		return shim.Error("create account arguments error: AccountId can't be nil.")
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "create account arguments error: AccountId can't be nil.")
 	}
 
 	// 校验账户信息
-	_, _, isExist, err := c.checkAccout(stub, prarm.AccountId)
+	_, _, isExist, err := assetcore.CheckAccount(stub, prarm.AccountId)
 	if err != nil {
-		e := fmt.Sprintf("Check account=%s error:%s", prarm.AccountId, err)
-		fmt.Println(e)
-		return shim.Error(e)
+		return assetcore.Errorf("Check account=%s error:%s", prarm.AccountId, err)
 	} else if isExist {
-		e := fmt.Sprintf("Account=%s already exists.", prarm.AccountId)
-		fmt.Println(e)
-		return shim.Error(e)
+		return assetcore.Error(assetcore.AccountExists(prarm.AccountId))
 	}
 
 	a := Account{
@@ -83,45 +87,16 @@ func (c *SimpleChaincode) createAccount(stub shim.ChaincodeStubInterface, args [
 		Assets:    []*Asset{},
 	}
 	// 保存账户信息
-	err = c.save(stub, a.AccountId, a)
+	err = assetcore.Save(stub, a.AccountId, a)
 	if err != nil {
-		e := fmt.Sprintf("save account=%+v error:%s", a, err)
-		fmt.Println(e)
-		return shim.Error(e)
+		return assetcore.Errorf("save account=%+v error:%s", a, err)
 	}
 
 	return shim.Success(nil)
 }
 
-// 获取账户信息，并判断是否存在
-func (c *SimpleChaincode) checkAccout(stub shim.ChaincodeStubInterface, id string) (b []byte, a Account, isExist bool, err error) {
-
-	b, err = stub.GetState(id)
-	if err != nil {
-		return b, a, a.AccountId != "", err
-	}
-	if b != nil && len(b) > 0 {
-		err = json.Unmarshal(b, &a)
-	}
-
-	return b, a, a.AccountId != "", err
-}
-
-// 保存state
-func (c *SimpleChaincode) save(stub shim.ChaincodeStubInterface, k string, v interface{}) error {
-	val, err := json.Marshal(v)
-	if err != nil {
-		return err
-	}
-	err = stub.PutState(k, val)
-	if err != nil {
-		return err
-	}
-	return nil
-}
-
 func main() {
-	err := shim.Start(new(SimpleChaincode))
+	err := shim.Start(newChaincode())
 	if err != nil {
 		fmt.Printf("Error starting Simple chaincode: %s", err)
 	}
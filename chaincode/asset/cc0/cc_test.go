@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ChainNova/samples/chaincode/asset/memstub"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// 业务场景第1步：小张、小王开户
+func TestScenario(t *testing.T) {
+	stub := memstub.New("cc0", newChaincode())
+	if resp := stub.Init(); resp.Status != shim.OK {
+		t.Fatalf("Init: %s", resp.Message)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		ok   bool
+	}{
+		{"xiaozhang开户", []string{"invoke", "CreateAccount", `{"accountId":"xiaozhang"}`}, true},
+		{"xiaowang开户", []string{"invoke", "CreateAccount", `{"accountId":"xiaowang"}`}, true},
+		{"重复开户", []string{"invoke", "CreateAccount", `{"accountId":"xiaozhang"}`}, false},
+		{"帐户id为空", []string{"invoke", "CreateAccount", `{"accountId":""}`}, false},
+		{"参数不是JSON", []string{"invoke", "CreateAccount", `xiaoli`}, false},
+		{"缺少参数", []string{"invoke", "CreateAccount"}, false},
+		{"未知方法", []string{"invoke", "AddAsset", "xiaozhang", `{}`}, false},
+		{"缺少方法名", []string{"invoke"}, false},
+		{"多余参数", []string{"invoke", "CreateAccount", `{"accountId":"xiaoli"}`, "extra"}, false},
+		{"列出函数", []string{"invoke", "ListFunctions"}, true},
+	}
+	for _, tt := range tests {
+		resp := stub.Invoke(tt.args...)
+		if (resp.Status == shim.OK) != tt.ok {
+			t.Errorf("%s: status=%d message=%q, want ok=%v", tt.name, resp.Status, resp.Message, tt.ok)
+		}
+	}
+
+	for _, id := range []string{"xiaozhang", "xiaowang"} {
+		var a Account
+		if err := json.Unmarshal(stub.State(id), &a); err != nil {
+			t.Fatalf("account=%s: %s", id, err)
+		}
+		if a.AccountId != id || len(a.Assets) != 0 {
+			t.Errorf("account=%s: got %+v", id, a)
+		}
+	}
+	if b := stub.State("xiaoli"); b != nil {
+		t.Errorf("account=xiaoli: got %s, want not exists", b)
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ChainNova/samples/chaincode/asset/assetcore"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// 授权代理帐户转出资产，额度为0时取消授权
+// 参数：账户ID、授权信息（代理帐户、资产及额度）
+func (c *SimpleChaincode) approve(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== approve ==========")
+	if len(args) < 2 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 2")
+	}
+
+	ownerID := args[0]
+	var prarm struct {
+		Spender string `json:"spender"` //代理帐户
+		Asset   *Asset `json:"asset"`   //授权的资产及额度
+	}
+	// 解析参数
+	err := json.Unmarshal([]byte(args[1]), &prarm)
+	if err != nil || ownerID == "" || prarm.Spender == "" || prarm.Asset == nil || prarm.Asset.Issuer == "" || prarm.Asset.Code == "" || prarm.Asset.Amount < 0 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "approve arguments error: account, spender, issuer and code can't be nil; amount must be a number and not less than 0.")
+	}
+	if ownerID == prarm.Spender {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Account=%s can't approve itself.", ownerID)
+	}
+
+	// 获取并校验账户信息
+	_, account, isExist, err := assetcore.CheckAccount(stub, ownerID)
+	if err != nil {
+		return assetcore.Errorf("Check account=%s error:%s", ownerID, err)
+	} else if !isExist {
+		return assetcore.Error(assetcore.AccountNotFound(ownerID))
+	}
+	err = assetcore.CheckOwner(stub, account.Owner, account.AccountId)
+	if err != nil {
+		return assetcore.Fail(assetcore.CodeUnauthorized, "Permission denied:%s", err)
+	}
+	_, _, isExist, err = assetcore.CheckAccount(stub, prarm.Spender)
+	if err != nil {
+		return assetcore.Errorf("Check account=%s error:%s", prarm.Spender, err)
+	} else if !isExist {
+		return assetcore.Error(assetcore.AccountNotFound(prarm.Spender))
+	}
+
+	a := assetcore.Allowance{
+		Owner:   ownerID,
+		Spender: prarm.Spender,
+		Issuer:  prarm.Asset.Issuer,
+		Code:    prarm.Asset.Code,
+		Amount:  prarm.Asset.Amount,
+	}
+	err = assetcore.SaveAllowance(stub, a)
+	if err != nil {
+		return assetcore.Errorf("save allowance=%+v error:%s", a, err)
+	}
+
+	err = c.emit(stub, Event{Type: "Approve", From: a.Owner, To: a.Spender, Issuer: a.Issuer, Code: a.Code, Amount: a.Amount})
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+
+	return shim.Success(nil)
+}
+
+// 查询授权额度
+// 参数：{"accountId":"所有者帐户","spender":"代理帐户","issuer":"发行机构","code":"资产代码"}
+func (c *SimpleChaincode) allowance(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== allowance ==========")
+	if len(args) < 1 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 1")
+	}
+
+	var prarm struct {
+		AccountId string `json:"accountId"` //所有者帐户
+		Spender   string `json:"spender"`   //代理帐户
+		Issuer    string `json:"issuer"`    //资产发行机构
+		Code      string `json:"code"`      //资产代码
+	}
+	err := json.Unmarshal([]byte(args[0]), &prarm)
+	if err != nil || prarm.AccountId == "" || prarm.Spender == "" || prarm.Issuer == "" || prarm.Code == "" {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "allowance arguments error: accountId, spender, issuer and code can't be nil.")
+	}
+
+	a, _, err := assetcore.CheckAllowance(stub, prarm.AccountId, prarm.Spender, prarm.Issuer, prarm.Code)
+	if err != nil {
+		return assetcore.Errorf("Check allowance error:%s", err)
+	}
+
+	b, err := json.Marshal(a)
+	if err != nil {
+		return assetcore.Errorf("Marshal allowance error:%s", err)
+	}
+	return shim.Success(b)
+}
+
+// 代理帐户在授权额度内从所有者帐户转出资产
+// 参数：代理账户ID、转移资产信息（包括转出账户、接收账户）
+func (c *SimpleChaincode) transferFrom(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== transferFrom ==========")
+	if len(args) < 2 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 2")
+	}
+
+	spenderID := args[0]
+	var transferAsset struct {
+		From      string `json:"from"`      //转出帐号
+		AccountId string `json:"accountId"` //转移目的帐号
+		Asset     *Asset `json:"asset"`     //欲转移的资产
+	}
+	// 解析参数
+	err := json.Unmarshal([]byte(args[1]), &transferAsset)
+	if err != nil || spenderID == "" || transferAsset.From == "" || transferAsset.AccountId == "" || transferAsset.Asset == nil || transferAsset.Asset.Issuer == "" || transferAsset.Asset.Code == "" || transferAsset.Asset.Amount <= 0 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "transfer from arguments error: spender, from, account, issuer and code can't be nil; amount must be a number and greater than 0.")
+	}
+	asset := *transferAsset.Asset
+
+	// 只有代理帐户的所有者才能使用授权额度
+	_, spender, isExist, err := assetcore.CheckAccount(stub, spenderID)
+	if err != nil {
+		return assetcore.Errorf("Check account=%s error:%s", spenderID, err)
+	} else if !isExist {
+		return assetcore.Error(assetcore.AccountNotFound(spenderID))
+	}
+	err = assetcore.CheckOwner(stub, spender.Owner, spender.AccountId)
+	if err != nil {
+		return assetcore.Fail(assetcore.CodeUnauthorized, "Permission denied:%s", err)
+	}
+
+	// 超过审批阈值的转移只能由所有者通过TransferAsset发起审批
+	_, required, err := assetcore.CheckApproval(stub, asset.Issuer, asset.Code, asset.Amount)
+	if err != nil {
+		return assetcore.Errorf("Check approval policy of issuer=%s&code=%s error:%s", asset.Issuer, asset.Code, err)
+	} else if required {
+		return assetcore.Fail(assetcore.CodeFailedPrecondition, "Amount=%v of issuer=%s&code=%s requires approval, use TransferAsset.", asset.Amount, asset.Issuer, asset.Code)
+	}
+
+	a, _, err := assetcore.CheckAllowance(stub, transferAsset.From, spenderID, asset.Issuer, asset.Code)
+	if err != nil {
+		return assetcore.Errorf("Check allowance error:%s", err)
+	}
+
+	r, err := c.moveAsset(stub, transferAsset.From, transferAsset.AccountId, asset, func(Account) error {
+		if a.Amount < asset.Amount {
+			return fmt.Errorf("allowance of spender=%s on account=%s issuer=%s&code=%s is %v < transfer count=%v", spenderID, transferAsset.From, asset.Issuer, asset.Code, a.Amount, asset.Amount)
+		}
+		return nil
+	})
+	if err != nil {
+		return assetcore.Error(err)
+	}
+
+	// 扣减授权额度
+	a.Amount -= asset.Amount
+	err = assetcore.SaveAllowance(stub, a)
+	if err != nil {
+		return assetcore.Errorf("save allowance=%+v error:%s", a, err)
+	}
+
+	err = c.emit(stub, Event{
+		Type:        "TransferFrom",
+		From:        transferAsset.From,
+		To:          transferAsset.AccountId,
+		Issuer:      asset.Issuer,
+		Code:        asset.Code,
+		Amount:      asset.Amount,
+		FromBalance: r.FromBalance,
+		ToBalance:   r.ToBalance,
+		Spender:     spenderID,
+		Fee:         r.Fee,
+		Collector:   r.Collector,
+	})
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+
+	return c.transferResponse(r)
+}
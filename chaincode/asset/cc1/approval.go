@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ChainNova/samples/chaincode/asset/assetcore"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// 设置资产的大额转移审批策略，只有发行机构可以操作
+// 参数：审批策略，quorum为0时取消审批
+func (c *SimpleChaincode) setApprovalPolicy(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== setApprovalPolicy ==========")
+	if len(args) < 1 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 1")
+	}
+
+	var p assetcore.ApprovalPolicy
+	// 解析参数
+	err := json.Unmarshal([]byte(args[0]), &p)
+	if err != nil || p.Issuer == "" || p.Code == "" || p.Quorum < 0 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "set approval policy arguments error: issuer and code can't be nil; quorum can't be less than 0.")
+	}
+	if p.Quorum > 0 {
+		err = assetcore.CheckPolicySigners(p)
+		if err != nil {
+			return assetcore.Fail(assetcore.CodeInvalidArgument, "set approval policy arguments error: %s.", err)
+		}
+	}
+
+	err = assetcore.SetApprovalPolicy(stub, p)
+	if err != nil {
+		return assetcore.Error(err)
+	}
+
+	err = c.emit(stub, Event{Type: "SetApprovalPolicy", Issuer: p.Issuer, Code: p.Code, Amount: p.Threshold})
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+
+	return shim.Success(nil)
+}
+
+// 发起待审批的转移，转移在审批数达到要求后执行
+func (c *SimpleChaincode) proposeTransfer(stub shim.ChaincodeStubInterface, fromID, toID string, asset Asset, p assetcore.ApprovalPolicy) pb.Response {
+	// 获取并校验账户信息
+	_, accountF, isExist, err := assetcore.CheckAccount(stub, fromID)
+	if err != nil {
+		return assetcore.Errorf("Check account=%s error:%s", fromID, err)
+	} else if !isExist {
+		return assetcore.Error(assetcore.AccountNotFound(fromID))
+	}
+	err = assetcore.CheckOwner(stub, accountF.Owner, accountF.AccountId)
+	if err != nil {
+		return assetcore.Fail(assetcore.CodeUnauthorized, "Permission denied:%s", err)
+	}
+	_, _, isExist, err = assetcore.CheckAccount(stub, toID)
+	if err != nil {
+		return assetcore.Errorf("Check account=%s error:%s", toID, err)
+	} else if !isExist {
+		return assetcore.Error(assetcore.AccountNotFound(toID))
+	}
+	if fromID == toID {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Account=%s can't transfer to itself.", fromID)
+	}
+	err = assetcore.CheckActive(stub, asset.Issuer, asset.Code, fromID, toID)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+	// 接收帐户必须有资格持有该资产
+	err = assetcore.CheckEligible(stub, asset.Issuer, asset.Code, toID)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+
+	t, err := assetcore.SavePendingTransfer(stub, p, fromID, toID, asset.Issuer, asset.Code, asset.Amount)
+	if err != nil {
+		return assetcore.Error(err)
+	}
+
+	err = c.emit(stub, Event{Type: "TransferPending", From: fromID, To: toID, Issuer: asset.Issuer, Code: asset.Code, Amount: asset.Amount, TransferID: t.ID})
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+
+	return c.pendingResponse(t)
+}
+
+// 签名人审批待审批的转移，审批数达到要求时执行转移
+// 参数：{"id":"待审批转移编号"}
+func (c *SimpleChaincode) approveTransfer(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== approveTransfer ==========")
+	t, key, now, err := c.loadPendingTransfer(stub, args)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+	err = assetcore.ApprovePendingTransfer(stub, &t, now)
+	if err != nil {
+		return assetcore.Error(err)
+	}
+
+	ev := Event{Type: "ApproveTransfer", From: t.From, To: t.To, Issuer: t.Issuer, Code: t.Code, Amount: t.Amount, TransferID: t.ID, Approvals: len(t.Approvals)}
+	if len(t.Approvals) < t.Quorum {
+		err = assetcore.Save(stub, key, t)
+		if err != nil {
+			return assetcore.Errorf("save pending transfer=%+v error:%s", t, err)
+		}
+	} else {
+		// 审批数达到要求，执行转移，转出帐户所有者已在发起时校验
+		r, err := c.moveAsset(stub, t.From, t.To, Asset{Issuer: t.Issuer, Code: t.Code, Amount: t.Amount}, func(Account) error { return nil })
+		if err != nil {
+			return assetcore.Error(err)
+		}
+		ev.FromBalance, ev.ToBalance, ev.Fee, ev.Collector = r.FromBalance, r.ToBalance, r.Fee, r.Collector
+		err = stub.DelState(key)
+		if err != nil {
+			return assetcore.Errorf("DelState error:%s", err)
+		}
+		ev.Executed = true
+	}
+
+	err = c.emit(stub, ev)
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+
+	return c.pendingResponse(t)
+}
+
+// 取消待审批的转移，转出帐户所有者或签名人可以取消
+// 参数：{"id":"待审批转移编号"}
+func (c *SimpleChaincode) cancelTransfer(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== cancelTransfer ==========")
+	t, key, _, err := c.loadPendingTransfer(stub, args)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+
+	caller, err := assetcore.Caller(stub)
+	if err != nil {
+		return assetcore.Fail(assetcore.CodeUnauthorized, "Permission denied:get caller identity error:%s", err)
+	}
+	if !assetcore.ContainsIdentity(t.Signers, caller) {
+		_, accountF, _, err := assetcore.CheckAccount(stub, t.From)
+		if err == nil {
+			err = assetcore.CheckOwner(stub, accountF.Owner, accountF.AccountId)
+		}
+		if err != nil {
+			return assetcore.Fail(assetcore.CodeUnauthorized, "Permission denied:%s", err)
+		}
+	}
+
+	err = stub.DelState(key)
+	if err != nil {
+		return assetcore.Errorf("DelState error:%s", err)
+	}
+
+	err = c.emit(stub, Event{Type: "CancelTransfer", From: t.From, To: t.To, Issuer: t.Issuer, Code: t.Code, Amount: t.Amount, TransferID: t.ID})
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+
+	return shim.Success(nil)
+}
+
+// 查询待审批的转移
+// 参数：{"id":"待审批转移编号"}
+func (c *SimpleChaincode) getPendingTransfer(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== getPendingTransfer ==========")
+	t, _, _, err := c.loadPendingTransfer(stub, args)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+	return c.pendingResponse(t)
+}
+
+// 解析参数并获取待审批的转移，不存在时返回错误
+func (c *SimpleChaincode) loadPendingTransfer(stub shim.ChaincodeStubInterface, args []string) (t assetcore.PendingTransfer, key string, now int64, err error) {
+	if len(args) < 1 {
+		return t, key, now, assetcore.NewError(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 1")
+	}
+	var prarm struct {
+		ID string `json:"id"` //待审批转移编号
+	}
+	err = json.Unmarshal([]byte(args[0]), &prarm)
+	if err != nil || prarm.ID == "" {
+		return t, key, now, assetcore.NewError(assetcore.CodeInvalidArgument, "pending transfer arguments error: id can't be nil")
+	}
+
+	return assetcore.LoadPendingTransfer(stub, prarm.ID)
+}
+
+func (c *SimpleChaincode) pendingResponse(t assetcore.PendingTransfer) pb.Response {
+	b, err := json.Marshal(t)
+	if err != nil {
+		return assetcore.Errorf("Marshal pending transfer error:%s", err)
+	}
+	return shim.Success(b)
+}
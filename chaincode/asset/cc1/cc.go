@@ -4,32 +4,60 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/ChainNova/samples/chaincode/asset/assetcore"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 	pb "github.com/hyperledger/fabric/protos/peer"
 )
 
 // SimpleChaincode ...
 type SimpleChaincode struct {
+	store  assetcore.Store   //持有量的存储模型
+	router *assetcore.Router //函数路由表，创建时构建一次
 }
 
-// Asset 资产
-type Asset struct {
-	Issuer string `json:"issuer"` //资产发行机构
-	Code   string `json:"code"`   //资产代码
-	Amount int64  `json:"amount"` //资产数量
+// 创建使用该存储模型的链码并构建函数路由表
+func newChaincode(store assetcore.Store) *SimpleChaincode {
+	c := &SimpleChaincode{store: store}
+	c.router = assetcore.NewRouter(c.functions())
+	return c
 }
 
+// Asset 资产
+type Asset = assetcore.Asset
+
 // Account 账户
-type Account struct {
-	AccountId string   `json:""accountId` //帐户id
-	Assets    []*Asset `json:"assets"`    //该帐户的资产列表
-}
+type Account = assetcore.Account
+
+// Identity 调用者身份
+type Identity = assetcore.Identity
+
+// Role 由MSP ID及证书属性确定的角色
+type Role = assetcore.Role
 
 // Init ...
 func (c *SimpleChaincode) Init(stub shim.ChaincodeStubInterface) pb.Response {
 	fmt.Println("########### Init chaincode ###########")
 
 	// Init中可以加一些初始化操作，比如初始化一种资产
+	_, args := stub.GetFunctionAndParameters()
+
+	// 初始化发行机构注册表
+	err := assetcore.InitIssuers(stub, args)
+	if err != nil {
+		return assetcore.Errorf("Init issuers error:%s", err)
+	}
+
+	// 初始化管理员角色
+	err = assetcore.InitAdmin(stub, args)
+	if err != nil {
+		return assetcore.Errorf("Init admin error:%s", err)
+	}
+
+	// 初始化合规角色
+	err = assetcore.InitCompliance(stub, args)
+	if err != nil {
+		return assetcore.Errorf("Init compliance error:%s", err)
+	}
 
 	return shim.Success(nil)
 }
@@ -39,19 +67,11 @@ func (c *SimpleChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
 	fmt.Println("########### Invoke chaincode ###########")
 	_, args := stub.GetFunctionAndParameters()
 	// 由于前面PPT中第一个参数总是“invoke”，真正的方法名是第二个参数。其实“invoke”不是必需的
-	function := args[0]
-
-	if function == "CreateAccount" {
-		return c.createAccount(stub, args[1:])
-	} else if function == "AddAsset" {
-		return c.addAsset(stub, args[1:])
-	} else if function == "TransferAsset" {
-		return c.transferAsset(stub, args[1:])
-	} else if function == "GetAccount" {
-		return c.getAccount(stub, args[1:])
+	if len(args) < 1 {
+		return assetcore.Error(&assetcore.ArgError{Message: "Incorrect number of arguments. Expecting function name"})
 	}
 
-	return shim.Error("Invalid invoke function name.")
+	return c.router.Invoke(stub, args[0], args[1:])
 }
 
 // 创建账户
@@ -59,7 +79,7 @@ func (c *SimpleChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
 func (c *SimpleChaincode) createAccount(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 	fmt.Println("=========== create account ==========")
 	if len(args) < 1 {
-		return shim.Error("Incorrect number of arguments. Expecting atleast 1")
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 1")
 	}
 
 	var prarm struct {
@@ -68,32 +88,43 @@ func (c *SimpleChaincode) createAccount(stub shim.ChaincodeStubInterface, args [
 	// 解析参数
 	err := json.Unmarshal([]byte(args[0]), &prarm)
 	if prarm.AccountId == "" || err != nil {
-		fmt.Println("create account arguments error: AccountId can't be nil.")
-		return shim.Error("create account arguments error: AccountId can't be nil.")
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "create account arguments error: AccountId can't be nil.")
 	}
 
 	// 校验账户信息
-	_, _, isExist, err := c.checkAccout(stub, prarm.AccountId)
+	_, _, isExist, err := assetcore.CheckAccount(stub, prarm.AccountId)
 	if err != nil {
-		e := fmt.Sprintf("Check account=%s error:%s", prarm.AccountId, err)
-		fmt.Println(e)
-		return shim.Error(e)
+		return assetcore.Errorf("Check account=%s error:%s", prarm.AccountId, err)
 	} else if isExist {
-		e := fmt.Sprintf("Account=%s already exists.", prarm.AccountId)
-		fmt.Println(e)
-		return shim.Error(e)
+		return assetcore.Error(assetcore.AccountExists(prarm.AccountId))
+	}
+
+	// 设置开户资格时，帐户必须已登记KYC
+	err = assetcore.CheckEligible(stub, "", "", prarm.AccountId)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+
+	// 获取创建者身份，作为账户所有者
+	owner, err := assetcore.Caller(stub)
+	if err != nil {
+		return assetcore.Errorf("Get caller identity error:%s", err)
 	}
 
 	a := Account{
 		AccountId: prarm.AccountId,
 		Assets:    []*Asset{},
+		Owner:     owner,
 	}
 	// 保存账户信息
-	err = c.save(stub, a.AccountId, a)
+	err = assetcore.Save(stub, a.AccountId, a)
+	if err != nil {
+		return assetcore.Errorf("save account=%+v error:%s", a, err)
+	}
+
+	err = c.emit(stub, Event{Type: "CreateAccount", To: a.AccountId})
 	if err != nil {
-		e := fmt.Sprintf("save account=%+v error:%s", a, err)
-		fmt.Println(e)
-		return shim.Error(e)
+		return assetcore.Errorf("SetEvent error:%s", err)
 	}
 
 	return shim.Success(nil)
@@ -104,7 +135,7 @@ func (c *SimpleChaincode) createAccount(stub shim.ChaincodeStubInterface, args [
 func (c *SimpleChaincode) addAsset(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 	fmt.Println("=========== addAsset asset ==========")
 	if len(args) < 2 {
-		return shim.Error("Incorrect number of arguments. Expecting atleast 2")
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 2")
 	}
 
 	accountId := args[0]
@@ -115,43 +146,61 @@ func (c *SimpleChaincode) addAsset(stub shim.ChaincodeStubInterface, args []stri
 	// 解析参数
 	err := json.Unmarshal([]byte(args[1]), &addAsset)
 	if accountId == "" || addAsset.Asset.Issuer == "" || addAsset.Asset.Code == "" || err != nil || addAsset.Asset.Amount <= 0 {
-		fmt.Println("add asset arguments error: accountId, issuer and code can't be nil; amount must be a number and greater than 0.")
-		return shim.Error("add asset arguments error: accountId, issuer and code can't be nil; amount must be a number and greater than 0.")
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "add asset arguments error: accountId, issuer and code can't be nil; amount must be a number and greater than 0.")
+	}
+
+	// 只有发行机构才能增加该机构的资产
+	err = assetcore.CheckIssuer(stub, addAsset.Asset.Issuer)
+	if err != nil {
+		return assetcore.Fail(assetcore.CodeUnauthorized, "Permission denied:%s", err)
+	}
+
+	// 资产不能停牌，帐户不能冻结
+	err = assetcore.CheckActive(stub, addAsset.Asset.Issuer, addAsset.Asset.Code, accountId)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+	// 接收帐户必须有资格持有该资产
+	err = assetcore.CheckEligible(stub, addAsset.Asset.Issuer, addAsset.Asset.Code, accountId)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
 	}
 
 	// 获取并校验账户资产信息
-	_, account, isExist, err := c.checkAccout(stub, accountId)
+	_, account, isExist, err := assetcore.CheckAccount(stub, accountId)
 	if err != nil {
-		e := fmt.Sprintf("Check account=%s error:%s", accountId, err)
-		fmt.Println(e)
-		return shim.Error(e)
+		return assetcore.Errorf("Check account=%s error:%s", accountId, err)
 	} else if !isExist {
-		e := fmt.Sprintf("Account=%s not exists.", accountId)
-		fmt.Println(e)
-		return shim.Error(e)
+		return assetcore.Error(assetcore.AccountNotFound(accountId))
 	}
 
-	find := false
 	// 判断是否存在该资产
 	// 如果已有该资产，则数值增加
 	// 如果没有，则加入该资产
-	for k, v := range account.Assets {
-		if v.Issuer == addAsset.Asset.Issuer && v.Code == addAsset.Asset.Code {
-			account.Assets[k].Amount = v.Amount + addAsset.Asset.Amount
-			find = true
-			break
-		}
+	balance := account.Credit(addAsset.Asset.Issuer, addAsset.Asset.Code, addAsset.Asset.Amount)
+
+	// 保存账户资产
+	err = assetcore.Save(stub, account.AccountId, account)
+	if err != nil {
+		return assetcore.Errorf("save account=%+v error:%s", account, err)
 	}
-	if !find {
-		account.Assets = append(account.Assets, addAsset.Asset)
+
+	// 累计发行数量
+	err = assetcore.AddSupply(stub, addAsset.Asset.Issuer, addAsset.Asset.Code, addAsset.Asset.Amount, 0)
+	if err != nil {
+		return assetcore.Errorf("Update supply of issuer=%s&code=%s error:%s", addAsset.Asset.Issuer, addAsset.Asset.Code, err)
 	}
 
-	// 保存账户资产
-	err = c.save(stub, account.AccountId, account)
+	err = c.emit(stub, Event{
+		Type:      "AddAsset",
+		To:        account.AccountId,
+		Issuer:    addAsset.Asset.Issuer,
+		Code:      addAsset.Asset.Code,
+		Amount:    addAsset.Asset.Amount,
+		ToBalance: balance,
+	})
 	if err != nil {
-		e := fmt.Sprintf("save account=%+v error:%s", account, err)
-		fmt.Println(e)
-		return shim.Error(e)
+		return assetcore.Errorf("SetEvent error:%s", err)
 	}
 
 	return shim.Success(nil)
@@ -163,7 +212,7 @@ func (c *SimpleChaincode) addAsset(stub shim.ChaincodeStubInterface, args []stri
 func (c *SimpleChaincode) transferAsset(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 	fmt.Println("=========== transferAsset ==========")
 	if len(args) < 2 {
-		return shim.Error("Incorrect number of arguments. Expecting atleast 2")
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 2")
 	}
 
 	fromID := args[0]
@@ -174,85 +223,137 @@ func (c *SimpleChaincode) transferAsset(stub shim.ChaincodeStubInterface, args [
 	// 解析参数
 	err := json.Unmarshal([]byte(args[1]), &transferAsset)
 	if fromID == "" || transferAsset.AccountId == "" || transferAsset.Asset.Issuer == "" || transferAsset.Asset.Code == "" || err != nil || transferAsset.Asset.Amount <= 0 {
-		fmt.Println("transfer asset arguments error: account, issuer and code can't be nil; amount must be a number and greater than 0.")
-		return shim.Error("transfer asset arguments error: account, issuer and code can't be nil; amount must be a number and greater than 0.")
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "transfer asset arguments error: account, issuer and code can't be nil; amount must be a number and greater than 0.")
 	}
 
+	// 超过审批阈值的转移需要签名人审批后执行
+	policy, required, err := assetcore.CheckApproval(stub, transferAsset.Asset.Issuer, transferAsset.Asset.Code, transferAsset.Asset.Amount)
+	if err != nil {
+		return assetcore.Errorf("Check approval policy of issuer=%s&code=%s error:%s", transferAsset.Asset.Issuer, transferAsset.Asset.Code, err)
+	} else if required {
+		return c.proposeTransfer(stub, fromID, transferAsset.AccountId, *transferAsset.Asset, policy)
+	}
+
+	r, err := c.moveAsset(stub, fromID, transferAsset.AccountId, *transferAsset.Asset, func(a Account) error {
+		// 只有账户所有者才能转出资产
+		return assetcore.CheckOwner(stub, a.Owner, a.AccountId)
+	})
+	if err != nil {
+		return assetcore.Error(err)
+	}
+
+	err = c.emit(stub, Event{
+		Type:        "TransferAsset",
+		From:        fromID,
+		To:          transferAsset.AccountId,
+		Issuer:      transferAsset.Asset.Issuer,
+		Code:        transferAsset.Asset.Code,
+		Amount:      transferAsset.Asset.Amount,
+		FromBalance: r.FromBalance,
+		ToBalance:   r.ToBalance,
+		Fee:         r.Fee,
+		Collector:   r.Collector,
+	})
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+
+	return c.transferResponse(r)
+}
+
+// 在两个账户间转移资产，返回转移结果
+// authorize校验交易提交者是否有权从转出账户转出资产；设置了手续费的资产由转出账户另外支付手续费给收费帐户
+func (c *SimpleChaincode) moveAsset(stub shim.ChaincodeStubInterface, fromID, toID string, asset Asset, authorize func(Account) error) (r TransferResult, err error) {
+	r = TransferResult{From: fromID, To: toID, Issuer: asset.Issuer, Code: asset.Code, Amount: asset.Amount}
+
 	// 获取并校验账户信息
-	_, accountF, isExist, err := c.checkAccout(stub, fromID)
+	_, accountF, isExist, err := assetcore.CheckAccount(stub, fromID)
 	if err != nil {
-		e := fmt.Sprintf("Check account=%s error:%s", fromID, err)
-		fmt.Println(e)
-		return shim.Error(e)
+		return r, fmt.Errorf("Check account=%s error:%s", fromID, err)
 	} else if !isExist {
-		e := fmt.Sprintf("Account=%s not exists.", fromID)
-		fmt.Println(e)
-		return shim.Error(e)
+		return r, assetcore.AccountNotFound(fromID)
+	}
+
+	err = authorize(accountF)
+	if err != nil {
+		return r, assetcore.NewError(assetcore.CodeUnauthorized, "Permission denied:%s", err)
+	}
+
+	// 资产不能停牌，帐户不能冻结
+	err = assetcore.CheckActive(stub, asset.Issuer, asset.Code, fromID, toID)
+	if err != nil {
+		return r, assetcore.Wrapf("%s.", err)
+	}
+	// 接收帐户必须有资格持有该资产
+	err = assetcore.CheckEligible(stub, asset.Issuer, asset.Code, toID)
+	if err != nil {
+		return r, assetcore.Wrapf("%s.", err)
 	}
 
 	// 获取并校验接收账户信息
-	_, accountT, isExist, err := c.checkAccout(stub, transferAsset.AccountId)
+	_, accountT, isExist, err := assetcore.CheckAccount(stub, toID)
 	if err != nil {
-		e := fmt.Sprintf("Check account=%s error:%s", transferAsset.AccountId, err)
-		fmt.Println(e)
-		return shim.Error(e)
+		return r, fmt.Errorf("Check account=%s error:%s", toID, err)
 	} else if !isExist {
-		e := fmt.Sprintf("Account=%s not exists.", transferAsset.AccountId)
-		fmt.Println(e)
-		return shim.Error(e)
+		return r, assetcore.AccountNotFound(toID)
+	}
+
+	// 不能转移给自己
+	if accountF.AccountId == accountT.AccountId {
+		return r, assetcore.NewError(assetcore.CodeInvalidArgument, "Account=%s can't transfer to itself.", accountF.AccountId)
+	}
+
+	// 计算手续费
+	r.Fee, r.Collector, err = assetcore.TransferFee(stub, fromID, asset.Issuer, asset.Code, asset.Amount)
+	if err != nil {
+		return r, assetcore.Wrapf("%s.", err)
 	}
 
-	find := false
 	// 检测账户资产
-	// 如果存在，则减去转移量（必须确保转移量小于账户对应资产数量）
+	// 如果存在，则减去转移量及手续费（必须确保转移量小于账户对应资产数量）
 	// 如果不存在，则返回错误
-	for k, v := range accountF.Assets {
-		if v.Issuer == transferAsset.Asset.Issuer && v.Code == transferAsset.Asset.Code {
-			if v.Amount < transferAsset.Asset.Amount {
-				e := fmt.Sprintf("Account=%s issuer=%s&code=%s&count=%v < transfer count=%v.", accountF.AccountId, v.Issuer, v.Code, v.Amount, transferAsset.Asset.Amount)
-				fmt.Println(e)
-				return shim.Error(e)
-			}
-			accountF.Assets[k].Amount = v.Amount - transferAsset.Asset.Amount
-			find = true
-		}
-	}
-	if !find {
-		e := fmt.Sprintf("Asset issuer=%s&code=%s of Account=%s not exists.", transferAsset.Asset.Issuer, transferAsset.Asset.Code, accountF.AccountId)
-		fmt.Println(e)
-		return shim.Error(e)
+	r.FromBalance, err = c.debitAsset(stub, &accountF, asset.Issuer, asset.Code, asset.Amount+r.Fee)
+	if err != nil {
+		return r, assetcore.Wrapf("%s.", err)
 	}
 
-	find = false
 	// 判断接收账户资产
 	// 如果存在该资产，则数量增加
 	// 如果不存在该资产，则新增该资产
-	for k, v := range accountT.Assets {
-		if v.Issuer == transferAsset.Asset.Issuer && v.Code == transferAsset.Asset.Code {
-			accountF.Assets[k].Amount = v.Amount + transferAsset.Asset.Amount
-			find = true
+	r.ToBalance = accountT.Credit(asset.Issuer, asset.Code, asset.Amount)
+
+	// 手续费转入收费帐户，收费帐户为接收账户时直接累加
+	if r.Fee > 0 {
+		if r.Collector == accountT.AccountId {
+			r.ToBalance = accountT.Credit(asset.Issuer, asset.Code, r.Fee)
+		} else {
+			_, collector, isExist, err := assetcore.CheckAccount(stub, r.Collector)
+			if err != nil {
+				return r, fmt.Errorf("Check account=%s error:%s", r.Collector, err)
+			} else if !isExist {
+				return r, assetcore.AccountNotFound(r.Collector)
+			}
+			collector.Credit(asset.Issuer, asset.Code, r.Fee)
+			err = assetcore.Save(stub, collector.AccountId, collector)
+			if err != nil {
+				return r, fmt.Errorf("save account=%+v error:%s", collector, err)
+			}
 		}
 	}
-	if !find {
-		accountT.Assets = append(accountT.Assets, transferAsset.Asset)
-	}
 
 	// 保存账户信息
-	err = c.save(stub, accountF.AccountId, accountF)
+	err = assetcore.Save(stub, accountF.AccountId, accountF)
 	if err != nil {
-		e := fmt.Sprintf("save account=%+v error:%s", accountF, err)
-		fmt.Println(e)
-		return shim.Error(e)
+		return r, fmt.Errorf("save account=%+v error:%s", accountF, err)
 	}
 
 	// 保存接收账户信息
-	err = c.save(stub, accountT.AccountId, accountT)
+	err = assetcore.Save(stub, accountT.AccountId, accountT)
 	if err != nil {
-		e := fmt.Sprintf("save account=%+v error:%s", accountT, err)
-		fmt.Println(e)
-		return shim.Error(e)
+		return r, fmt.Errorf("save account=%+v error:%s", accountT, err)
 	}
-	return shim.Success(nil)
+
+	return r, nil
 }
 
 // 获取用户信息
@@ -260,7 +361,7 @@ func (c *SimpleChaincode) transferAsset(stub shim.ChaincodeStubInterface, args [
 func (c *SimpleChaincode) getAccount(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 	fmt.Println("=========== getAccount ==========")
 	if len(args) < 1 {
-		return shim.Error("Incorrect number of arguments. Expecting atleast 1")
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 1")
 	}
 
 	var prarm struct {
@@ -269,54 +370,43 @@ func (c *SimpleChaincode) getAccount(stub shim.ChaincodeStubInterface, args []st
 	// 解析参数
 	err := json.Unmarshal([]byte(args[0]), &prarm)
 	if prarm.AccountId == "" || err != nil {
-		fmt.Println("create account arguments error: AccountId can't be nil.")
-		return shim.Error("create account arguments error: AccountId can't be nil.")
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "create account arguments error: AccountId can't be nil.")
 	}
 
 	// 获取账户信息
-	b, _, isExist, err := c.checkAccout(stub, prarm.AccountId)
+	b, _, isExist, err := assetcore.CheckAccount(stub, prarm.AccountId)
 	if err != nil {
-		e := fmt.Sprintf("Check account=%s error:%s", prarm.AccountId, err)
-		fmt.Println(e)
-		return shim.Error(e)
+		return assetcore.Errorf("Check account=%s error:%s", prarm.AccountId, err)
 	} else if !isExist {
-		e := fmt.Sprintf("Account=%s not exists.", prarm.AccountId)
-		fmt.Println(e)
-		return shim.Error(e)
+		return assetcore.Error(assetcore.AccountNotFound(prarm.AccountId))
 	}
 
 	return shim.Success(b)
 }
 
-// 获取账户信息，并判断是否存在
-func (c *SimpleChaincode) checkAccout(stub shim.ChaincodeStubInterface, id string) (b []byte, a Account, isExist bool, err error) {
-
-	b, err = stub.GetState(id)
-	if err != nil {
-		return b, a, a.AccountId != "", err
-	}
-	if b != nil && len(b) > 0 {
-		err = json.Unmarshal(b, &a)
-	}
-
-	return b, a, a.AccountId != "", err
-}
-
-// 保存state
-func (c *SimpleChaincode) save(stub shim.ChaincodeStubInterface, k string, v interface{}) error {
-	val, err := json.Marshal(v)
-	if err != nil {
-		return err
-	}
-	err = stub.PutState(k, val)
-	if err != nil {
-		return err
+// 减少账户中的资产，返回减少后的数量
+// 账户中不存在该资产、数量不足或未解锁数量不足时返回错误
+func (c *SimpleChaincode) debitAsset(stub shim.ChaincodeStubInterface, a *Account, issuer, code string, amount int64) (int64, error) {
+	for k, v := range a.Assets {
+		if v.Issuer == issuer && v.Code == code {
+			if v.Amount < amount {
+				return v.Amount, assetcore.InsufficientFunds(a.AccountId, issuer, code, v.Amount, amount, "Account=%s issuer=%s&code=%s&count=%v < transfer count=%v", a.AccountId, issuer, code, v.Amount, amount)
+			}
+			// 归属计划锁定的数量不能转出
+			err := assetcore.CheckUnlocked(stub, a.AccountId, issuer, code, v.Amount, amount)
+			if err != nil {
+				return v.Amount, err
+			}
+			a.Assets[k].Amount = v.Amount - amount
+			return a.Assets[k].Amount, nil
+		}
 	}
-	return nil
+	return 0, assetcore.InsufficientFunds(a.AccountId, issuer, code, 0, amount, "Asset issuer=%s&code=%s of Account=%s not exists", issuer, code, a.AccountId)
 }
 
 func main() {
-	err := shim.Start(new(SimpleChaincode))
+	// cc1的持有量保存在帐户文档中
+	err := shim.Start(newChaincode(assetcore.AccountStore{}))
 	if err != nil {
 		fmt.Printf("Error starting Simple chaincode: %s", err)
 	}
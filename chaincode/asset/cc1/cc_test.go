@@ -0,0 +1,574 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ChainNova/samples/chaincode/asset/assetcore"
+	"github.com/ChainNova/samples/chaincode/asset/memstub"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+var (
+	aaa        = memstub.MustIdentity("AAAMSP", "issuer.aaa", nil)
+	bbb        = memstub.MustIdentity("BBBMSP", "issuer.bbb", nil)
+	admin      = memstub.MustIdentity("AdminMSP", "admin", nil)
+	compliance = memstub.MustIdentity("AdminMSP", "compliance", map[string]string{"role": "compliance"})
+	xiaozhang  = memstub.MustIdentity("Org1MSP", "xiaozhang", nil)
+	xiaowang   = memstub.MustIdentity("Org1MSP", "xiaowang", nil)
+)
+
+// 初始化链码：AAA、BBB两个发行机构，管理员及合规角色
+func newStub(t *testing.T) *memstub.Stub {
+	stub := memstub.New("cc1", newChaincode(assetcore.AccountStore{}))
+	resp := stub.As(admin).Init("init",
+		`[{"name":"AAA","mspId":"AAAMSP"},{"name":"BBB","mspId":"BBBMSP"}]`,
+		`{"mspId":"AdminMSP"}`,
+		`{"mspId":"AdminMSP","attrName":"role","attrValue":"compliance"}`)
+	if resp.Status != shim.OK {
+		t.Fatalf("Init: %s", resp.Message)
+	}
+	return stub
+}
+
+type step struct {
+	name   string
+	caller []byte
+	args   []string
+	ok     bool
+}
+
+func run(t *testing.T, stub *memstub.Stub, steps []step) {
+	for _, s := range steps {
+		resp := stub.As(s.caller).Invoke(append([]string{"invoke"}, s.args...)...)
+		if (resp.Status == shim.OK) != s.ok {
+			t.Errorf("%s: status=%d message=%q, want ok=%v", s.name, resp.Status, resp.Message, s.ok)
+		}
+	}
+}
+
+// 通过GetAccount查询帐户各资产数量
+func holdings(t *testing.T, stub *memstub.Stub, id string) map[string]int64 {
+	resp := stub.As(xiaozhang).Invoke("invoke", "GetAccount", `{"accountId":"`+id+`"}`)
+	if resp.Status != shim.OK {
+		t.Fatalf("GetAccount %s: %s", id, resp.Message)
+	}
+	var a Account
+	if err := json.Unmarshal(resp.Payload, &a); err != nil {
+		t.Fatalf("GetAccount %s: %s", id, err)
+	}
+	m := map[string]int64{}
+	for _, v := range a.Assets {
+		m[v.Issuer+"/"+v.Code] = v.Amount
+	}
+	return m
+}
+
+func checkHoldings(t *testing.T, stub *memstub.Stub, id string, want map[string]int64) {
+	got := holdings(t, stub, id)
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("account=%s %s: got %d, want %d", id, k, got[k], v)
+		}
+	}
+	for k, v := range got {
+		if _, ok := want[k]; !ok && v != 0 {
+			t.Errorf("account=%s %s: got %d, want 0", id, k, v)
+		}
+	}
+}
+
+// 通过AuditSupply查询各资产的对账结果，有不一致的资产时报错
+func audit(t *testing.T, stub *memstub.Stub) map[string]SupplyAudit {
+	resp := stub.Invoke("invoke", "AuditSupply")
+	var r struct {
+		Assets        []SupplyAudit `json:"assets"`
+		Discrepancies []SupplyAudit `json:"discrepancies"`
+	}
+	if resp.Status != shim.OK || json.Unmarshal(resp.Payload, &r) != nil {
+		t.Fatalf("AuditSupply: status=%d message=%q", resp.Status, resp.Message)
+	}
+	if len(r.Discrepancies) != 0 {
+		t.Errorf("AuditSupply discrepancies: got %+v", r.Discrepancies)
+	}
+	m := map[string]SupplyAudit{}
+	for _, a := range r.Assets {
+		m[a.Issuer+"/"+a.Code] = a
+	}
+	return m
+}
+
+// 业务场景：小张开户，购买A1 100股及B1 200股，再转移50股A1给小王
+func TestScenario(t *testing.T) {
+	stub := newStub(t)
+
+	run(t, stub, []step{
+		{"xiaozhang开户", xiaozhang, []string{"CreateAccount", `{"accountId":"xiaozhang"}`}, true},
+		{"重复开户", xiaowang, []string{"CreateAccount", `{"accountId":"xiaozhang"}`}, false},
+		{"xiaowang开户", xiaowang, []string{"CreateAccount", `{"accountId":"xiaowang"}`}, true},
+		{"AAA发行A1", aaa, []string{"AddAsset", "xiaozhang", `{"asset":{"issuer":"AAA","code":"A1","amount":100}}`}, true},
+		{"BBB发行B1", bbb, []string{"AddAsset", "xiaozhang", `{"asset":{"issuer":"BBB","code":"B1","amount":200}}`}, true},
+		{"AAA不能发行BBB的资产", aaa, []string{"AddAsset", "xiaozhang", `{"asset":{"issuer":"BBB","code":"B1","amount":200}}`}, false},
+		{"帐户所有者不能发行", xiaozhang, []string{"AddAsset", "xiaozhang", `{"asset":{"issuer":"AAA","code":"A1","amount":100}}`}, false},
+		{"发行数量为0", aaa, []string{"AddAsset", "xiaozhang", `{"asset":{"issuer":"AAA","code":"A1","amount":0}}`}, false},
+		{"发行给不存在的帐户", aaa, []string{"AddAsset", "xiaoli", `{"asset":{"issuer":"AAA","code":"A1","amount":100}}`}, false},
+		{"xiaozhang转移50股A1给xiaowang", xiaozhang, []string{"TransferAsset", "xiaozhang", `{"accountId":"xiaowang","asset":{"issuer":"AAA","code":"A1","amount":50}}`}, true},
+		{"非所有者不能转移", xiaowang, []string{"TransferAsset", "xiaozhang", `{"accountId":"xiaowang","asset":{"issuer":"AAA","code":"A1","amount":10}}`}, false},
+		{"余额不足", xiaozhang, []string{"TransferAsset", "xiaozhang", `{"accountId":"xiaowang","asset":{"issuer":"AAA","code":"A1","amount":51}}`}, false},
+		{"转移给不存在的帐户", xiaozhang, []string{"TransferAsset", "xiaozhang", `{"accountId":"xiaoli","asset":{"issuer":"AAA","code":"A1","amount":10}}`}, false},
+		{"未知方法", xiaozhang, []string{"Unknown"}, false},
+		{"参数缺少必填字段", xiaozhang, []string{"TransferAsset", "xiaozhang", `{"accountId":"xiaowang","asset":{"issuer":"AAA"}}`}, false},
+		{"列出函数", xiaozhang, []string{"ListFunctions"}, true},
+	})
+
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 50, "BBB/B1": 200})
+	checkHoldings(t, stub, "xiaowang", map[string]int64{"AAA/A1": 50})
+
+	ev := stub.LastEvent()
+	if ev == nil || ev.EventName != "TransferAsset" {
+		t.Fatalf("last event: got %+v, want TransferAsset", ev)
+	}
+}
+
+// 设置开户资格及资产持有资格后，未认证的帐户不能开户或接收资产，失败的交易不改变状态
+func TestEligibility(t *testing.T) {
+	stub := newStub(t)
+
+	run(t, stub, []step{
+		{"xiaozhang开户", xiaozhang, []string{"CreateAccount", `{"accountId":"xiaozhang"}`}, true},
+		{"AAA发行A1", aaa, []string{"AddAsset", "xiaozhang", `{"asset":{"issuer":"AAA","code":"A1","amount":100}}`}, true},
+		{"非合规角色不能设置资格", admin, []string{"SetEligibility", `{"issuer":"AAA","code":"A1","minTier":2}`}, false},
+		{"设置A1持有资格", compliance, []string{"SetEligibility", `{"issuer":"AAA","code":"A1","minTier":2}`}, true},
+		{"xiaowang开户", xiaowang, []string{"CreateAccount", `{"accountId":"xiaowang"}`}, true},
+		{"xiaowang未登记KYC", xiaozhang, []string{"TransferAsset", "xiaozhang", `{"accountId":"xiaowang","asset":{"issuer":"AAA","code":"A1","amount":50}}`}, false},
+		{"xiaowang登记KYC等级1", compliance, []string{"SetKYC", `{"accountId":"xiaowang","status":"verified","tier":1}`}, true},
+		{"xiaowang等级不足", xiaozhang, []string{"TransferAsset", "xiaozhang", `{"accountId":"xiaowang","asset":{"issuer":"AAA","code":"A1","amount":50}}`}, false},
+		{"xiaowang登记KYC等级2", compliance, []string{"SetKYC", `{"accountId":"xiaowang","status":"verified","tier":2}`}, true},
+		{"xiaozhang转移50股A1给xiaowang", xiaozhang, []string{"TransferAsset", "xiaozhang", `{"accountId":"xiaowang","asset":{"issuer":"AAA","code":"A1","amount":50}}`}, true},
+		{"设置开户资格", compliance, []string{"SetEligibility", `{"issuer":"","code":"","minTier":1}`}, true},
+		{"xiaoli未登记KYC不能开户", xiaowang, []string{"CreateAccount", `{"accountId":"xiaoli"}`}, false},
+	})
+
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 50})
+	checkHoldings(t, stub, "xiaowang", map[string]int64{"AAA/A1": 50})
+	if b := stub.State("xiaoli"); b != nil {
+		t.Errorf("account=xiaoli: got %s, want not exists", b)
+	}
+}
+
+// 没有参数的调用返回错误而不是panic
+func TestEmptyInvoke(t *testing.T) {
+	stub := newStub(t)
+	if resp := stub.As(xiaozhang).Invoke(); resp.Status == shim.OK {
+		t.Errorf("empty invoke: got status=%d, want error", resp.Status)
+	}
+	if resp := stub.As(xiaozhang).Invoke("invoke"); resp.Status == shim.OK {
+		t.Errorf("invoke without function: got status=%d, want error", resp.Status)
+	}
+}
+
+// cc1与cc2对同类错误返回相同的错误码
+func TestErrorCodes(t *testing.T) {
+	stub := newStub(t)
+	run(t, stub, []step{
+		{"xiaozhang开户", xiaozhang, []string{"CreateAccount", `{"accountId":"xiaozhang"}`}, true},
+		{"xiaowang开户", xiaowang, []string{"CreateAccount", `{"accountId":"xiaowang"}`}, true},
+		{"AAA发行A1", aaa, []string{"AddAsset", "xiaozhang", `{"asset":{"issuer":"AAA","code":"A1","amount":100}}`}, true},
+	})
+
+	tests := []struct {
+		name   string
+		caller []byte
+		args   []string
+		code   string
+	}{
+		{"重复开户", xiaozhang, []string{"CreateAccount", `{"accountId":"xiaozhang"}`}, assetcore.CodeAlreadyExists},
+		{"帐户不存在", xiaozhang, []string{"GetAccount", `{"accountId":"xiaoli"}`}, assetcore.CodeAccountNotFound},
+		{"转移给不存在的帐户", xiaozhang, []string{"TransferAsset", "xiaozhang", `{"accountId":"xiaoli","asset":{"issuer":"AAA","code":"A1","amount":10}}`}, assetcore.CodeAccountNotFound},
+		{"持有数量不足", xiaozhang, []string{"TransferAsset", "xiaozhang", `{"accountId":"xiaowang","asset":{"issuer":"AAA","code":"A1","amount":101}}`}, assetcore.CodeInsufficientFunds},
+		{"非所有者不能转移", xiaowang, []string{"TransferAsset", "xiaozhang", `{"accountId":"xiaowang","asset":{"issuer":"AAA","code":"A1","amount":10}}`}, assetcore.CodeUnauthorized},
+		{"参数不完整", xiaozhang, []string{"TransferAsset", "xiaozhang", `{"accountId":"xiaowang"}`}, assetcore.CodeInvalidArgument},
+		{"未知方法", xiaozhang, []string{"Unknown"}, assetcore.CodeInvalidArgument},
+	}
+	for _, tt := range tests {
+		resp := stub.As(tt.caller).Invoke(append([]string{"invoke"}, tt.args...)...)
+		var e assetcore.CodedError
+		if err := json.Unmarshal([]byte(resp.Message), &e); err != nil || e.Code != tt.code || resp.Status < shim.ERRORTHRESHOLD {
+			t.Errorf("%s: status=%d message=%q, want code=%s", tt.name, resp.Status, resp.Message, tt.code)
+		}
+	}
+}
+
+// 同一帐户出现在多笔转移中时按顺序累计，任意一笔失败则全部不执行
+func TestBatchTransfer(t *testing.T) {
+	stub := newStub(t)
+	run(t, stub, []step{
+		{"xiaozhang开户", xiaozhang, []string{"CreateAccount", `{"accountId":"xiaozhang"}`}, true},
+		{"xiaozhang开第二个户", xiaozhang, []string{"CreateAccount", `{"accountId":"xiaozhang2"}`}, true},
+		{"xiaowang开户", xiaowang, []string{"CreateAccount", `{"accountId":"xiaowang"}`}, true},
+		{"AAA发行A1", aaa, []string{"AddAsset", "xiaozhang", `{"asset":{"issuer":"AAA","code":"A1","amount":100}}`}, true},
+		{"BBB发行B1", bbb, []string{"AddAsset", "xiaozhang", `{"asset":{"issuer":"BBB","code":"B1","amount":50}}`}, true},
+	})
+
+	a1 := func(from, to string, amount int) string {
+		return fmt.Sprintf(`{"from":"%s","to":"%s","asset":{"issuer":"AAA","code":"A1","amount":%d}}`, from, to, amount)
+	}
+	tests := []struct {
+		name string
+		legs []string
+		ok   bool
+	}{
+		{"最后一笔持有量不足", []string{a1("xiaozhang", "xiaozhang2", 60), a1("xiaozhang2", "xiaowang", 20), a1("xiaozhang", "xiaowang", 30), a1("xiaozhang", "xiaowang", 20)}, false},
+		{"最后一笔转给不存在的帐户", []string{a1("xiaozhang", "xiaowang", 10), a1("xiaozhang", "xiaowang", 10), a1("xiaozhang", "xiaoli", 10)}, false},
+		{"最后一笔转出帐户不属于调用者", []string{a1("xiaozhang", "xiaowang", 10), a1("xiaowang", "xiaozhang", 5)}, false},
+		{"最后一笔转给自己", []string{a1("xiaozhang", "xiaowang", 10), a1("xiaozhang", "xiaozhang", 5)}, false},
+	}
+	for _, tt := range tests {
+		run(t, stub, []step{{tt.name, xiaozhang, []string{"BatchTransfer", "[" + strings.Join(tt.legs, ",") + "]"}, tt.ok}})
+		checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 100, "BBB/B1": 50})
+		checkHoldings(t, stub, "xiaozhang2", map[string]int64{})
+		checkHoldings(t, stub, "xiaowang", map[string]int64{})
+	}
+
+	legs := []string{a1("xiaozhang", "xiaozhang2", 60), a1("xiaozhang2", "xiaowang", 20), a1("xiaozhang", "xiaowang", 30),
+		`{"from":"xiaozhang","to":"xiaowang","asset":{"issuer":"BBB","code":"B1","amount":50}}`}
+	run(t, stub, []step{{"批量转移", xiaozhang, []string{"BatchTransfer", "[" + strings.Join(legs, ",") + "]"}, true}})
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 10})
+	checkHoldings(t, stub, "xiaozhang2", map[string]int64{"AAA/A1": 40})
+	checkHoldings(t, stub, "xiaowang", map[string]int64{"AAA/A1": 50, "BBB/B1": 50})
+}
+
+// 冻结的资产不能转移；受益帐户到期前可以部分收取，到期后不能收取，由帐户所有者收回
+func TestHold(t *testing.T) {
+	stub := newStub(t)
+	hold := func(name string, amount, duration int) string {
+		return fmt.Sprintf(`{"name":"%s","to":"xiaowang","asset":{"issuer":"AAA","code":"A1","amount":%d},"duration":%d}`, name, amount, duration)
+	}
+	run(t, stub, []step{
+		{"xiaozhang开户", xiaozhang, []string{"CreateAccount", `{"accountId":"xiaozhang"}`}, true},
+		{"xiaowang开户", xiaowang, []string{"CreateAccount", `{"accountId":"xiaowang"}`}, true},
+		{"AAA发行A1", aaa, []string{"AddAsset", "xiaozhang", `{"asset":{"issuer":"AAA","code":"A1","amount":100}}`}, true},
+		{"冻结60股", xiaozhang, []string{"Hold", "xiaozhang", hold("h1", 60, 3600)}, true},
+		{"非所有者不能冻结", xiaowang, []string{"Hold", "xiaozhang", hold("h2", 10, 3600)}, false},
+		{"冻结名称重复", xiaozhang, []string{"Hold", "xiaozhang", hold("h1", 10, 3600)}, false},
+		{"冻结后持有量不足", xiaozhang, []string{"Hold", "xiaozhang", hold("h2", 41, 3600)}, false},
+		{"冻结的资产不能转移", xiaozhang, []string{"TransferAsset", "xiaozhang", `{"accountId":"xiaowang","asset":{"issuer":"AAA","code":"A1","amount":41}}`}, false},
+		{"到期前不能收回", xiaozhang, []string{"ReleaseHold", "xiaozhang", `{"name":"h1"}`}, false},
+		{"非受益帐户不能收取", xiaozhang, []string{"CaptureHold", "xiaozhang", `{"name":"h1"}`}, false},
+		{"收取数量超过冻结数量", xiaowang, []string{"CaptureHold", "xiaozhang", `{"name":"h1","amount":61}`}, false},
+	})
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 40})
+	checkHoldings(t, stub, "xiaowang", map[string]int64{})
+
+	run(t, stub, []step{
+		{"部分收取，剩余退回", xiaowang, []string{"CaptureHold", "xiaozhang", `{"name":"h1","amount":25}`}, true},
+		{"收取后冻结不存在", xiaowang, []string{"CaptureHold", "xiaozhang", `{"name":"h1"}`}, false},
+		{"冻结30股，60秒到期", xiaozhang, []string{"Hold", "xiaozhang", hold("h2", 30, 60)}, true},
+		{"冻结20股，60秒到期", xiaozhang, []string{"Hold", "xiaozhang", hold("h3", 20, 60)}, true},
+	})
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 25})
+	checkHoldings(t, stub, "xiaowang", map[string]int64{"AAA/A1": 25})
+
+	stub.Advance(61 * time.Second)
+	run(t, stub, []step{
+		{"到期后不能收取", xiaowang, []string{"CaptureHold", "xiaozhang", `{"name":"h2"}`}, false},
+		{"到期后所有者收回", xiaozhang, []string{"ReleaseHold", "xiaozhang", `{"name":"h2"}`}, true},
+		{"受益帐户释放", xiaowang, []string{"ReleaseHold", "xiaozhang", `{"name":"h3"}`}, true},
+	})
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 75})
+	checkHoldings(t, stub, "xiaowang", map[string]int64{"AAA/A1": 25})
+}
+
+// 赎回的资产从帐户中扣除并计入销毁数量，冻结的资产不能赎回
+func TestRedeem(t *testing.T) {
+	stub := newStub(t)
+	redeem := func(amount int) string {
+		return fmt.Sprintf(`{"asset":{"issuer":"AAA","code":"A1","amount":%d}}`, amount)
+	}
+	run(t, stub, []step{
+		{"xiaozhang开户", xiaozhang, []string{"CreateAccount", `{"accountId":"xiaozhang"}`}, true},
+		{"xiaowang开户", xiaowang, []string{"CreateAccount", `{"accountId":"xiaowang"}`}, true},
+		{"AAA发行A1", aaa, []string{"AddAsset", "xiaozhang", `{"asset":{"issuer":"AAA","code":"A1","amount":100}}`}, true},
+		{"赎回30股", xiaozhang, []string{"Redeem", "xiaozhang", redeem(30)}, true},
+		{"非所有者不能赎回", xiaowang, []string{"Redeem", "xiaozhang", redeem(10)}, false},
+		{"持有量不足", xiaozhang, []string{"Redeem", "xiaozhang", redeem(71)}, false},
+		{"冻结20股", xiaozhang, []string{"Hold", "xiaozhang", `{"name":"h1","to":"xiaowang","asset":{"issuer":"AAA","code":"A1","amount":20},"duration":3600}`}, true},
+		{"冻结的资产不能赎回", xiaozhang, []string{"Redeem", "xiaozhang", redeem(51)}, false},
+	})
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 50})
+
+	a := audit(t, stub)["AAA/A1"]
+	if a.Issued != 100 || a.Redeemed != 30 || a.Circulating != 50 || a.Held != 20 {
+		t.Errorf("AuditSupply AAA/A1: got %+v", a)
+	}
+}
+
+// 发行、赎回、冻结及转移后，帐户持有及冻结中的数量与发行总量记录一致
+func TestAuditSupply(t *testing.T) {
+	stub := newStub(t)
+	run(t, stub, []step{
+		{"xiaozhang开户", xiaozhang, []string{"CreateAccount", `{"accountId":"xiaozhang"}`}, true},
+		{"xiaowang开户", xiaowang, []string{"CreateAccount", `{"accountId":"xiaowang"}`}, true},
+		{"AAA发行A1", aaa, []string{"AddAsset", "xiaozhang", `{"asset":{"issuer":"AAA","code":"A1","amount":100}}`}, true},
+		{"BBB发行B1", bbb, []string{"AddAsset", "xiaozhang", `{"asset":{"issuer":"BBB","code":"B1","amount":50}}`}, true},
+		{"赎回10股A1", xiaozhang, []string{"Redeem", "xiaozhang", `{"asset":{"issuer":"AAA","code":"A1","amount":10}}`}, true},
+		{"冻结20股A1", xiaozhang, []string{"Hold", "xiaozhang", `{"name":"h1","to":"xiaowang","asset":{"issuer":"AAA","code":"A1","amount":20},"duration":3600}`}, true},
+		{"转移30股A1", xiaozhang, []string{"TransferAsset", "xiaozhang", `{"accountId":"xiaowang","asset":{"issuer":"AAA","code":"A1","amount":30}}`}, true},
+	})
+
+	audits := audit(t, stub)
+	if a := audits["AAA/A1"]; a.Issued != 100 || a.Redeemed != 10 || a.Circulating != 70 || a.Held != 20 {
+		t.Errorf("AuditSupply AAA/A1: got %+v", a)
+	}
+	if a := audits["BBB/B1"]; a.Issued != 50 || a.Redeemed != 0 || a.Circulating != 50 || a.Held != 0 {
+		t.Errorf("AuditSupply BBB/B1: got %+v", a)
+	}
+}
+
+// 按书签翻页，每页不超过pageSize个帐户，最后一页书签为空
+func TestListAccounts(t *testing.T) {
+	stub := newStub(t)
+	ids := []string{"a1", "a2", "a3", "a4", "a5"}
+	for _, id := range ids {
+		run(t, stub, []step{{"开户" + id, xiaozhang, []string{"CreateAccount", `{"accountId":"` + id + `"}`}, true}})
+	}
+	run(t, stub, []step{{"pageSize为0", xiaozhang, []string{"ListAccounts", `{"pageSize":0}`}, false}})
+
+	got := []string{}
+	bookmark := ""
+	for i := 0; i < len(ids); i++ {
+		resp := stub.Invoke("invoke", "ListAccounts", fmt.Sprintf(`{"pageSize":2,"bookmark":"%s"}`, bookmark))
+		var page struct {
+			Accounts []Account `json:"accounts"`
+			Count    int32     `json:"count"`
+			Bookmark string    `json:"bookmark"`
+		}
+		if resp.Status != shim.OK || json.Unmarshal(resp.Payload, &page) != nil {
+			t.Fatalf("ListAccounts: status=%d message=%q", resp.Status, resp.Message)
+		}
+		if page.Count != int32(len(page.Accounts)) || page.Count > 2 {
+			t.Errorf("ListAccounts page #%d: got %s", i, resp.Payload)
+		}
+		for _, a := range page.Accounts {
+			got = append(got, a.AccountId)
+		}
+		if bookmark = page.Bookmark; bookmark == "" {
+			break
+		}
+	}
+	if strings.Join(got, ",") != strings.Join(ids, ",") {
+		t.Errorf("ListAccounts: got %v, want %v", got, ids)
+	}
+}
+
+// 代理帐户在额度内转移所有者的资产，每次转移扣减额度，额度为0时取消授权
+func TestTransferFrom(t *testing.T) {
+	stub := newStub(t)
+	transferFrom := func(amount int) string {
+		return fmt.Sprintf(`{"from":"xiaozhang","accountId":"xiaowang","asset":{"issuer":"AAA","code":"A1","amount":%d}}`, amount)
+	}
+	allowance := func() int64 {
+		resp := stub.Invoke("invoke", "Allowance", `{"accountId":"xiaozhang","spender":"custodian","issuer":"AAA","code":"A1"}`)
+		var a assetcore.Allowance
+		if resp.Status != shim.OK || json.Unmarshal(resp.Payload, &a) != nil {
+			t.Fatalf("Allowance: status=%d message=%q", resp.Status, resp.Message)
+		}
+		return a.Amount
+	}
+	run(t, stub, []step{
+		{"xiaozhang开户", xiaozhang, []string{"CreateAccount", `{"accountId":"xiaozhang"}`}, true},
+		{"xiaowang开户", xiaowang, []string{"CreateAccount", `{"accountId":"xiaowang"}`}, true},
+		{"代理帐户开户", xiaowang, []string{"CreateAccount", `{"accountId":"custodian"}`}, true},
+		{"AAA发行A1", aaa, []string{"AddAsset", "xiaozhang", `{"asset":{"issuer":"AAA","code":"A1","amount":100}}`}, true},
+		{"非所有者不能授权", xiaowang, []string{"Approve", "xiaozhang", `{"spender":"custodian","asset":{"issuer":"AAA","code":"A1","amount":50}}`}, false},
+		{"授权50股", xiaozhang, []string{"Approve", "xiaozhang", `{"spender":"custodian","asset":{"issuer":"AAA","code":"A1","amount":50}}`}, true},
+		{"代理转移30股", xiaowang, []string{"TransferFrom", "custodian", transferFrom(30)}, true},
+		{"超出剩余额度", xiaowang, []string{"TransferFrom", "custodian", transferFrom(21)}, false},
+		{"非代理帐户所有者不能转移", xiaozhang, []string{"TransferFrom", "custodian", transferFrom(10)}, false},
+	})
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 70})
+	checkHoldings(t, stub, "xiaowang", map[string]int64{"AAA/A1": 30})
+	if got := allowance(); got != 20 {
+		t.Errorf("allowance: got %d, want 20", got)
+	}
+
+	run(t, stub, []step{
+		{"额度为0时取消授权", xiaozhang, []string{"Approve", "xiaozhang", `{"spender":"custodian","asset":{"issuer":"AAA","code":"A1","amount":0}}`}, true},
+		{"取消授权后不能转移", xiaowang, []string{"TransferFrom", "custodian", transferFrom(1)}, false},
+	})
+	if got := allowance(); got != 0 {
+		t.Errorf("allowance: got %d, want 0", got)
+	}
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 70})
+}
+
+// 设置审批策略后，超过阈值的数量不能冻结或收取，只能通过TransferAsset发起审批
+func TestApprovalThreshold(t *testing.T) {
+	stub := newStub(t)
+	policy := func(threshold int) string {
+		return fmt.Sprintf(`{"issuer":"AAA","code":"A1","threshold":%d,"quorum":1,"duration":3600,"signers":[{"mspId":"AdminMSP","subject":"CN=admin"}]}`, threshold)
+	}
+	hold := func(name string, amount int) string {
+		return fmt.Sprintf(`{"name":"%s","to":"xiaowang","asset":{"issuer":"AAA","code":"A1","amount":%d},"duration":3600}`, name, amount)
+	}
+	run(t, stub, []step{
+		{"xiaozhang开户", xiaozhang, []string{"CreateAccount", `{"accountId":"xiaozhang"}`}, true},
+		{"xiaowang开户", xiaowang, []string{"CreateAccount", `{"accountId":"xiaowang"}`}, true},
+		{"AAA发行A1", aaa, []string{"AddAsset", "xiaozhang", `{"asset":{"issuer":"AAA","code":"A1","amount":100}}`}, true},
+		{"阈值50", aaa, []string{"SetApprovalPolicy", policy(50)}, true},
+		{"冻结超过阈值", xiaozhang, []string{"Hold", "xiaozhang", hold("h1", 51)}, false},
+		{"冻结不超过阈值", xiaozhang, []string{"Hold", "xiaozhang", hold("h1", 40)}, true},
+		{"阈值降为30", aaa, []string{"SetApprovalPolicy", policy(30)}, true},
+		{"收取超过阈值", xiaowang, []string{"CaptureHold", "xiaozhang", `{"name":"h1"}`}, false},
+		{"收取不超过阈值", xiaowang, []string{"CaptureHold", "xiaozhang", `{"name":"h1","amount":30}`}, true},
+	})
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 70})
+	checkHoldings(t, stub, "xiaowang", map[string]int64{"AAA/A1": 30})
+}
+
+// 超过阈值的转移生成待审批转移，签名人审批数达到要求时执行；重复审批、非签名人审批及到期后审批被拒绝
+func TestApproveTransfer(t *testing.T) {
+	alice := memstub.MustIdentity("SignerMSP", "alice", nil)
+	bob := memstub.MustIdentity("SignerMSP", "bob", nil)
+	stub := newStub(t)
+	run(t, stub, []step{
+		{"xiaozhang开户", xiaozhang, []string{"CreateAccount", `{"accountId":"xiaozhang"}`}, true},
+		{"xiaowang开户", xiaowang, []string{"CreateAccount", `{"accountId":"xiaowang"}`}, true},
+		{"AAA发行A1", aaa, []string{"AddAsset", "xiaozhang", `{"asset":{"issuer":"AAA","code":"A1","amount":200}}`}, true},
+		{"阈值50，两人审批", aaa, []string{"SetApprovalPolicy", `{"issuer":"AAA","code":"A1","threshold":50,"quorum":2,"duration":3600,"signers":[{"mspId":"SignerMSP","subject":"CN=alice"},{"mspId":"SignerMSP","subject":"CN=bob"}]}`}, true},
+	})
+
+	propose := func() string {
+		t.Helper()
+		resp := stub.As(xiaozhang).Invoke("invoke", "TransferAsset", "xiaozhang", `{"accountId":"xiaowang","asset":{"issuer":"AAA","code":"A1","amount":60}}`)
+		var p assetcore.PendingTransfer
+		if resp.Status != shim.OK || json.Unmarshal(resp.Payload, &p) != nil || p.ID == "" {
+			t.Fatalf("TransferAsset: status=%d message=%q", resp.Status, resp.Message)
+		}
+		return `{"id":"` + p.ID + `"}`
+	}
+
+	p1 := propose()
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 200})
+	run(t, stub, []step{
+		{"非签名人不能审批", xiaowang, []string{"ApproveTransfer", p1}, false},
+		{"alice审批", alice, []string{"ApproveTransfer", p1}, true},
+		{"alice重复审批", alice, []string{"ApproveTransfer", p1}, false},
+	})
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 200})
+	run(t, stub, []step{
+		{"bob审批后执行", bob, []string{"ApproveTransfer", p1}, true},
+		{"执行后不存在", xiaozhang, []string{"PendingTransfer", p1}, false},
+	})
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 140})
+	checkHoldings(t, stub, "xiaowang", map[string]int64{"AAA/A1": 60})
+
+	p2, p3, p4 := propose(), propose(), propose()
+	run(t, stub, []step{
+		{"接收帐户不能取消", xiaowang, []string{"CancelTransfer", p2}, false},
+		{"转出帐户所有者取消", xiaozhang, []string{"CancelTransfer", p2}, true},
+		{"取消后不能审批", alice, []string{"ApproveTransfer", p2}, false},
+		{"签名人取消", bob, []string{"CancelTransfer", p3}, true},
+		{"alice审批p4", alice, []string{"ApproveTransfer", p4}, true},
+	})
+	stub.Advance(3601 * time.Second)
+	run(t, stub, []step{{"到期后不能审批", bob, []string{"ApproveTransfer", p4}, false}})
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 140})
+	checkHoldings(t, stub, "xiaowang", map[string]int64{"AAA/A1": 60})
+}
+
+// 归属计划锁定期内全部锁定，之后按时间线性解锁，锁定的数量不能转移
+func TestGrantVested(t *testing.T) {
+	stub := newStub(t)
+	transfer := func(amount int) []string {
+		return []string{"TransferAsset", "xiaozhang", fmt.Sprintf(`{"accountId":"xiaowang","asset":{"issuer":"AAA","code":"A1","amount":%d}}`, amount)}
+	}
+	grant := `{"asset":{"issuer":"AAA","code":"A1","amount":100},"cliff":100,"duration":400}`
+	run(t, stub, []step{
+		{"xiaozhang开户", xiaozhang, []string{"CreateAccount", `{"accountId":"xiaozhang"}`}, true},
+		{"xiaowang开户", xiaowang, []string{"CreateAccount", `{"accountId":"xiaowang"}`}, true},
+		{"AAA发行A1", aaa, []string{"AddAsset", "xiaozhang", `{"asset":{"issuer":"AAA","code":"A1","amount":20}}`}, true},
+		{"非发行机构不能授予", xiaozhang, []string{"GrantVested", "xiaozhang", grant}, false},
+		{"授予100股，锁定100秒，400秒解锁完", aaa, []string{"GrantVested", "xiaozhang", grant}, true},
+		{"未解锁完时不能再授予", aaa, []string{"GrantVested", "xiaozhang", grant}, false},
+		{"转移锁定的数量", xiaozhang, transfer(21), false},
+		{"转移未锁定的数量", xiaozhang, transfer(20), true},
+	})
+	stub.Advance(99 * time.Second)
+	run(t, stub, []step{{"锁定期内不能转移", xiaozhang, transfer(1), false}})
+
+	stub.Advance(101 * time.Second)
+	resp := stub.Invoke("invoke", "VestingStatus", `{"accountId":"xiaozhang","issuer":"AAA","code":"A1"}`)
+	var status assetcore.VestingStatus
+	if resp.Status != shim.OK || json.Unmarshal(resp.Payload, &status) != nil {
+		t.Fatalf("VestingStatus: status=%d message=%q", resp.Status, resp.Message)
+	}
+	if status.Vested != 50 || status.Locked != 50 || status.Balance != 100 || status.Unlocked != 50 {
+		t.Errorf("VestingStatus: got %+v", status)
+	}
+	run(t, stub, []step{
+		{"超过已解锁的数量", xiaozhang, transfer(51), false},
+		{"转移已解锁的数量", xiaozhang, transfer(50), true},
+	})
+
+	stub.Advance(200 * time.Second)
+	run(t, stub, []step{{"全部解锁后转移", xiaozhang, transfer(50), true}})
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{})
+	checkHoldings(t, stub, "xiaowang", map[string]int64{"AAA/A1": 120})
+}
+
+// 拆分后每条持仓及冻结按比例向下取整，舍弃的数量计入fractional，发行总量按调整后的数量重新计算
+func TestSplitAsset(t *testing.T) {
+	stub := newStub(t)
+	split := `{"issuer":"AAA","code":"A1","numerator":2,"denominator":3}`
+	run(t, stub, []step{
+		{"xiaozhang开户", xiaozhang, []string{"CreateAccount", `{"accountId":"xiaozhang"}`}, true},
+		{"xiaowang开户", xiaowang, []string{"CreateAccount", `{"accountId":"xiaowang"}`}, true},
+		{"AAA发行A1给xiaozhang", aaa, []string{"AddAsset", "xiaozhang", `{"asset":{"issuer":"AAA","code":"A1","amount":10}}`}, true},
+		{"AAA发行A1给xiaowang", aaa, []string{"AddAsset", "xiaowang", `{"asset":{"issuer":"AAA","code":"A1","amount":5}}`}, true},
+		{"冻结3股", xiaozhang, []string{"Hold", "xiaozhang", `{"name":"h1","to":"xiaowang","asset":{"issuer":"AAA","code":"A1","amount":3},"duration":3600}`}, true},
+		{"非管理员不能拆分", aaa, []string{"SplitAsset", split}, false},
+	})
+
+	resp := stub.As(admin).Invoke("invoke", "SplitAsset", split)
+	var s SplitSummary
+	if resp.Status != shim.OK || json.Unmarshal(resp.Payload, &s) != nil {
+		t.Fatalf("SplitAsset: status=%d message=%q", resp.Status, resp.Message)
+	}
+	// 7*2/3=4，5*2/3=3，3*2/3=2，合计9，按总量15*2/3应得10
+	if s.Holders != 2 || s.Holds != 1 || s.Before != 15 || s.After != 9 || s.Fractional != 1 {
+		t.Errorf("split summary: got %+v", s)
+	}
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 4})
+	checkHoldings(t, stub, "xiaowang", map[string]int64{"AAA/A1": 3})
+	if a := audit(t, stub)["AAA/A1"]; a.Issued-a.Redeemed != 9 || a.Held != 2 {
+		t.Errorf("AuditSupply AAA/A1: got %+v", a)
+	}
+}
+
+// 收取冻结视同转移，手续费由被冻结帐户从可用持有量中另外支付
+func TestCaptureHoldFee(t *testing.T) {
+	stub := newStub(t)
+	hold := func(name string, amount int) string {
+		return fmt.Sprintf(`{"name":"%s","to":"xiaowang","asset":{"issuer":"AAA","code":"A1","amount":%d},"duration":3600}`, name, amount)
+	}
+	run(t, stub, []step{
+		{"xiaozhang开户", xiaozhang, []string{"CreateAccount", `{"accountId":"xiaozhang"}`}, true},
+		{"xiaowang开户", xiaowang, []string{"CreateAccount", `{"accountId":"xiaowang"}`}, true},
+		{"收费帐户开户", admin, []string{"CreateAccount", `{"accountId":"fund"}`}, true},
+		{"AAA发行A1", aaa, []string{"AddAsset", "xiaozhang", `{"asset":{"issuer":"AAA","code":"A1","amount":100}}`}, true},
+		{"设置A1手续费10%", admin, []string{"SetTransferFee", `{"issuer":"AAA","code":"A1","mode":"bps","rate":1000,"collector":"fund"}`}, true},
+		{"冻结60股", xiaozhang, []string{"Hold", "xiaozhang", hold("h1", 60)}, true},
+		{"收取50股，剩余退回并扣除手续费", xiaowang, []string{"CaptureHold", "xiaozhang", `{"name":"h1","amount":50}`}, true},
+		{"冻结全部45股", xiaozhang, []string{"Hold", "xiaozhang", hold("h2", 45)}, true},
+		{"可用持有量不足以支付手续费", xiaowang, []string{"CaptureHold", "xiaozhang", `{"name":"h2"}`}, false},
+		{"收取40股", xiaowang, []string{"CaptureHold", "xiaozhang", `{"name":"h2","amount":40}`}, true},
+	})
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 1})
+	checkHoldings(t, stub, "xiaowang", map[string]int64{"AAA/A1": 90})
+	checkHoldings(t, stub, "fund", map[string]int64{"AAA/A1": 9})
+	audit(t, stub)
+}
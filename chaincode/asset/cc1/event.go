@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// EventVersion 事件格式版本，事件结构不兼容变更时递增
+const EventVersion = 1
+
+// Event 链码事件，每个成功修改状态的交易都会发出一个事件
+type Event struct {
+	Version     int    `json:"version"`              //事件格式版本
+	Type        string `json:"type"`                 //事件类型，与调用的函数名一致
+	TxID        string `json:"txId"`                 //交易ID
+	From        string `json:"from,omitempty"`       //转出帐户
+	To          string `json:"to,omitempty"`         //转入帐户
+	Issuer      string `json:"issuer,omitempty"`     //资产发行机构
+	Code        string `json:"code,omitempty"`       //资产代码
+	Amount      int64  `json:"amount"`               //变动数量
+	FromBalance int64  `json:"fromBalance"`          //转出帐户该资产变动后的数量
+	ToBalance   int64  `json:"toBalance"`            //转入帐户该资产变动后的数量
+	Reason      string `json:"reason,omitempty"`     //冻结或停牌原因
+	Spender     string `json:"spender,omitempty"`    //代理转移时的代理帐户
+	TransferID  string `json:"transferId,omitempty"` //待审批转移编号
+	Approvals   int    `json:"approvals,omitempty"`  //已审批数
+	Executed    bool   `json:"executed,omitempty"`   //审批后是否已执行转移
+	Fee         int64  `json:"fee,omitempty"`        //转出帐户另外支付的手续费
+	Collector   string `json:"collector,omitempty"`  //收费帐户
+
+	Legs  []TransferResult `json:"legs,omitempty"`  //批量转移中每笔转移的结果
+	Split *SplitSummary    `json:"split,omitempty"` //拆分/合并结果
+}
+
+// 发出链码事件，事件名为事件类型
+func (c *SimpleChaincode) emit(stub shim.ChaincodeStubInterface, ev Event) error {
+	ev.Version = EventVersion
+	ev.TxID = stub.GetTxID()
+
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	return stub.SetEvent(ev.Type, payload)
+}
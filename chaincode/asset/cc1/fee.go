@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ChainNova/samples/chaincode/asset/assetcore"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// 设置资产的转移手续费，只有管理员可以操作
+// 参数：{"issuer":"发行机构","code":"资产代码","mode":"flat或bps","rate":费率,"collector":"收费帐户"}，rate为0时取消收费
+func (c *SimpleChaincode) setTransferFee(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== setTransferFee ==========")
+	if len(args) < 1 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 1")
+	}
+
+	var f assetcore.FeeSchedule
+	// 解析参数
+	err := json.Unmarshal([]byte(args[0]), &f)
+	if err != nil || f.Issuer == "" || f.Code == "" || f.Rate < 0 || (f.Mode != assetcore.FeeModeFlat && f.Mode != assetcore.FeeModeBps) || (f.Mode == assetcore.FeeModeBps && f.Rate > 10000) {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "set transfer fee arguments error: issuer and code can't be nil; mode must be flat or bps; rate can't be less than 0, bps rate can't be greater than 10000.")
+	}
+
+	err = assetcore.SetTransferFee(stub, f)
+	if err != nil {
+		return assetcore.Error(err)
+	}
+
+	err = c.emit(stub, Event{Type: "SetTransferFee", Issuer: f.Issuer, Code: f.Code, Fee: f.Rate, Collector: f.Collector})
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+
+	return shim.Success(nil)
+}
+
+// 查询资产的转移手续费设置，未设置时rate为0
+// 参数：{"issuer":"发行机构","code":"资产代码"}
+func (c *SimpleChaincode) getTransferFee(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== getTransferFee ==========")
+	if len(args) < 1 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 1")
+	}
+
+	var prarm struct {
+		Issuer string `json:"issuer"` //资产发行机构
+		Code   string `json:"code"`   //资产代码
+	}
+	err := json.Unmarshal([]byte(args[0]), &prarm)
+	if err != nil || prarm.Issuer == "" || prarm.Code == "" {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "transfer fee arguments error: issuer and code can't be nil.")
+	}
+
+	f, err := assetcore.CheckTransferFee(stub, prarm.Issuer, prarm.Code)
+	if err != nil {
+		return assetcore.Errorf("Check transfer fee of issuer=%s&code=%s error:%s", prarm.Issuer, prarm.Code, err)
+	}
+
+	b, err := json.Marshal(f)
+	if err != nil {
+		return assetcore.Errorf("Marshal transfer fee error:%s", err)
+	}
+	return shim.Success(b)
+}
+
+// 返回转移结果
+func (c *SimpleChaincode) transferResponse(r TransferResult) pb.Response {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return assetcore.Errorf("Marshal transfer result error:%s", err)
+	}
+	return shim.Success(b)
+}
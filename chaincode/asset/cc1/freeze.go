@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ChainNova/samples/chaincode/asset/assetcore"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// 冻结/解冻帐户，只有管理员可以操作
+// 参数：账户ID
+//
+//	原因
+func (c *SimpleChaincode) freezeAccount(stub shim.ChaincodeStubInterface, args []string, active bool) pb.Response {
+	fmt.Println("=========== freezeAccount ==========")
+	if len(args) < 2 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 2")
+	}
+
+	accountId := args[0]
+	var prarm struct {
+		Reason string `json:"reason"` //原因
+	}
+	// 解析参数
+	err := json.Unmarshal([]byte(args[1]), &prarm)
+	if accountId == "" || prarm.Reason == "" || err != nil {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "freeze account arguments error: accountId and reason can't be nil.")
+	}
+
+	_, _, isExist, err := assetcore.CheckAccount(stub, accountId)
+	if err != nil {
+		return assetcore.Errorf("Check account=%s error:%s", accountId, err)
+	} else if !isExist {
+		return assetcore.Error(assetcore.AccountNotFound(accountId))
+	}
+
+	err = assetcore.SetRestriction(stub, assetcore.FreezeObjectType, []string{accountId}, active, prarm.Reason)
+	if err != nil {
+		return assetcore.Errorf("Freeze account=%s error:%s", accountId, err)
+	}
+
+	ev := Event{Type: "FreezeAccount", To: accountId, Reason: prarm.Reason}
+	if !active {
+		ev.Type = "UnfreezeAccount"
+	}
+	err = c.emit(stub, ev)
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+
+	return shim.Success(nil)
+}
+
+// 停牌/复牌资产，只有管理员可以操作
+// 参数：资产信息及原因
+func (c *SimpleChaincode) haltAsset(stub shim.ChaincodeStubInterface, args []string, active bool) pb.Response {
+	fmt.Println("=========== haltAsset ==========")
+	if len(args) < 1 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 1")
+	}
+
+	var prarm struct {
+		Issuer string `json:"issuer"` //资产发行机构
+		Code   string `json:"code"`   //资产代码
+		Reason string `json:"reason"` //原因
+	}
+	// 解析参数
+	err := json.Unmarshal([]byte(args[0]), &prarm)
+	if prarm.Issuer == "" || prarm.Code == "" || prarm.Reason == "" || err != nil {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "halt asset arguments error: issuer, code and reason can't be nil.")
+	}
+
+	err = assetcore.SetRestriction(stub, assetcore.HaltObjectType, []string{prarm.Issuer, prarm.Code}, active, prarm.Reason)
+	if err != nil {
+		return assetcore.Errorf("Halt asset issuer=%s&code=%s error:%s", prarm.Issuer, prarm.Code, err)
+	}
+
+	ev := Event{Type: "HaltAsset", Issuer: prarm.Issuer, Code: prarm.Code, Reason: prarm.Reason}
+	if !active {
+		ev.Type = "ResumeAsset"
+	}
+	err = c.emit(stub, ev)
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+
+	return shim.Success(nil)
+}
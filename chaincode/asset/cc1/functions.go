@@ -0,0 +1,135 @@
+package main
+
+import (
+	"github.com/ChainNova/samples/chaincode/asset/assetcore"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// 可调用的函数及参数，函数名之后的参数依次为帐户id等字符串参数及JSON参数
+func (c *SimpleChaincode) functions() []assetcore.Function {
+	var (
+		Str  = assetcore.Str
+		JSON = assetcore.JSON
+	)
+	asset := []string{"asset.issuer", "asset.code"}
+
+	return []assetcore.Function{
+		{Name: "CreateAccount", Desc: "创建帐户", Handler: c.createAccount, Args: []assetcore.Arg{
+			JSON("account", `{"accountId":"帐户id"}`, "accountId"),
+		}},
+		{Name: "AddAsset", Desc: "发行机构向帐户发行资产", Handler: c.addAsset, Args: []assetcore.Arg{
+			Str("accountId", "帐户id"),
+			JSON("asset", `{"asset":{"issuer":"","code":"","amount":0}}`, asset...),
+		}},
+		{Name: "TransferAsset", Desc: "转移资产", Handler: c.transferAsset, Args: []assetcore.Arg{
+			Str("from", "转出帐户id"),
+			JSON("transfer", `{"accountId":"转移目的帐户","asset":{"issuer":"","code":"","amount":0}}`, append([]string{"accountId"}, asset...)...),
+		}},
+		{Name: "Approve", Desc: "授权代理帐户转移资产的额度", Handler: c.approve, Args: []assetcore.Arg{
+			Str("owner", "资产所有者帐户id"),
+			JSON("approve", `{"spender":"代理帐户","asset":{"issuer":"","code":"","amount":0}}`, append([]string{"spender"}, asset...)...),
+		}},
+		{Name: "Allowance", Desc: "查询代理帐户的剩余额度", Query: true, Handler: c.allowance, Args: []assetcore.Arg{
+			JSON("allowance", `{"accountId":"所有者帐户","spender":"代理帐户","issuer":"","code":""}`, "accountId", "spender", "issuer", "code"),
+		}},
+		{Name: "TransferFrom", Desc: "代理帐户在额度内转移资产", Handler: c.transferFrom, Args: []assetcore.Arg{
+			Str("spender", "代理帐户id"),
+			JSON("transfer", `{"from":"转出帐户","accountId":"转移目的帐户","asset":{"issuer":"","code":"","amount":0}}`, append([]string{"from", "accountId"}, asset...)...),
+		}},
+		{Name: "SetApprovalPolicy", Desc: "设置资产的多方审批策略", Handler: c.setApprovalPolicy, Args: []assetcore.Arg{
+			JSON("policy", `{"issuer":"","code":"","threshold":0,"quorum":0,"signers":[],"duration":0}`, "issuer", "code"),
+		}},
+		{Name: "ApproveTransfer", Desc: "审批待审批转移", Handler: c.approveTransfer, Args: []assetcore.Arg{
+			JSON("transfer", `{"id":"待审批转移编号"}`, "id"),
+		}},
+		{Name: "CancelTransfer", Desc: "取消待审批转移", Handler: c.cancelTransfer, Args: []assetcore.Arg{
+			JSON("transfer", `{"id":"待审批转移编号"}`, "id"),
+		}},
+		{Name: "PendingTransfer", Desc: "查询待审批转移", Query: true, Handler: c.getPendingTransfer, Args: []assetcore.Arg{
+			JSON("transfer", `{"id":"待审批转移编号"}`, "id"),
+		}},
+		{Name: "GrantVested", Desc: "发行按时间解锁的资产", Handler: c.grantVested, Args: []assetcore.Arg{
+			Str("accountId", "帐户id"),
+			JSON("grant", `{"asset":{"issuer":"","code":"","amount":0},"cliff":0,"duration":0,"start":0}`, append(asset, "duration")...),
+		}},
+		{Name: "VestingStatus", Desc: "查询资产解锁进度", Query: true, Handler: c.vestingStatus, Args: []assetcore.Arg{
+			JSON("vesting", `{"accountId":"","issuer":"","code":""}`, "accountId", "issuer", "code"),
+		}},
+		{Name: "SplitAsset", Desc: "按比例拆分或合并资产", Handler: c.splitAsset, Args: []assetcore.Arg{
+			JSON("split", `{"issuer":"","code":"","numerator":0,"denominator":0}`, "issuer", "code", "numerator", "denominator"),
+		}},
+		{Name: "SetTransferFee", Desc: "设置资产的转移手续费", Handler: c.setTransferFee, Args: []assetcore.Arg{
+			JSON("fee", `{"issuer":"","code":"","mode":"flat或bps","rate":0,"collector":"收费帐户"}`, "issuer", "code", "mode"),
+		}},
+		{Name: "TransferFee", Desc: "查询资产的转移手续费", Query: true, Handler: c.getTransferFee, Args: []assetcore.Arg{
+			JSON("asset", `{"issuer":"","code":""}`, "issuer", "code"),
+		}},
+		{Name: "SetKYC", Desc: "登记帐户的KYC认证", Handler: c.setKYC, Args: []assetcore.Arg{
+			JSON("kyc", `{"accountId":"","status":"verified、suspended或revoked","tier":0,"expiry":0}`, "accountId", "status"),
+		}},
+		{Name: "KYC", Desc: "查询帐户的KYC认证", Query: true, Handler: c.getKYC, Args: []assetcore.Arg{
+			JSON("account", `{"accountId":"帐户id"}`, "accountId"),
+		}},
+		{Name: "SetEligibility", Desc: "设置开户或资产持有所需的最低认证等级", Handler: c.setEligibility, Args: []assetcore.Arg{
+			JSON("eligibility", `{"issuer":"","code":"","minTier":0}，issuer、code为空时为开户资格`, "minTier"),
+		}},
+		{Name: "BatchTransfer", Desc: "批量转移资产，全部成功或全部失败", Handler: c.batchTransfer, Args: []assetcore.Arg{
+			JSON("legs", `[{"from":"","to":"","asset":{"issuer":"","code":"","amount":0}}]`, append([]string{"from", "to"}, asset...)...),
+		}},
+		{Name: "Hold", Desc: "冻结帐户的部分资产", Handler: c.hold, Args: []assetcore.Arg{
+			Str("accountId", "被冻结帐户id"),
+			JSON("hold", `{"name":"冻结名称","to":"受益帐户","asset":{"issuer":"","code":"","amount":0},"duration":0}`, append([]string{"name", "to"}, asset...)...),
+		}},
+		{Name: "ReleaseHold", Desc: "释放冻结的资产", Handler: c.releaseHold, Args: []assetcore.Arg{
+			Str("accountId", "被冻结帐户id"),
+			JSON("hold", `{"name":"冻结名称"}`, "name"),
+		}},
+		{Name: "CaptureHold", Desc: "将冻结的资产转给受益帐户", Handler: c.captureHold, Args: []assetcore.Arg{
+			Str("accountId", "被冻结帐户id"),
+			JSON("hold", `{"name":"冻结名称","amount":0}，amount为0时收取全部`, "name"),
+		}},
+		{Name: "FreezeAccount", Desc: "冻结帐户", Handler: c.restrictAccount(true), Args: []assetcore.Arg{
+			Str("accountId", "帐户id"),
+			JSON("freeze", `{"reason":"原因"}`, "reason"),
+		}},
+		{Name: "UnfreezeAccount", Desc: "解冻帐户", Handler: c.restrictAccount(false), Args: []assetcore.Arg{
+			Str("accountId", "帐户id"),
+			JSON("freeze", `{"reason":"原因"}`, "reason"),
+		}},
+		{Name: "HaltAsset", Desc: "资产停牌", Handler: c.restrictAsset(true), Args: []assetcore.Arg{
+			JSON("halt", `{"issuer":"","code":"","reason":"原因"}`, "issuer", "code", "reason"),
+		}},
+		{Name: "ResumeAsset", Desc: "资产复牌", Handler: c.restrictAsset(false), Args: []assetcore.Arg{
+			JSON("halt", `{"issuer":"","code":"","reason":"原因"}`, "issuer", "code", "reason"),
+		}},
+		{Name: "Redeem", Desc: "向发行机构赎回资产", Handler: c.redeem, Args: []assetcore.Arg{
+			Str("accountId", "帐户id"),
+			JSON("redeem", `{"asset":{"issuer":"","code":"","amount":0}}`, asset...),
+		}},
+		{Name: "AuditSupply", Desc: "核对各资产的发行量与帐户持有量", Query: true, Handler: c.auditSupply},
+		{Name: "ListAccounts", Desc: "分页列出帐户", Query: true, Handler: c.listAccounts, Args: []assetcore.Arg{
+			JSON("page", `{"pageSize":0,"bookmark":"书签，第一页为空"}`, "pageSize"),
+		}},
+		{Name: "GetAccount", Desc: "查询帐户", Query: true, Handler: c.getAccount, Args: []assetcore.Arg{
+			JSON("account", `{"accountId":"帐户id"}`, "accountId"),
+		}},
+		{Name: "AccountHistory", Desc: "查询帐户的历史版本", Query: true, Handler: c.accountHistory, Args: []assetcore.Arg{
+			JSON("account", `{"accountId":"帐户id"}`, "accountId"),
+		}},
+	}
+}
+
+// 冻结或解冻帐户
+func (c *SimpleChaincode) restrictAccount(freeze bool) assetcore.Handler {
+	return func(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+		return c.freezeAccount(stub, args, freeze)
+	}
+}
+
+// 资产停牌或复牌
+func (c *SimpleChaincode) restrictAsset(halt bool) assetcore.Handler {
+	return func(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+		return c.haltAsset(stub, args, halt)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ChainNova/samples/chaincode/asset/assetcore"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// AccountModification 账户的一个历史版本
+type AccountModification struct {
+	TxID      string    `json:"txId"`      //交易ID
+	Timestamp time.Time `json:"timestamp"` //交易时间
+	IsDelete  bool      `json:"isDelete"`  //是否为删除操作
+	Account   *Account  `json:"account"`   //该版本的账户信息
+}
+
+// 获取账户历史
+// 参数：查询账户信息
+func (c *SimpleChaincode) accountHistory(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== accountHistory ==========")
+	if len(args) < 1 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 1")
+	}
+
+	var prarm struct {
+		AccountId string `json:"accountId"` //帐户id
+	}
+	// 解析参数
+	err := json.Unmarshal([]byte(args[0]), &prarm)
+	if prarm.AccountId == "" || err != nil {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "account history arguments error: AccountId can't be nil.")
+	}
+
+	historyIterator, err := stub.GetHistoryForKey(prarm.AccountId)
+	if err != nil {
+		return assetcore.Errorf("GetHistoryForKey account=%s error:%s", prarm.AccountId, err)
+	}
+	defer historyIterator.Close()
+
+	history := []AccountModification{}
+	for historyIterator.HasNext() {
+		km, err := historyIterator.Next()
+		if err != nil {
+			return assetcore.Errorf("Iterate history of account=%s error:%s", prarm.AccountId, err)
+		}
+
+		m := AccountModification{
+			TxID:     km.TxId,
+			IsDelete: km.IsDelete,
+		}
+		if km.Timestamp != nil {
+			m.Timestamp, _ = ptypes.Timestamp(km.Timestamp)
+		}
+		if !km.IsDelete && len(km.Value) > 0 {
+			var a Account
+			err = json.Unmarshal(km.Value, &a)
+			if err != nil {
+				fmt.Println("json.Unmarshal error:", err, string(km.Value))
+				continue
+			}
+			m.Account = &a
+		}
+		history = append(history, m)
+	}
+
+	b, err := json.Marshal(history)
+	if err != nil {
+		return assetcore.Errorf("Marshal history error:%s", err)
+	}
+	return shim.Success(b)
+}
@@ -0,0 +1,343 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ChainNova/samples/chaincode/asset/assetcore"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// 冻结资产
+// 参数：账户ID
+//
+//	冻结信息（名称、受益帐户、资产、有效时长秒数）
+func (c *SimpleChaincode) hold(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== hold ==========")
+	if len(args) < 2 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 2")
+	}
+
+	accountId := args[0]
+	var prarm struct {
+		Name     string `json:"name"`     //冻结名称
+		To       string `json:"to"`       //受益帐户
+		Asset    *Asset `json:"asset"`    //欲冻结的资产
+		Duration int64  `json:"duration"` //有效时长（秒）
+	}
+	// 解析参数
+	err := json.Unmarshal([]byte(args[1]), &prarm)
+	if accountId == "" || prarm.Name == "" || prarm.To == "" || prarm.Asset == nil || prarm.Asset.Issuer == "" || prarm.Asset.Code == "" || err != nil || prarm.Asset.Amount <= 0 || prarm.Duration <= 0 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "hold arguments error: account, name, to, issuer and code can't be nil; amount and duration must be numbers and greater than 0.")
+	}
+	if accountId == prarm.To {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Account=%s can't hold for itself.", accountId)
+	}
+
+	// 获取并校验账户信息
+	_, account, isExist, err := assetcore.CheckAccount(stub, accountId)
+	if err != nil {
+		return assetcore.Errorf("Check account=%s error:%s", accountId, err)
+	} else if !isExist {
+		return assetcore.Error(assetcore.AccountNotFound(accountId))
+	}
+
+	// 只有账户所有者才能冻结资产
+	err = assetcore.CheckOwner(stub, account.Owner, account.AccountId)
+	if err != nil {
+		return assetcore.Fail(assetcore.CodeUnauthorized, "Permission denied:%s", err)
+	}
+
+	// 资产不能停牌，帐户不能冻结
+	err = assetcore.CheckActive(stub, prarm.Asset.Issuer, prarm.Asset.Code, accountId, prarm.To)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+	// 接收帐户必须有资格持有该资产
+	err = assetcore.CheckEligible(stub, prarm.Asset.Issuer, prarm.Asset.Code, prarm.To)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+	// 冻结的资产可以被受益帐户收取，超过审批阈值的数量只能通过TransferAsset发起审批
+	_, required, err := assetcore.CheckApproval(stub, prarm.Asset.Issuer, prarm.Asset.Code, prarm.Asset.Amount)
+	if err != nil {
+		return assetcore.Errorf("Check approval policy of issuer=%s&code=%s error:%s", prarm.Asset.Issuer, prarm.Asset.Code, err)
+	} else if required {
+		return assetcore.Fail(assetcore.CodeFailedPrecondition, "Amount=%v of issuer=%s&code=%s requires approval, use TransferAsset.", prarm.Asset.Amount, prarm.Asset.Issuer, prarm.Asset.Code)
+	}
+
+	// 受益帐户必须存在
+	_, _, isExist, err = assetcore.CheckAccount(stub, prarm.To)
+	if err != nil {
+		return assetcore.Errorf("Check account=%s error:%s", prarm.To, err)
+	} else if !isExist {
+		return assetcore.Error(assetcore.AccountNotFound(prarm.To))
+	}
+
+	_, isExist, key, err := assetcore.CheckHold(stub, accountId, prarm.Name)
+	if err != nil {
+		return assetcore.Errorf("Check hold=%s of account=%s error:%s", prarm.Name, accountId, err)
+	} else if isExist {
+		return assetcore.Fail(assetcore.CodeAlreadyExists, "Hold=%s of account=%s already exists.", prarm.Name, accountId)
+	}
+
+	now, err := assetcore.TxTime(stub)
+	if err != nil {
+		return assetcore.Errorf("GetTxTimestamp error:%s", err)
+	}
+
+	// 从可用资产中扣除冻结数量
+	balance, err := c.debitAsset(stub, &account, prarm.Asset.Issuer, prarm.Asset.Code, prarm.Asset.Amount)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+
+	h := assetcore.Hold{
+		Name:    prarm.Name,
+		Account: accountId,
+		To:      prarm.To,
+		Issuer:  prarm.Asset.Issuer,
+		Code:    prarm.Asset.Code,
+		Amount:  prarm.Asset.Amount,
+		Expiry:  now + prarm.Duration,
+	}
+
+	err = assetcore.Save(stub, account.AccountId, account)
+	if err != nil {
+		return assetcore.Errorf("save account=%+v error:%s", account, err)
+	}
+	err = assetcore.Save(stub, key, h)
+	if err != nil {
+		return assetcore.Errorf("save hold=%+v error:%s", h, err)
+	}
+
+	err = c.emit(stub, Event{
+		Type:        "Hold",
+		From:        h.Account,
+		To:          h.To,
+		Issuer:      h.Issuer,
+		Code:        h.Code,
+		Amount:      h.Amount,
+		FromBalance: balance,
+	})
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+
+	return shim.Success(nil)
+}
+
+// 释放冻结，冻结资产退回原账户
+// 受益帐户可随时释放，被冻结账户的所有者只能在到期后收回
+// 参数：账户ID
+//
+//	冻结名称
+func (c *SimpleChaincode) releaseHold(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== releaseHold ==========")
+	if len(args) < 2 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 2")
+	}
+
+	h, key, account, to, now, err := c.loadHold(stub, args)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+
+	if assetcore.CheckOwner(stub, to.Owner, to.AccountId) != nil {
+		err = assetcore.CheckOwner(stub, account.Owner, account.AccountId)
+		if err != nil {
+			return assetcore.Fail(assetcore.CodeUnauthorized, "Permission denied:%s", err)
+		}
+		if now < h.Expiry {
+			return assetcore.Fail(assetcore.CodeFailedPrecondition, "Hold=%s of account=%s not expired until %v.", h.Name, h.Account, h.Expiry)
+		}
+	}
+
+	balance := account.Credit(h.Issuer, h.Code, h.Amount)
+	err = assetcore.Save(stub, account.AccountId, account)
+	if err != nil {
+		return assetcore.Errorf("save account=%+v error:%s", account, err)
+	}
+	err = stub.DelState(key)
+	if err != nil {
+		return assetcore.Errorf("DelState error:%s", err)
+	}
+
+	err = c.emit(stub, Event{
+		Type:      "ReleaseHold",
+		To:        h.Account,
+		Issuer:    h.Issuer,
+		Code:      h.Code,
+		Amount:    h.Amount,
+		ToBalance: balance,
+	})
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+
+	return shim.Success(nil)
+}
+
+// 收取冻结，冻结资产转入受益帐户，未收取的部分退回原账户
+// 只有受益帐户的所有者能在到期前收取，手续费由被冻结帐户另外支付
+// 参数：账户ID
+//
+//	冻结名称及收取数量（可选，默认全部）
+func (c *SimpleChaincode) captureHold(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== captureHold ==========")
+	if len(args) < 2 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 2")
+	}
+
+	h, key, account, to, now, err := c.loadHold(stub, args)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+
+	// 收取数量可选，默认全部收取
+	var prarm struct {
+		Amount int64 `json:"amount"` //收取数量
+	}
+	json.Unmarshal([]byte(args[1]), &prarm)
+	amount := h.Amount
+	if prarm.Amount != 0 {
+		if prarm.Amount < 0 || prarm.Amount > h.Amount {
+			return assetcore.Fail(assetcore.CodeInvalidArgument, "capture hold arguments error: amount must be greater than 0 and not greater than %v.", h.Amount)
+		}
+		amount = prarm.Amount
+	}
+
+	err = assetcore.CheckOwner(stub, to.Owner, to.AccountId)
+	if err != nil {
+		return assetcore.Fail(assetcore.CodeUnauthorized, "Permission denied:%s", err)
+	}
+
+	// 资产不能停牌，帐户不能冻结
+	err = assetcore.CheckActive(stub, h.Issuer, h.Code, h.Account, h.To)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+	// 接收帐户必须有资格持有该资产
+	err = assetcore.CheckEligible(stub, h.Issuer, h.Code, h.To)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+	if now >= h.Expiry {
+		return assetcore.Fail(assetcore.CodeFailedPrecondition, "Hold=%s of account=%s expired at %v.", h.Name, h.Account, h.Expiry)
+	}
+	// 冻结后设置的审批策略同样适用于收取
+	_, required, err := assetcore.CheckApproval(stub, h.Issuer, h.Code, amount)
+	if err != nil {
+		return assetcore.Errorf("Check approval policy of issuer=%s&code=%s error:%s", h.Issuer, h.Code, err)
+	} else if required {
+		return assetcore.Fail(assetcore.CodeFailedPrecondition, "Amount=%v of issuer=%s&code=%s requires approval, use TransferAsset.", amount, h.Issuer, h.Code)
+	}
+
+	// 收取视同转移，手续费由被冻结帐户从可用持有量中另外支付
+	fee, collector, err := assetcore.TransferFee(stub, h.Account, h.Issuer, h.Code, amount)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+
+	toBalance := to.Credit(h.Issuer, h.Code, amount)
+	// 未收取的部分退回原账户，并扣除手续费
+	var fromBalance int64
+	if amount < h.Amount || fee > 0 {
+		if amount < h.Amount {
+			fromBalance = account.Credit(h.Issuer, h.Code, h.Amount-amount)
+		}
+		if fee > 0 {
+			fromBalance, err = c.debitAsset(stub, &account, h.Issuer, h.Code, fee)
+			if err != nil {
+				return assetcore.Errorf("%s.", err)
+			}
+		}
+		err = assetcore.Save(stub, account.AccountId, account)
+		if err != nil {
+			return assetcore.Errorf("save account=%+v error:%s", account, err)
+		}
+	}
+	// 手续费转入收费帐户，收费帐户为受益帐户时直接累加
+	if fee > 0 {
+		if collector == to.AccountId {
+			toBalance = to.Credit(h.Issuer, h.Code, fee)
+		} else {
+			_, collectorAccount, isExist, err := assetcore.CheckAccount(stub, collector)
+			if err != nil {
+				return assetcore.Errorf("Check account=%s error:%s", collector, err)
+			} else if !isExist {
+				return assetcore.Error(assetcore.AccountNotFound(collector))
+			}
+			collectorAccount.Credit(h.Issuer, h.Code, fee)
+			err = assetcore.Save(stub, collectorAccount.AccountId, collectorAccount)
+			if err != nil {
+				return assetcore.Errorf("save account=%+v error:%s", collectorAccount, err)
+			}
+		}
+	}
+	err = assetcore.Save(stub, to.AccountId, to)
+	if err != nil {
+		return assetcore.Errorf("save account=%+v error:%s", to, err)
+	}
+	err = stub.DelState(key)
+	if err != nil {
+		return assetcore.Errorf("DelState error:%s", err)
+	}
+
+	err = c.emit(stub, Event{
+		Type:        "CaptureHold",
+		From:        h.Account,
+		To:          h.To,
+		Issuer:      h.Issuer,
+		Code:        h.Code,
+		Amount:      amount,
+		FromBalance: fromBalance,
+		ToBalance:   toBalance,
+		Fee:         fee,
+		Collector:   collector,
+	})
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+
+	return shim.Success(nil)
+}
+
+// 获取冻结及相关账户信息，冻结或账户不存在时返回错误
+func (c *SimpleChaincode) loadHold(stub shim.ChaincodeStubInterface, args []string) (h assetcore.Hold, key string, account, to Account, now int64, err error) {
+	accountId := args[0]
+	var prarm struct {
+		Name string `json:"name"` //冻结名称
+	}
+	err = json.Unmarshal([]byte(args[1]), &prarm)
+	if accountId == "" || prarm.Name == "" || err != nil {
+		return h, key, account, to, now, assetcore.NewError(assetcore.CodeInvalidArgument, "hold arguments error: account and name can't be nil")
+	}
+
+	h, isExist, key, err := assetcore.CheckHold(stub, accountId, prarm.Name)
+	if err != nil {
+		return h, key, account, to, now, fmt.Errorf("Check hold=%s of account=%s error:%s", prarm.Name, accountId, err)
+	} else if !isExist {
+		return h, key, account, to, now, assetcore.NewError(assetcore.CodeNotFound, "Hold=%s of account=%s not exists", prarm.Name, accountId)
+	}
+
+	_, account, isExist, err = assetcore.CheckAccount(stub, h.Account)
+	if err != nil {
+		return h, key, account, to, now, fmt.Errorf("Check account=%s error:%s", h.Account, err)
+	} else if !isExist {
+		return h, key, account, to, now, assetcore.AccountNotFound(h.Account)
+	}
+	_, to, isExist, err = assetcore.CheckAccount(stub, h.To)
+	if err != nil {
+		return h, key, account, to, now, fmt.Errorf("Check account=%s error:%s", h.To, err)
+	} else if !isExist {
+		return h, key, account, to, now, assetcore.AccountNotFound(h.To)
+	}
+
+	now, err = assetcore.TxTime(stub)
+	if err != nil {
+		return h, key, account, to, now, fmt.Errorf("GetTxTimestamp error:%s", err)
+	}
+	return h, key, account, to, now, nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ChainNova/samples/chaincode/asset/assetcore"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// 登记或更新帐户的KYC状态，只有合规角色可以操作
+// 参数：{"accountId":"帐户","status":"verified","tier":2,"expiry":到期时间}
+func (c *SimpleChaincode) setKYC(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== setKYC ==========")
+	if len(args) < 1 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 1")
+	}
+
+	var prarm struct {
+		AccountId string `json:"accountId"` //帐户id
+		Status    string `json:"status"`    //认证状态
+		Tier      int    `json:"tier"`      //认证等级
+		Expiry    int64  `json:"expiry"`    //到期时间（秒）
+	}
+	// 解析参数
+	err := json.Unmarshal([]byte(args[0]), &prarm)
+	if err != nil || prarm.AccountId == "" || (prarm.Status != assetcore.KYCVerified && prarm.Status != assetcore.KYCSuspended && prarm.Status != assetcore.KYCRevoked) || prarm.Tier < 0 || prarm.Expiry < 0 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "set KYC arguments error: accountId can't be nil; status must be verified, suspended or revoked; tier and expiry can't be less than 0.")
+	}
+
+	k := assetcore.KYC{ID: prarm.AccountId, Status: prarm.Status, Tier: prarm.Tier, Expiry: prarm.Expiry}
+	err = assetcore.SetKYC(stub, &k)
+	if err != nil {
+		return assetcore.Error(err)
+	}
+
+	err = c.emit(stub, Event{Type: "SetKYC", To: k.ID, Reason: k.Status})
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+
+	return shim.Success(nil)
+}
+
+// 查询帐户的KYC登记
+// 参数：{"accountId":"帐户"}
+func (c *SimpleChaincode) getKYC(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== getKYC ==========")
+	if len(args) < 1 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 1")
+	}
+
+	var prarm struct {
+		AccountId string `json:"accountId"` //帐户id
+	}
+	err := json.Unmarshal([]byte(args[0]), &prarm)
+	if err != nil || prarm.AccountId == "" {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "KYC arguments error: accountId can't be nil.")
+	}
+
+	k, isExist, err := assetcore.CheckKYC(stub, prarm.AccountId)
+	if err != nil {
+		return assetcore.Errorf("Check KYC of account=%s error:%s", prarm.AccountId, err)
+	} else if !isExist {
+		return assetcore.Fail(assetcore.CodeNotFound, "KYC of account=%s not exists.", prarm.AccountId)
+	}
+
+	b, err := json.Marshal(k)
+	if err != nil {
+		return assetcore.Errorf("Marshal KYC error:%s", err)
+	}
+	return shim.Success(b)
+}
+
+// 设置资产的持有资格，只有合规角色可以操作
+// 参数：{"issuer":"发行机构","code":"资产代码","minTier":最低认证等级}，issuer和code为空时设置开户资格，minTier为0时取消限制
+func (c *SimpleChaincode) setEligibility(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== setEligibility ==========")
+	if len(args) < 1 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 1")
+	}
+
+	var r assetcore.Eligibility
+	// 解析参数
+	err := json.Unmarshal([]byte(args[0]), &r)
+	if err != nil || (r.Issuer == "") != (r.Code == "") || r.MinTier < 0 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "set eligibility arguments error: issuer and code must be both set or both nil; minTier can't be less than 0.")
+	}
+
+	err = assetcore.SetEligibility(stub, r)
+	if err != nil {
+		return assetcore.Error(err)
+	}
+
+	err = c.emit(stub, Event{Type: "SetEligibility", Issuer: r.Issuer, Code: r.Code, Amount: int64(r.MinTier)})
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+
+	return shim.Success(nil)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ChainNova/samples/chaincode/asset/assetcore"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// 分页列出所有帐户
+// 参数：分页信息（每页数量、书签）
+func (c *SimpleChaincode) listAccounts(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== listAccounts ==========")
+	if len(args) < 1 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 1")
+	}
+
+	var prarm struct {
+		PageSize int32  `json:"pageSize"` //每页数量
+		Bookmark string `json:"bookmark"` //书签，第一页为空
+	}
+	// 解析参数
+	err := json.Unmarshal([]byte(args[0]), &prarm)
+	if err != nil || prarm.PageSize <= 0 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "list accounts arguments error: pageSize must be a number and greater than 0.")
+	}
+
+	// 帐户以AccountId为key，不包含复合key
+	accountsIterator, metadata, err := stub.GetStateByRangeWithPagination("", "", prarm.PageSize, prarm.Bookmark)
+	if err != nil {
+		return assetcore.Errorf("GetStateByRangeWithPagination error:%s", err)
+	}
+	defer accountsIterator.Close()
+
+	page := struct {
+		Accounts []Account `json:"accounts"` //本页帐户
+		Count    int32     `json:"count"`    //本页帐户数
+		Bookmark string    `json:"bookmark"` //下一页书签
+	}{Accounts: []Account{}}
+
+	for accountsIterator.HasNext() {
+		kv, err := accountsIterator.Next()
+		if err != nil {
+			return assetcore.Errorf("Iterate accounts error:%s", err)
+		}
+		var a Account
+		err = json.Unmarshal(kv.Value, &a)
+		if err != nil {
+			fmt.Println("json.Unmarshal error:", err, string(kv.Value))
+			continue
+		}
+		page.Accounts = append(page.Accounts, a)
+	}
+	if metadata != nil {
+		page.Count = metadata.FetchedRecordsCount
+		page.Bookmark = metadata.Bookmark
+	}
+
+	b, err := json.Marshal(page)
+	if err != nil {
+		return assetcore.Errorf("Marshal page error:%s", err)
+	}
+	return shim.Success(b)
+}
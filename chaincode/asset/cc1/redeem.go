@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ChainNova/samples/chaincode/asset/assetcore"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// 赎回资产，持有者将资产退还发行机构，退还的资产被销毁
+// 参数：账户ID
+//
+//	赎回资产信息
+func (c *SimpleChaincode) redeem(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== redeem ==========")
+	if len(args) < 2 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 2")
+	}
+
+	accountId := args[0]
+	var redeem struct {
+		Asset *Asset `json:"asset"` //欲赎回的资产
+	}
+	// 解析参数
+	err := json.Unmarshal([]byte(args[1]), &redeem)
+	if accountId == "" || redeem.Asset == nil || redeem.Asset.Issuer == "" || redeem.Asset.Code == "" || err != nil || redeem.Asset.Amount <= 0 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "redeem arguments error: accountId, issuer and code can't be nil; amount must be a number and greater than 0.")
+	}
+
+	// 获取并校验账户信息
+	_, account, isExist, err := assetcore.CheckAccount(stub, accountId)
+	if err != nil {
+		return assetcore.Errorf("Check account=%s error:%s", accountId, err)
+	} else if !isExist {
+		return assetcore.Error(assetcore.AccountNotFound(accountId))
+	}
+
+	// 只有账户所有者才能赎回资产
+	err = assetcore.CheckOwner(stub, account.Owner, account.AccountId)
+	if err != nil {
+		return assetcore.Fail(assetcore.CodeUnauthorized, "Permission denied:%s", err)
+	}
+
+	// 资产不能停牌，帐户不能冻结
+	err = assetcore.CheckActive(stub, redeem.Asset.Issuer, redeem.Asset.Code, accountId)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+
+	balance, err := c.debitAsset(stub, &account, redeem.Asset.Issuer, redeem.Asset.Code, redeem.Asset.Amount)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+
+	// 保存账户资产
+	err = assetcore.Save(stub, account.AccountId, account)
+	if err != nil {
+		return assetcore.Errorf("save account=%+v error:%s", account, err)
+	}
+
+	// 累计赎回销毁数量
+	err = assetcore.AddSupply(stub, redeem.Asset.Issuer, redeem.Asset.Code, 0, redeem.Asset.Amount)
+	if err != nil {
+		return assetcore.Errorf("Update supply of issuer=%s&code=%s error:%s", redeem.Asset.Issuer, redeem.Asset.Code, err)
+	}
+
+	err = c.emit(stub, Event{
+		Type:        "Redeem",
+		From:        account.AccountId,
+		Issuer:      redeem.Asset.Issuer,
+		Code:        redeem.Asset.Code,
+		Amount:      redeem.Asset.Amount,
+		FromBalance: balance,
+	})
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+
+	return shim.Success(nil)
+}
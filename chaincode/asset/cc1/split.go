@@ -0,0 +1,257 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/ChainNova/samples/chaincode/asset/assetcore"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// SplitSummary 拆分/合并结果
+// 每条记录的数量按 数量*Numerator/Denominator 向下取整，不足1单位的部分舍弃
+type SplitSummary struct {
+	Issuer      string `json:"issuer"`      //资产发行机构
+	Code        string `json:"code"`        //资产代码
+	Numerator   int64  `json:"numerator"`   //拆分比例分子
+	Denominator int64  `json:"denominator"` //拆分比例分母
+	Holders     int    `json:"holders"`     //调整的帐户数
+	Holds       int    `json:"holds"`       //调整的冻结数
+	Before      int64  `json:"before"`      //调整前帐户持有及冻结的总量
+	After       int64  `json:"after"`       //调整后帐户持有及冻结的总量
+	Fractional  int64  `json:"fractional"`  //按总量计算应得与逐条取整后合计的差额，即舍弃的数量
+	Vestings    int    `json:"vestings"`    //调整的归属计划数
+	Allowances  int    `json:"allowances"`  //调整的授权额度数
+	Pending     int    `json:"pending"`     //调整的待审批转移数
+}
+
+// 按比例拆分或合并资产，只有管理员可以操作
+// 如2拆1为{"numerator":2,"denominator":1}，10合1为{"numerator":1,"denominator":10}
+// 参数：{"issuer":"发行机构","code":"资产代码","numerator":分子,"denominator":分母}
+func (c *SimpleChaincode) splitAsset(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== splitAsset ==========")
+	if len(args) < 1 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 1")
+	}
+
+	var prarm struct {
+		Issuer      string `json:"issuer"`      //资产发行机构
+		Code        string `json:"code"`        //资产代码
+		Numerator   int64  `json:"numerator"`   //拆分比例分子
+		Denominator int64  `json:"denominator"` //拆分比例分母
+	}
+	// 解析参数
+	err := json.Unmarshal([]byte(args[0]), &prarm)
+	if err != nil || prarm.Issuer == "" || prarm.Code == "" || prarm.Numerator <= 0 || prarm.Denominator <= 0 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "split asset arguments error: issuer and code can't be nil; numerator and denominator must be numbers and greater than 0.")
+	}
+	summary := SplitSummary{Issuer: prarm.Issuer, Code: prarm.Code, Numerator: prarm.Numerator, Denominator: prarm.Denominator}
+
+	err = assetcore.CheckAdmin(stub)
+	if err != nil {
+		return assetcore.Fail(assetcore.CodeUnauthorized, "Permission denied:%s", err)
+	}
+
+	err = c.rescaleAsset(stub, &summary)
+	if err != nil {
+		return assetcore.Errorf("Split asset issuer=%s&code=%s error:%s", prarm.Issuer, prarm.Code, err)
+	}
+
+	err = c.emit(stub, Event{Type: "SplitAsset", Issuer: summary.Issuer, Code: summary.Code, Amount: summary.After, Split: &summary})
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+
+	b, err := json.Marshal(summary)
+	if err != nil {
+		return assetcore.Errorf("Marshal split summary error:%s", err)
+	}
+	return shim.Success(b)
+}
+
+// 调整所有帐户持有、冻结、归属计划、授权额度、待审批转移、审批阈值、手续费及发行总量记录中该资产的数量
+func (c *SimpleChaincode) rescaleAsset(stub shim.ChaincodeStubInterface, s *SplitSummary) error {
+	scale := func(v int64) (int64, error) {
+		if v > math.MaxInt64/s.Numerator {
+			return 0, assetcore.NewError(assetcore.CodeInvalidArgument, "amount=%v overflows after split", v)
+		}
+		return v * s.Numerator / s.Denominator, nil
+	}
+
+	// 帐户持有数量，帐户以AccountId为key，不包含复合key
+	accountsIterator, err := stub.GetStateByRange("", "")
+	if err != nil {
+		return fmt.Errorf("GetStateByRange error:%s", err)
+	}
+	defer accountsIterator.Close()
+	for accountsIterator.HasNext() {
+		kv, err := accountsIterator.Next()
+		if err != nil {
+			return fmt.Errorf("Iterate accounts error:%s", err)
+		}
+		var a Account
+		err = json.Unmarshal(kv.Value, &a)
+		if err != nil || a.AccountId == "" {
+			fmt.Println("json.Unmarshal error:", err, string(kv.Value))
+			continue
+		}
+		changed := false
+		for k, v := range a.Assets {
+			if v.Issuer != s.Issuer || v.Code != s.Code {
+				continue
+			}
+			amount, err := scale(v.Amount)
+			if err != nil {
+				return assetcore.Wrapf("account=%s %s", a.AccountId, err)
+			}
+			s.Before += v.Amount
+			s.After += amount
+			a.Assets[k].Amount = amount
+			changed = true
+		}
+		if changed {
+			err = assetcore.Save(stub, a.AccountId, a)
+			if err != nil {
+				return fmt.Errorf("save account=%+v error:%s", a, err)
+			}
+			s.Holders++
+		}
+	}
+
+	// 冻结中的数量
+	s.Holds, err = c.rescaleRecords(stub, assetcore.HoldObjectType, func(b []byte) (interface{}, bool, error) {
+		var h assetcore.Hold
+		err := json.Unmarshal(b, &h)
+		if err != nil || h.Issuer != s.Issuer || h.Code != s.Code {
+			return nil, false, err
+		}
+		amount, err := scale(h.Amount)
+		s.Before += h.Amount
+		s.After += amount
+		h.Amount = amount
+		return h, true, err
+	})
+	if err != nil {
+		return err
+	}
+
+	// 按总量计算应得的数量，与逐条取整后的合计比较
+	expected, err := scale(s.Before)
+	if err != nil {
+		return err
+	}
+	s.Fractional = expected - s.After
+
+	s.Vestings, err = c.rescaleRecords(stub, assetcore.VestingObjectType, func(b []byte) (interface{}, bool, error) {
+		var v assetcore.Vesting
+		err := json.Unmarshal(b, &v)
+		if err != nil || v.Issuer != s.Issuer || v.Code != s.Code {
+			return nil, false, err
+		}
+		v.Total, err = scale(v.Total)
+		return v, true, err
+	})
+	if err != nil {
+		return err
+	}
+
+	s.Allowances, err = c.rescaleRecords(stub, assetcore.AllowanceObjectType, func(b []byte) (interface{}, bool, error) {
+		var a assetcore.Allowance
+		err := json.Unmarshal(b, &a)
+		if err != nil || a.Issuer != s.Issuer || a.Code != s.Code {
+			return nil, false, err
+		}
+		a.Amount, err = scale(a.Amount)
+		return a, true, err
+	})
+	if err != nil {
+		return err
+	}
+
+	s.Pending, err = c.rescaleRecords(stub, assetcore.PendingTransferObjectType, func(b []byte) (interface{}, bool, error) {
+		var t assetcore.PendingTransfer
+		err := json.Unmarshal(b, &t)
+		if err != nil || t.Issuer != s.Issuer || t.Code != s.Code {
+			return nil, false, err
+		}
+		t.Amount, err = scale(t.Amount)
+		return t, true, err
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = c.rescaleRecords(stub, assetcore.ApprovalPolicyObjectType, func(b []byte) (interface{}, bool, error) {
+		var p assetcore.ApprovalPolicy
+		err := json.Unmarshal(b, &p)
+		if err != nil || p.Issuer != s.Issuer || p.Code != s.Code {
+			return nil, false, err
+		}
+		p.Threshold, err = scale(p.Threshold)
+		return p, true, err
+	})
+	if err != nil {
+		return err
+	}
+
+	// 按笔收取的手续费数量，不足1时保留1；按比例收取的不变
+	_, err = c.rescaleRecords(stub, assetcore.FeeObjectType, func(b []byte) (interface{}, bool, error) {
+		var f assetcore.FeeSchedule
+		err := json.Unmarshal(b, &f)
+		if err != nil || f.Issuer != s.Issuer || f.Code != s.Code || f.Mode != assetcore.FeeModeFlat {
+			return nil, false, err
+		}
+		f.Rate, err = scale(f.Rate)
+		if err == nil && f.Rate == 0 {
+			f.Rate = 1
+		}
+		return f, true, err
+	})
+	if err != nil {
+		return err
+	}
+
+	// 发行总量记录：赎回数量按比例调整，发行数量按调整后的流通量重新计算，舍弃的数量不再计入
+	_, err = c.rescaleRecords(stub, assetcore.SupplyObjectType, func(b []byte) (interface{}, bool, error) {
+		var supply assetcore.Supply
+		err := json.Unmarshal(b, &supply)
+		if err != nil || supply.Issuer != s.Issuer || supply.Code != s.Code {
+			return nil, false, err
+		}
+		supply.Redeemed, err = scale(supply.Redeemed)
+		supply.Issued = supply.Redeemed + s.After
+		return supply, true, err
+	})
+	return err
+}
+
+// 遍历某类复合key的所有记录，rescale返回需要保存的新记录
+func (c *SimpleChaincode) rescaleRecords(stub shim.ChaincodeStubInterface, objectType string, rescale func([]byte) (interface{}, bool, error)) (int, error) {
+	iterator, err := stub.GetStateByPartialCompositeKey(objectType, []string{})
+	if err != nil {
+		return 0, fmt.Errorf("GetStateByPartialCompositeKey error:%s", err)
+	}
+	defer iterator.Close()
+
+	count := 0
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return count, fmt.Errorf("Iterate %s error:%s", objectType, err)
+		}
+		v, changed, err := rescale(kv.Value)
+		if err != nil {
+			return count, fmt.Errorf("rescale %s error:%s", kv.Key, err)
+		} else if !changed {
+			continue
+		}
+		err = assetcore.Save(stub, kv.Key, v)
+		if err != nil {
+			return count, fmt.Errorf("save %s error:%s", kv.Key, err)
+		}
+		count++
+	}
+	return count, nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/ChainNova/samples/chaincode/asset/assetcore"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// SupplyAudit 一类资产的对账结果
+type SupplyAudit struct {
+	assetcore.Supply
+	Circulating int64 `json:"circulating"` //帐户持有数量
+	Held        int64 `json:"held"`        //冻结中的数量
+	Discrepancy int64 `json:"discrepancy"` //circulating+held-(issued-redeemed)，不为0表示账实不符
+}
+
+// 资产总量对账
+// 遍历所有帐户及冻结，按发行机构和资产代码汇总，与发行总量记录核对
+func (c *SimpleChaincode) auditSupply(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== auditSupply ==========")
+
+	audits := map[string]*SupplyAudit{}
+	get := func(issuer, code string) *SupplyAudit {
+		k := issuer + "~" + code
+		if _, ok := audits[k]; !ok {
+			audits[k] = &SupplyAudit{Supply: assetcore.Supply{Issuer: issuer, Code: code}}
+		}
+		return audits[k]
+	}
+
+	// 发行总量记录
+	supplyIterator, err := stub.GetStateByPartialCompositeKey(assetcore.SupplyObjectType, []string{})
+	if err != nil {
+		return assetcore.Errorf("GetStateByPartialCompositeKey error:%s", err)
+	}
+	defer supplyIterator.Close()
+	for supplyIterator.HasNext() {
+		kv, err := supplyIterator.Next()
+		if err != nil {
+			return assetcore.Errorf("Iterate supply error:%s", err)
+		}
+		var s assetcore.Supply
+		err = json.Unmarshal(kv.Value, &s)
+		if err != nil {
+			return assetcore.Errorf("Unmarshal supply=%s error:%s", string(kv.Value), err)
+		}
+		get(s.Issuer, s.Code).Supply = s
+	}
+
+	// 帐户持有数量，帐户以AccountId为key，不包含复合key
+	accountsIterator, err := stub.GetStateByRange("", "")
+	if err != nil {
+		return assetcore.Errorf("GetStateByRange error:%s", err)
+	}
+	defer accountsIterator.Close()
+	for accountsIterator.HasNext() {
+		kv, err := accountsIterator.Next()
+		if err != nil {
+			return assetcore.Errorf("Iterate accounts error:%s", err)
+		}
+		var a Account
+		err = json.Unmarshal(kv.Value, &a)
+		if err != nil || a.AccountId == "" {
+			fmt.Println("json.Unmarshal error:", err, string(kv.Value))
+			continue
+		}
+		for _, v := range a.Assets {
+			get(v.Issuer, v.Code).Circulating += v.Amount
+		}
+	}
+
+	// 冻结中的数量
+	holdsIterator, err := stub.GetStateByPartialCompositeKey(assetcore.HoldObjectType, []string{})
+	if err != nil {
+		return assetcore.Errorf("GetStateByPartialCompositeKey error:%s", err)
+	}
+	defer holdsIterator.Close()
+	for holdsIterator.HasNext() {
+		kv, err := holdsIterator.Next()
+		if err != nil {
+			return assetcore.Errorf("Iterate holds error:%s", err)
+		}
+		var h assetcore.Hold
+		err = json.Unmarshal(kv.Value, &h)
+		if err != nil {
+			fmt.Println("json.Unmarshal error:", err, string(kv.Value))
+			continue
+		}
+		get(h.Issuer, h.Code).Held += h.Amount
+	}
+
+	report := struct {
+		Assets        []SupplyAudit `json:"assets"`
+		Discrepancies []SupplyAudit `json:"discrepancies"`
+	}{Assets: []SupplyAudit{}, Discrepancies: []SupplyAudit{}}
+
+	keys := []string{}
+	for k := range audits {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		a := audits[k]
+		a.Discrepancy = a.Circulating + a.Held - (a.Issued - a.Redeemed)
+		report.Assets = append(report.Assets, *a)
+		if a.Discrepancy != 0 {
+			report.Discrepancies = append(report.Discrepancies, *a)
+		}
+	}
+
+	b, err := json.Marshal(report)
+	if err != nil {
+		return assetcore.Errorf("Marshal report error:%s", err)
+	}
+	return shim.Success(b)
+}
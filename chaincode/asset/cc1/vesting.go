@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ChainNova/samples/chaincode/asset/assetcore"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// 按归属计划授予资产，只有发行机构可以操作
+// 参数：账户ID、授予信息（资产、开始时间、锁定期、归属总时长），开始时间为0时从当前交易时间开始
+func (c *SimpleChaincode) grantVested(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== grantVested ==========")
+	if len(args) < 2 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 2")
+	}
+
+	accountId := args[0]
+	var grant struct {
+		Asset    *Asset `json:"asset"`    //授予的资产
+		Start    int64  `json:"start"`    //开始时间（秒）
+		Cliff    int64  `json:"cliff"`    //锁定期（秒）
+		Duration int64  `json:"duration"` //归属总时长（秒）
+	}
+	// 解析参数
+	err := json.Unmarshal([]byte(args[1]), &grant)
+	if err != nil || accountId == "" || grant.Asset == nil || grant.Asset.Issuer == "" || grant.Asset.Code == "" || grant.Asset.Amount <= 0 || grant.Duration <= 0 || grant.Cliff < 0 || grant.Cliff > grant.Duration {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "grant vested arguments error: accountId, issuer and code can't be nil; amount and duration must be greater than 0; cliff must be between 0 and duration.")
+	}
+	asset := *grant.Asset
+
+	// 只有发行机构才能授予该机构的资产
+	err = assetcore.CheckIssuer(stub, asset.Issuer)
+	if err != nil {
+		return assetcore.Fail(assetcore.CodeUnauthorized, "Permission denied:%s", err)
+	}
+
+	// 资产不能停牌，帐户不能冻结
+	err = assetcore.CheckActive(stub, asset.Issuer, asset.Code, accountId)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+	// 接收帐户必须有资格持有该资产
+	err = assetcore.CheckEligible(stub, asset.Issuer, asset.Code, accountId)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+
+	_, account, isExist, err := assetcore.CheckAccount(stub, accountId)
+	if err != nil {
+		return assetcore.Errorf("Check account=%s error:%s", accountId, err)
+	} else if !isExist {
+		return assetcore.Error(assetcore.AccountNotFound(accountId))
+	}
+
+	now, err := assetcore.TxTime(stub)
+	if err != nil {
+		return assetcore.Errorf("GetTxTimestamp error:%s", err)
+	}
+
+	// 每个帐户的每类资产只有一个归属计划，原计划全部解锁后才能再次授予
+	v, key, err := assetcore.CheckVesting(stub, accountId, asset.Issuer, asset.Code)
+	if err != nil {
+		return assetcore.Errorf("Check vesting of account=%s, asset issuer=%s&code=%s error:%s", accountId, asset.Issuer, asset.Code, err)
+	} else if locked := v.Total - assetcore.VestedAmount(v, now); locked > 0 {
+		return assetcore.Fail(assetcore.CodeRestricted, "Account=%s issuer=%s&code=%s still has %v locked by vesting.", accountId, asset.Issuer, asset.Code, locked)
+	}
+
+	v = assetcore.Vesting{
+		DocType:  assetcore.VestingDocType,
+		ID:       accountId,
+		Issuer:   asset.Issuer,
+		Code:     asset.Code,
+		Total:    asset.Amount,
+		Start:    grant.Start,
+		Cliff:    grant.Cliff,
+		Duration: grant.Duration,
+	}
+	if v.Start == 0 {
+		v.Start = now
+	}
+
+	balance := account.Credit(asset.Issuer, asset.Code, asset.Amount)
+	err = assetcore.Save(stub, account.AccountId, account)
+	if err != nil {
+		return assetcore.Errorf("save account=%+v error:%s", account, err)
+	}
+	err = assetcore.Save(stub, key, v)
+	if err != nil {
+		return assetcore.Errorf("save vesting=%+v error:%s", v, err)
+	}
+
+	// 累计发行数量
+	err = assetcore.AddSupply(stub, asset.Issuer, asset.Code, asset.Amount, 0)
+	if err != nil {
+		return assetcore.Errorf("Update supply of issuer=%s&code=%s error:%s", asset.Issuer, asset.Code, err)
+	}
+
+	err = c.emit(stub, Event{Type: "GrantVested", To: accountId, Issuer: asset.Issuer, Code: asset.Code, Amount: asset.Amount, ToBalance: balance})
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+
+	return shim.Success(nil)
+}
+
+// 查询归属状态
+// 参数：{"accountId":"帐户","issuer":"发行机构","code":"资产代码"}
+func (c *SimpleChaincode) vestingStatus(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== vestingStatus ==========")
+	if len(args) < 1 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 1")
+	}
+
+	var prarm struct {
+		AccountId string `json:"accountId"` //帐户id
+		Issuer    string `json:"issuer"`    //资产发行机构
+		Code      string `json:"code"`      //资产代码
+	}
+	err := json.Unmarshal([]byte(args[0]), &prarm)
+	if err != nil || prarm.AccountId == "" || prarm.Issuer == "" || prarm.Code == "" {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "vesting status arguments error: accountId, issuer and code can't be nil.")
+	}
+
+	// 帐户不存在时返回错误
+	balance, err := c.store.Holding(stub, prarm.AccountId, prarm.Issuer, prarm.Code)
+	if err != nil {
+		return assetcore.Errorf("Check account=%s error:%s", prarm.AccountId, err)
+	}
+
+	v, _, err := assetcore.CheckVesting(stub, prarm.AccountId, prarm.Issuer, prarm.Code)
+	if err != nil {
+		return assetcore.Errorf("Check vesting of account=%s, asset issuer=%s&code=%s error:%s", prarm.AccountId, prarm.Issuer, prarm.Code, err)
+	}
+	now, err := assetcore.TxTime(stub)
+	if err != nil {
+		return assetcore.Errorf("GetTxTimestamp error:%s", err)
+	}
+
+	b, err := json.Marshal(assetcore.NewVestingStatus(v, balance, now))
+	if err != nil {
+		return assetcore.Errorf("Marshal vesting status error:%s", err)
+	}
+	return shim.Success(b)
+}
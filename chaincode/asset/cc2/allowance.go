@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/ChainNova/samples/chaincode/asset/assetcore"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// 授权代理帐户转出资产，额度为0时取消授权
+// 参数：所有者帐户、代理帐户、issuer、code、额度
+func (c *SimpleChaincode) approve(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== approve ==========")
+	if len(args) < 5 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 5")
+	}
+
+	owner := args[0]
+	spender := args[1]
+	issuer := args[2]
+	code := args[3]
+	amount, err := strconv.ParseInt(args[4], 10, 64)
+	if owner == "" || spender == "" || issuer == "" || code == "" || err != nil || amount < 0 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "approve arguments error: account, spender, issuer and code can't be nil; amount must be a number and not less than 0.")
+	}
+	if owner == spender {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Account=%s can't approve itself.", owner)
+	}
+
+	_, account, isExist, err := assetcore.CheckCashAccount(stub, owner)
+	if err != nil {
+		return assetcore.Errorf("Check account=%s error:%s", owner, err)
+	} else if !isExist {
+		return assetcore.Error(assetcore.AccountNotFound(owner))
+	}
+	err = assetcore.CheckOwner(stub, account.Owner, account.ID)
+	if err != nil {
+		return assetcore.Fail(assetcore.CodeUnauthorized, "Permission denied:%s", err)
+	}
+	_, _, isExist, err = assetcore.CheckCashAccount(stub, spender)
+	if err != nil {
+		return assetcore.Errorf("Check account=%s error:%s", spender, err)
+	} else if !isExist {
+		return assetcore.Error(assetcore.AccountNotFound(spender))
+	}
+
+	a := assetcore.Allowance{
+		DocType: assetcore.AllowanceDocType,
+		Owner:   owner,
+		Spender: spender,
+		Issuer:  issuer,
+		Code:    code,
+		Amount:  amount,
+	}
+	err = assetcore.SaveAllowance(stub, a)
+	if err != nil {
+		return assetcore.Errorf("save allowance=%+v error:%s", a, err)
+	}
+
+	err = c.emit(stub, Event{Type: "Approve", From: owner, To: spender, Issuer: issuer, Code: code, Amount: amount})
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+
+	return shim.Success(nil)
+}
+
+// 查询授权额度
+// 参数：所有者帐户、代理帐户、issuer、code
+func (c *SimpleChaincode) allowance(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== allowance ==========")
+	if len(args) < 4 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 4")
+	}
+	if args[0] == "" || args[1] == "" || args[2] == "" || args[3] == "" {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "allowance arguments error: account, spender, issuer and code can't be nil.")
+	}
+
+	a, _, err := assetcore.CheckAllowance(stub, args[0], args[1], args[2], args[3])
+	if err != nil {
+		return assetcore.Errorf("Check allowance error:%s", err)
+	}
+
+	b, err := json.Marshal(a)
+	if err != nil {
+		return assetcore.Errorf("Marshal allowance error:%s", err)
+	}
+	return shim.Success(b)
+}
+
+// 代理帐户在授权额度内从所有者帐户转出资产
+// 参数：代理帐户、转出帐户、接收帐户、issuer、code、数量
+func (c *SimpleChaincode) transferFrom(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== transferFrom ==========")
+	if len(args) < 6 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 6")
+	}
+
+	spenderID := args[0]
+	from := args[1]
+	to := args[2]
+	issuer := args[3]
+	code := args[4]
+	count, err := strconv.ParseInt(args[5], 10, 64)
+	if spenderID == "" || from == "" || to == "" || issuer == "" || code == "" || err != nil || count <= 0 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "transfer from arguments error: spender, from, to, issuer and code can't be nil; amount must be a number and greater than 0.")
+	}
+
+	// 只有代理帐户的所有者才能使用授权额度
+	_, spender, isExist, err := assetcore.CheckCashAccount(stub, spenderID)
+	if err != nil {
+		return assetcore.Errorf("Check account=%s error:%s", spenderID, err)
+	} else if !isExist {
+		return assetcore.Error(assetcore.AccountNotFound(spenderID))
+	}
+	err = assetcore.CheckOwner(stub, spender.Owner, spender.ID)
+	if err != nil {
+		return assetcore.Fail(assetcore.CodeUnauthorized, "Permission denied:%s", err)
+	}
+
+	// 超过审批阈值的转移只能由所有者通过Transfer发起审批
+	_, required, err := assetcore.CheckApproval(stub, issuer, code, count)
+	if err != nil {
+		return assetcore.Errorf("Check approval policy of issuer=%s&code=%s error:%s", issuer, code, err)
+	} else if required {
+		return assetcore.Fail(assetcore.CodeFailedPrecondition, "Amount=%v of issuer=%s&code=%s requires approval, use Transfer.", count, issuer, code)
+	}
+
+	a, _, err := assetcore.CheckAllowance(stub, from, spenderID, issuer, code)
+	if err != nil {
+		return assetcore.Errorf("Check allowance error:%s", err)
+	}
+
+	r, err := c.moveAsset(stub, from, to, issuer, code, count, func(Account) error {
+		if a.Amount < count {
+			return fmt.Errorf("allowance of spender=%s on account=%s issuer=%s&code=%s is %v < transfer count=%v", spenderID, from, issuer, code, a.Amount, count)
+		}
+		return nil
+	})
+	if err != nil {
+		return assetcore.Error(err)
+	}
+
+	// 扣减授权额度
+	a.Amount -= count
+	err = assetcore.SaveAllowance(stub, a)
+	if err != nil {
+		return assetcore.Errorf("save allowance=%+v error:%s", a, err)
+	}
+
+	err = c.emit(stub, Event{
+		Type:        "TransferFrom",
+		From:        from,
+		To:          to,
+		Issuer:      issuer,
+		Code:        code,
+		Amount:      count,
+		FromBalance: r.FromBalance,
+		ToBalance:   r.ToBalance,
+		Spender:     spenderID,
+		Fee:         r.Fee,
+		Collector:   r.Collector,
+	})
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+
+	return c.transferResponse(r)
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/ChainNova/samples/chaincode/asset/assetcore"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// 设置资产的大额转移审批策略，只有发行机构可以操作
+// 参数：issuer、code、阈值、审批数、有效时长（秒）、签名人列表（JSON数组），审批数为0时取消审批
+func (c *SimpleChaincode) setApprovalPolicy(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== setApprovalPolicy ==========")
+	if len(args) < 4 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 4")
+	}
+
+	p := assetcore.ApprovalPolicy{
+		Issuer: args[0],
+		Code:   args[1],
+	}
+	threshold, err1 := strconv.ParseInt(args[2], 10, 64)
+	quorum, err2 := strconv.Atoi(args[3])
+	if p.Issuer == "" || p.Code == "" || err1 != nil || err2 != nil || quorum < 0 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "set approval policy arguments error: issuer and code can't be nil; threshold and quorum must be numbers, quorum can't be less than 0.")
+	}
+	p.Threshold = threshold
+	p.Quorum = quorum
+	if p.Quorum > 0 {
+		if len(args) < 6 {
+			return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 6")
+		}
+		duration, err1 := strconv.ParseInt(args[4], 10, 64)
+		err2 := json.Unmarshal([]byte(args[5]), &p.Signers)
+		if err1 != nil || err2 != nil {
+			return assetcore.Fail(assetcore.CodeInvalidArgument, "set approval policy arguments error: duration must be a number; signers must be a JSON array.")
+		}
+		p.Duration = duration
+		err := assetcore.CheckPolicySigners(p)
+		if err != nil {
+			return assetcore.Fail(assetcore.CodeInvalidArgument, "set approval policy arguments error: %s.", err)
+		}
+	}
+
+	err := assetcore.SetApprovalPolicy(stub, p)
+	if err != nil {
+		return assetcore.Error(err)
+	}
+
+	err = c.emit(stub, Event{Type: "SetApprovalPolicy", Issuer: p.Issuer, Code: p.Code, Amount: p.Threshold})
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+
+	return shim.Success(nil)
+}
+
+// 发起待审批的转移，转移在审批数达到要求后执行
+func (c *SimpleChaincode) proposeTransfer(stub shim.ChaincodeStubInterface, from, to, issuer, code string, count int64, p assetcore.ApprovalPolicy) pb.Response {
+	_, accountF, isExist, err := assetcore.CheckCashAccount(stub, from)
+	if err != nil {
+		return assetcore.Errorf("Check account=%s error:%s", from, err)
+	} else if !isExist {
+		return assetcore.Error(assetcore.AccountNotFound(from))
+	}
+	err = assetcore.CheckOwner(stub, accountF.Owner, accountF.ID)
+	if err != nil {
+		return assetcore.Fail(assetcore.CodeUnauthorized, "Permission denied:%s", err)
+	}
+	_, _, isExist, err = assetcore.CheckCashAccount(stub, to)
+	if err != nil {
+		return assetcore.Errorf("Check account=%s error:%s", to, err)
+	} else if !isExist {
+		return assetcore.Error(assetcore.AccountNotFound(to))
+	}
+	if from == to {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Account=%s can't transfer to itself.", from)
+	}
+	err = assetcore.CheckActive(stub, issuer, code, from, to)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+	// 接收帐户必须有资格持有该资产
+	err = assetcore.CheckEligible(stub, issuer, code, to)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+
+	t, err := assetcore.SavePendingTransfer(stub, p, from, to, issuer, code, count)
+	if err != nil {
+		return assetcore.Error(err)
+	}
+
+	err = c.emit(stub, Event{Type: "TransferPending", From: from, To: to, Issuer: issuer, Code: code, Amount: count, TransferID: t.ID})
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+
+	return c.pendingResponse(t)
+}
+
+// 签名人审批待审批的转移，审批数达到要求时执行转移
+// 参数：待审批转移编号
+func (c *SimpleChaincode) approveTransfer(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== approveTransfer ==========")
+	t, key, now, err := c.loadPendingTransfer(stub, args)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+	err = assetcore.ApprovePendingTransfer(stub, &t, now)
+	if err != nil {
+		return assetcore.Error(err)
+	}
+
+	ev := Event{Type: "ApproveTransfer", From: t.From, To: t.To, Issuer: t.Issuer, Code: t.Code, Amount: t.Amount, TransferID: t.ID, Approvals: len(t.Approvals)}
+	if len(t.Approvals) < t.Quorum {
+		err = assetcore.Save(stub, key, t)
+		if err != nil {
+			return assetcore.Errorf("save pending transfer=%+v error:%s", t, err)
+		}
+	} else {
+		// 审批数达到要求，执行转移，转出帐户所有者已在发起时校验
+		r, err := c.moveAsset(stub, t.From, t.To, t.Issuer, t.Code, t.Amount, func(Account) error { return nil })
+		if err != nil {
+			return assetcore.Error(err)
+		}
+		ev.FromBalance, ev.ToBalance, ev.Fee, ev.Collector = r.FromBalance, r.ToBalance, r.Fee, r.Collector
+		err = stub.DelState(key)
+		if err != nil {
+			return assetcore.Errorf("DelState error:%s", err)
+		}
+		ev.Executed = true
+	}
+
+	err = c.emit(stub, ev)
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+
+	return c.pendingResponse(t)
+}
+
+// 取消待审批的转移，转出帐户所有者或签名人可以取消
+// 参数：待审批转移编号
+func (c *SimpleChaincode) cancelTransfer(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== cancelTransfer ==========")
+	t, key, _, err := c.loadPendingTransfer(stub, args)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+
+	caller, err := assetcore.Caller(stub)
+	if err != nil {
+		return assetcore.Fail(assetcore.CodeUnauthorized, "Permission denied:get caller identity error:%s", err)
+	}
+	if !assetcore.ContainsIdentity(t.Signers, caller) {
+		_, accountF, _, err := assetcore.CheckCashAccount(stub, t.From)
+		if err == nil {
+			err = assetcore.CheckOwner(stub, accountF.Owner, accountF.ID)
+		}
+		if err != nil {
+			return assetcore.Fail(assetcore.CodeUnauthorized, "Permission denied:%s", err)
+		}
+	}
+
+	err = stub.DelState(key)
+	if err != nil {
+		return assetcore.Errorf("DelState error:%s", err)
+	}
+
+	err = c.emit(stub, Event{Type: "CancelTransfer", From: t.From, To: t.To, Issuer: t.Issuer, Code: t.Code, Amount: t.Amount, TransferID: t.ID})
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+
+	return shim.Success(nil)
+}
+
+// 查询待审批的转移
+// 参数：待审批转移编号
+func (c *SimpleChaincode) getPendingTransfer(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== getPendingTransfer ==========")
+	t, _, _, err := c.loadPendingTransfer(stub, args)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+	return c.pendingResponse(t)
+}
+
+// 获取待审批的转移，不存在时返回错误
+func (c *SimpleChaincode) loadPendingTransfer(stub shim.ChaincodeStubInterface, args []string) (t assetcore.PendingTransfer, key string, now int64, err error) {
+	if len(args) < 1 {
+		return t, key, now, assetcore.NewError(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 1")
+	}
+	return assetcore.LoadPendingTransfer(stub, args[0])
+}
+
+func (c *SimpleChaincode) pendingResponse(t assetcore.PendingTransfer) pb.Response {
+	b, err := json.Marshal(t)
+	if err != nil {
+		return assetcore.Errorf("Marshal pending transfer error:%s", err)
+	}
+	return shim.Success(b)
+}
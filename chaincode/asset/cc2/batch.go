@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ChainNova/samples/chaincode/asset/assetcore"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// TransferLeg 批量转移中的一笔转移
+type TransferLeg struct {
+	From   string `json:"from"`   //转出帐号
+	To     string `json:"to"`     //转移目的帐号
+	Issuer string `json:"issuer"` //资产发行机构
+	Code   string `json:"code"`   //资产代码
+	Amount int64  `json:"amount"` //转移数量
+}
+
+// TransferResult 一笔转移的结果
+type TransferResult struct {
+	Index       int    `json:"index"`               //在批量转移中的序号
+	From        string `json:"from"`                //转出帐号
+	To          string `json:"to"`                  //转移目的帐号
+	Issuer      string `json:"issuer"`              //资产发行机构
+	Code        string `json:"code"`                //资产代码
+	Amount      int64  `json:"amount"`              //转移数量
+	FromBalance int64  `json:"fromBalance"`         //转出帐户该资产转移后的数量
+	ToBalance   int64  `json:"toBalance"`           //转入帐户该资产转移后的数量
+	Fee         int64  `json:"fee,omitempty"`       //转出帐户另外支付的手续费
+	Collector   string `json:"collector,omitempty"` //收费帐户
+}
+
+// 批量转移资产，所有转移按顺序在同一交易中执行，任意一笔失败则全部不执行
+func (c *SimpleChaincode) batchTransfer(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== batchTransfer ==========")
+	if len(args) < 1 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 1")
+	}
+
+	var legs []TransferLeg
+	err := json.Unmarshal([]byte(args[0]), &legs)
+	if err != nil || len(legs) == 0 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "batch transfer arguments error: legs must be a non-empty JSON array.")
+	}
+
+	// 同一账户可能出现在多笔转移中，持有量统一在缓存中修改，最后一次性保存
+	cache := newStateCache(c, stub)
+	owned := map[string]bool{}
+
+	results := []TransferResult{}
+	for i, leg := range legs {
+		if leg.From == "" || leg.To == "" || leg.Issuer == "" || leg.Code == "" || leg.Amount <= 0 {
+			return assetcore.Fail(assetcore.CodeInvalidArgument, "leg %d arguments error: account, issuer and code can't be nil; amount must be a number and greater than 0.", i)
+		}
+		if leg.From == leg.To {
+			return assetcore.Fail(assetcore.CodeInvalidArgument, "leg %d error: Account=%s can't transfer to itself.", i, leg.From)
+		}
+
+		// 超过审批阈值的转移只能通过Transfer发起审批
+		_, required, err := assetcore.CheckApproval(stub, leg.Issuer, leg.Code, leg.Amount)
+		if err != nil {
+			return assetcore.Errorf("leg %d error: Check approval policy error:%s.", i, err)
+		} else if required {
+			return assetcore.Fail(assetcore.CodeFailedPrecondition, "leg %d error: amount=%v of issuer=%s&code=%s requires approval, use Transfer.", i, leg.Amount, leg.Issuer, leg.Code)
+		}
+
+		err = assetcore.CheckActive(stub, leg.Issuer, leg.Code, leg.From, leg.To)
+		if err != nil {
+			return assetcore.Errorf("leg %d error: %s.", i, err)
+		}
+		// 接收帐户必须有资格持有该资产
+		err = assetcore.CheckEligible(stub, leg.Issuer, leg.Code, leg.To)
+		if err != nil {
+			return assetcore.Errorf("leg %d error: %s.", i, err)
+		}
+
+		accountF, err := cache.getAccount(leg.From)
+		if err != nil {
+			return assetcore.Errorf("leg %d error: %s.", i, err)
+		}
+		if !owned[leg.From] {
+			err = assetcore.CheckOwner(stub, accountF.Owner, accountF.ID)
+			if err != nil {
+				return assetcore.Fail(assetcore.CodeUnauthorized, "leg %d error: Permission denied:%s", i, err)
+			}
+			owned[leg.From] = true
+		}
+		_, err = cache.getAccount(leg.To)
+		if err != nil {
+			return assetcore.Errorf("leg %d error: %s.", i, err)
+		}
+
+		// 计算手续费，由转出帐户另外支付
+		fee, collector, err := assetcore.TransferFee(stub, leg.From, leg.Issuer, leg.Code, leg.Amount)
+		if err != nil {
+			return assetcore.Errorf("leg %d error: %s.", i, err)
+		}
+
+		fromBalance, err := cache.addHolding(leg.From, leg.Issuer, leg.Code, -leg.Amount-fee)
+		if err != nil {
+			return assetcore.Errorf("leg %d error: %s.", i, err)
+		}
+		toBalance, err := cache.addHolding(leg.To, leg.Issuer, leg.Code, leg.Amount)
+		if err != nil {
+			return assetcore.Errorf("leg %d error: %s.", i, err)
+		}
+
+		// 手续费转入收费帐户
+		if fee > 0 {
+			_, err = cache.getAccount(collector)
+			if err != nil {
+				return assetcore.Errorf("leg %d error: fee collector %s.", i, err)
+			}
+			balance, err := cache.addHolding(collector, leg.Issuer, leg.Code, fee)
+			if err != nil {
+				return assetcore.Errorf("leg %d error: %s.", i, err)
+			}
+			if collector == leg.To {
+				toBalance = balance
+			}
+		}
+
+		results = append(results, TransferResult{
+			Index:       i,
+			From:        leg.From,
+			To:          leg.To,
+			Issuer:      leg.Issuer,
+			Code:        leg.Code,
+			Amount:      leg.Amount,
+			FromBalance: fromBalance,
+			ToBalance:   toBalance,
+			Fee:         fee,
+			Collector:   collector,
+		})
+	}
+
+	// 全部校验通过后保存持有量
+	err = cache.flush()
+	if err != nil {
+		return assetcore.Error(err)
+	}
+
+	err = c.emit(stub, Event{Type: "BatchTransfer", Legs: results})
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+
+	b, err := json.Marshal(results)
+	if err != nil {
+		return assetcore.Errorf("Marshal results error:%s", err)
+	}
+	return shim.Success(b)
+}
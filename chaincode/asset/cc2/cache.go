@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/ChainNova/samples/chaincode/asset/assetcore"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// stateCache 交易内的账户及持有量缓存
+// 同一交易中多次读写同一状态时，先在缓存中修改，全部校验通过后再统一保存
+type stateCache struct {
+	c    *SimpleChaincode
+	stub shim.ChaincodeStubInterface
+
+	accounts    map[string]*Account
+	accountIDs  []string
+	holdings    map[string]int64
+	holdingKeys []string
+	dirty       map[string]bool //修改过的账户ID及持有量key
+}
+
+func newStateCache(c *SimpleChaincode, stub shim.ChaincodeStubInterface) *stateCache {
+	return &stateCache{
+		c:        c,
+		stub:     stub,
+		accounts: map[string]*Account{},
+		dirty:    map[string]bool{},
+		holdings: map[string]int64{},
+	}
+}
+
+// 获取账户，账户不存在时返回错误
+func (m *stateCache) getAccount(id string) (*Account, error) {
+	if a, ok := m.accounts[id]; ok {
+		return a, nil
+	}
+	_, a, isExist, err := assetcore.CheckCashAccount(m.stub, id)
+	if err != nil {
+		return nil, fmt.Errorf("Check account=%s error:%s", id, err)
+	} else if !isExist {
+		return nil, assetcore.AccountNotFound(id)
+	}
+	m.accounts[id] = &a
+	m.accountIDs = append(m.accountIDs, id)
+	return &a, nil
+}
+
+// 修改账户现金余额，返回修改后的余额，余额不足时返回错误
+func (m *stateCache) addBalance(id string, delta int64) (int64, error) {
+	a, err := m.getAccount(id)
+	if err != nil {
+		return 0, err
+	}
+	if a.Balance+delta < 0 {
+		return a.Balance, assetcore.InsufficientFunds(id, "", "", a.Balance, -delta, "Account=%s balance=%v < amount=%v", id, a.Balance, -delta)
+	}
+	a.Balance = a.Balance + delta
+	m.dirty[id] = true
+	return a.Balance, nil
+}
+
+// 获取账户某类资产的持有量
+func (m *stateCache) getHolding(id, issuer, code string) (int64, string, error) {
+	key, err := m.stub.CreateCompositeKey(AccountAssetObjectType, []string{id, issuer, code})
+	if err != nil {
+		return 0, key, err
+	}
+	if sum, ok := m.holdings[key]; ok {
+		return sum, key, nil
+	}
+	sum, err := m.c.store.Holding(m.stub, id, issuer, code)
+	if err != nil {
+		return 0, key, fmt.Errorf("Check account=%s, asset issuer=%s&code=%s error:%s", id, issuer, code, err)
+	}
+	m.holdings[key] = sum
+	m.holdingKeys = append(m.holdingKeys, key)
+	return sum, key, nil
+}
+
+// 修改账户某类资产的持有量，返回修改后的持有量，持有量不足时返回错误
+func (m *stateCache) addHolding(id, issuer, code string, delta int64) (int64, error) {
+	sum, key, err := m.getHolding(id, issuer, code)
+	if err != nil {
+		return 0, err
+	}
+	if sum+delta < 0 {
+		return sum, assetcore.InsufficientFunds(id, issuer, code, sum, -delta, "Account=%s issuer=%s&code=%s&count=%v < transfer count=%v", id, issuer, code, sum, -delta)
+	}
+	// 归属计划锁定的数量不能转出
+	if delta < 0 {
+		err = assetcore.CheckUnlocked(m.stub, id, issuer, code, sum, -delta)
+		if err != nil {
+			return sum, err
+		}
+	}
+	m.holdings[key] = sum + delta
+	m.dirty[key] = true
+	return m.holdings[key], nil
+}
+
+// 保存所有修改过的账户及持有量
+func (m *stateCache) flush() error {
+	for _, id := range m.accountIDs {
+		if !m.dirty[id] {
+			continue
+		}
+		err := assetcore.Save(m.stub, id, m.accounts[id])
+		if err != nil {
+			return fmt.Errorf("save account=%+v error:%s", m.accounts[id], err)
+		}
+	}
+	for _, key := range m.holdingKeys {
+		if !m.dirty[key] {
+			continue
+		}
+		_, parts, err := m.stub.SplitCompositeKey(key)
+		if err != nil {
+			return err
+		}
+		err = m.c.store.SetHolding(m.stub, parts[0], parts[1], parts[2], m.holdings[key])
+		if err != nil {
+			return fmt.Errorf("PutState error:%s", err)
+		}
+	}
+	return nil
+}
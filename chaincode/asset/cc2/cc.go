@@ -5,82 +5,126 @@ import (
 	"fmt"
 	"strconv"
 
+	"github.com/ChainNova/samples/chaincode/asset/assetcore"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 	pb "github.com/hyperledger/fabric/protos/peer"
 )
 
 // SimpleChaincode ...
 type SimpleChaincode struct {
+	store  assetcore.Store   //持有量的存储模型
+	router *assetcore.Router //函数路由表，创建时构建一次
 }
 
-// Asset ...
-type Asset struct {
-	Issuer string `json:"issuer"` //资产发行机构
-	Code   string `json:"code"`   //资产代码
-	Amount int    `json:"amount"` //资产数量
+// 创建使用该存储模型的链码并构建函数路由表
+// 迁移开始后到完成前，除Migrate外修改状态的函数都被拒绝
+func newChaincode(store assetcore.Store) *SimpleChaincode {
+	c := &SimpleChaincode{store: store}
+	functions := c.functions()
+	for i := range functions {
+		if !functions[i].Query && functions[i].Name != "Migrate" {
+			functions[i].Handler = c.rejectDuringMigration(functions[i].Name, functions[i].Handler)
+		}
+	}
+	c.router = assetcore.NewRouter(functions)
+	return c
 }
 
-// Account ...
-type Account struct {
-	ID      string `json:"id"`      //帐户id
-	Balance int    `json:"balance"` //账户余额
-}
+// Asset 资产
+type Asset = assetcore.Asset
+
+// Account 资金帐户
+type Account = assetcore.CashAccount
+
+// Identity 调用者身份
+type Identity = assetcore.Identity
+
+// Role 由MSP ID及证书属性确定的角色
+type Role = assetcore.Role
+
+// Holding 帐户持有的某类资产，以AccountAsset~id~issuer~code为key
+type Holding = assetcore.Holding
 
 const (
-	AssetObjectType        = "Asset~issuer~code"
-	AccountAssetObjectType = "AccountAsset~id~issuer~code"
+	AccountAssetObjectType    = assetcore.HoldingObjectType
+	TradeObjectType           = "Trade~id"
+	OrderObjectType           = "Order~id"
+	OrderBookObjectType       = "OrderBook~issuer~code~side~price~time~id"
+	DistributionObjectType    = "Distribution~issuer~code~id"
+	DividendReceiptObjectType = "DividendReceipt~distribution~id"
+)
+
+// 文档类型，保存在每个文档的docType字段中，用于CouchDB富查询
+const (
+	HoldingDocType         = assetcore.HoldingDocType
+	TradeDocType           = "trade"
+	OrderDocType           = "order"
+	OrderIndexDocType      = "orderIndex"
+	DistributionDocType    = "distribution"
+	DividendReceiptDocType = "dividendReceipt"
 )
 
 // Init ...
 func (c *SimpleChaincode) Init(stub shim.ChaincodeStubInterface) pb.Response {
 	fmt.Println("########### Init chaincode ###########")
+	_, args := stub.GetFunctionAndParameters()
+
+	// init issuers
+	err := assetcore.InitIssuers(stub, args)
+	if err != nil {
+		return assetcore.Errorf("Init issuers error:%s", err)
+	}
+
+	// init admin role
+	err = assetcore.InitAdmin(stub, args)
+	if err != nil {
+		return assetcore.Errorf("Init admin error:%s", err)
+	}
+
+	// init compliance role
+	err = assetcore.InitCompliance(stub, args)
+	if err != nil {
+		return assetcore.Errorf("Init compliance error:%s", err)
+	}
 
 	// init asset A1
 	a1 := Asset{
-		Issuer: "AAA",
-		Code:   "A1",
-		Amount: 10000,
+		DocType: assetcore.AssetDocType,
+		Issuer:  "AAA",
+		Code:    "A1",
+		Amount:  10000,
+		Issued:  10000,
 	}
-	_, _, isExist, key, err := c.checkAsset(stub, a1.Issuer, a1.Code)
+	_, _, isExist, key, err := assetcore.CheckAsset(stub, a1.Issuer, a1.Code)
 	if err != nil {
-		e := fmt.Sprintf("Check asset=%+v error:%s", a1, err)
-		fmt.Println(e)
-		return shim.Error(e)
+		return assetcore.Errorf("Check asset=%+v error:%s", a1, err)
 	} else if isExist {
-		e := fmt.Sprintf("Asset=%+v already exists.", a1)
-		fmt.Println(e)
-		return shim.Error(e)
+		return assetcore.Fail(assetcore.CodeAlreadyExists, "Asset=%+v already exists.", a1)
 	}
 
-	err = c.save(stub, key, a1)
+	err = assetcore.Save(stub, key, a1)
 	if err != nil {
-		e := fmt.Sprintf("save asset=%+v error:%s", a1, err)
-		fmt.Println(e)
-		return shim.Error(e)
+		return assetcore.Errorf("save asset=%+v error:%s", a1, err)
 	}
 
 	// init asset B1
 	b1 := Asset{
-		Issuer: "BBB",
-		Code:   "B1",
-		Amount: 10000,
+		DocType: assetcore.AssetDocType,
+		Issuer:  "BBB",
+		Code:    "B1",
+		Amount:  10000,
+		Issued:  10000,
 	}
-	_, _, isExist, key, err = c.checkAsset(stub, b1.Issuer, b1.Code)
+	_, _, isExist, key, err = assetcore.CheckAsset(stub, b1.Issuer, b1.Code)
 	if err != nil {
-		e := fmt.Sprintf("Check asset=%+v error:%s", b1, err)
-		fmt.Println(e)
-		return shim.Error(e)
+		return assetcore.Errorf("Check asset=%+v error:%s", b1, err)
 	} else if isExist {
-		e := fmt.Sprintf("Asset=%+v already exists.", b1)
-		fmt.Println(e)
-		return shim.Error(e)
+		return assetcore.Fail(assetcore.CodeAlreadyExists, "Asset=%+v already exists.", b1)
 	}
 
-	err = c.save(stub, key, b1)
+	err = assetcore.Save(stub, key, b1)
 	if err != nil {
-		e := fmt.Sprintf("save asset=%+v error:%s", b1, err)
-		fmt.Println(e)
-		return shim.Error(e)
+		return assetcore.Errorf("save asset=%+v error:%s", b1, err)
 	}
 
 	return shim.Success(nil)
@@ -91,60 +135,53 @@ func (c *SimpleChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
 	fmt.Println("########### Invoke chaincode ###########")
 	function, args := stub.GetFunctionAndParameters()
 
-	if function == "CreateAccount" {
-		return c.createAccount(stub, args)
-	} else if function == "CreateAsset" {
-		return c.createAsset(stub, args)
-	} else if function == "Buy" {
-		return c.buy(stub, args)
-	} else if function == "Transfer" {
-		return c.transfer(stub, args)
-	} else if function == "AccountInfo" {
-		return c.accountInfo(stub, args)
-	} else if function == "AssetInfo" {
-		return c.assetInfo(stub, args)
-	} else if function == "MyAssets" {
-		return c.myAssets(stub, args)
-	} else if function == "IssuerAssets" {
-		return c.issuerAssets(stub, args)
-	}
-
-	return shim.Error("Invalid invoke function name.")
+	return c.router.Invoke(stub, function, args)
 }
 
 func (c *SimpleChaincode) createAccount(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 	fmt.Println("=========== create account ==========")
 	if len(args) < 2 {
-		return shim.Error("Incorrect number of arguments. Expecting atleast 2")
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 2")
 	}
 
 	id := args[0]
-	balance, err := strconv.Atoi(args[1])
+	balance, err := strconv.ParseInt(args[1], 10, 64)
 	if id == "" || err != nil || balance <= 0 {
-		fmt.Println("create account arguments error: id can't be nil; balance must be a number and greater than 0.")
-		return shim.Error("create account arguments error: id can't be nil; balance must be a number and greater than 0.")
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "create account arguments error: id can't be nil; balance must be a number and greater than 0.")
 	}
 
-	_, _, isExist, err := c.checkAccout(stub, id)
+	_, _, isExist, err := assetcore.CheckCashAccount(stub, id)
 	if err != nil {
-		e := fmt.Sprintf("Check account=%s error:%s", id, err)
-		fmt.Println(e)
-		return shim.Error(e)
+		return assetcore.Errorf("Check account=%s error:%s", id, err)
 	} else if isExist {
-		e := fmt.Sprintf("Account=%s already exists.", id)
-		fmt.Println(e)
-		return shim.Error(e)
+		return assetcore.Error(assetcore.AccountExists(id))
+	}
+
+	// 设置开户资格时，帐户必须已登记KYC
+	err = assetcore.CheckEligible(stub, "", "", id)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+
+	owner, err := assetcore.Caller(stub)
+	if err != nil {
+		return assetcore.Errorf("Get caller identity error:%s", err)
 	}
 
 	a := Account{
+		DocType: assetcore.AccountDocType,
 		ID:      id,
 		Balance: balance,
+		Owner:   owner,
 	}
-	err = c.save(stub, a.ID, a)
+	err = assetcore.Save(stub, a.ID, a)
 	if err != nil {
-		e := fmt.Sprintf("save account=%+v error:%s", a, err)
-		fmt.Println(e)
-		return shim.Error(e)
+		return assetcore.Errorf("save account=%+v error:%s", a, err)
+	}
+
+	err = c.emit(stub, Event{Type: "CreateAccount", To: a.ID, Amount: a.Balance, Balance: a.Balance})
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
 	}
 
 	return shim.Success(nil)
@@ -153,39 +190,44 @@ func (c *SimpleChaincode) createAccount(stub shim.ChaincodeStubInterface, args [
 func (c *SimpleChaincode) createAsset(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 	fmt.Println("=========== create asset ==========")
 	if len(args) < 3 {
-		return shim.Error("Incorrect number of arguments. Expecting atleast 3")
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 3")
 	}
 
 	issuer := args[0]
 	code := args[1]
-	amount, err := strconv.Atoi(args[2])
+	amount, err := strconv.ParseInt(args[2], 10, 64)
 	if issuer == "" || code == "" || err != nil || amount <= 0 {
-		fmt.Println("create asset arguments error: issuer and code can't be nil; amount must be a number and greater than 0.")
-		return shim.Error("create asset arguments error: issuer and code can't be nil; amount must be a number and greater than 0.")
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "create asset arguments error: issuer and code can't be nil; amount must be a number and greater than 0.")
+	}
+
+	err = assetcore.CheckIssuer(stub, issuer)
+	if err != nil {
+		return assetcore.Fail(assetcore.CodeUnauthorized, "Permission denied:%s", err)
 	}
 
 	a := Asset{
-		Issuer: issuer,
-		Code:   code,
-		Amount: amount,
+		DocType: assetcore.AssetDocType,
+		Issuer:  issuer,
+		Code:    code,
+		Amount:  amount,
+		Issued:  amount,
 	}
 
-	_, _, isExist, key, err := c.checkAsset(stub, a.Issuer, a.Code)
+	_, _, isExist, key, err := assetcore.CheckAsset(stub, a.Issuer, a.Code)
 	if err != nil {
-		e := fmt.Sprintf("Check asset=%+v error:%s", a, err)
-		fmt.Println(e)
-		return shim.Error(e)
+		return assetcore.Errorf("Check asset=%+v error:%s", a, err)
 	} else if isExist {
-		e := fmt.Sprintf("Asset=%+v already exists.", a)
-		fmt.Println(e)
-		return shim.Error(e)
+		return assetcore.Fail(assetcore.CodeAlreadyExists, "Asset=%+v already exists.", a)
+	}
+
+	err = assetcore.Save(stub, key, a)
+	if err != nil {
+		return assetcore.Errorf("save asset=%+v error:%s", a, err)
 	}
 
-	err = c.save(stub, key, a)
+	err = c.emit(stub, Event{Type: "CreateAsset", Issuer: a.Issuer, Code: a.Code, Amount: a.Amount, Supply: a.Amount})
 	if err != nil {
-		e := fmt.Sprintf("save asset=%+v error:%s", a, err)
-		fmt.Println(e)
-		return shim.Error(e)
+		return assetcore.Errorf("SetEvent error:%s", err)
 	}
 
 	return shim.Success(nil)
@@ -194,80 +236,88 @@ func (c *SimpleChaincode) createAsset(stub shim.ChaincodeStubInterface, args []s
 func (c *SimpleChaincode) buy(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 	fmt.Println("=========== buy ==========")
 	if len(args) < 4 {
-		return shim.Error("Incorrect number of arguments. Expecting atleast 4")
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 4")
 	}
 
 	id := args[0]
 	issuer := args[1]
 	code := args[2]
-	count, err := strconv.Atoi(args[3])
+	count, err := strconv.ParseInt(args[3], 10, 64)
 	if id == "" || issuer == "" || code == "" || err != nil || count <= 0 {
-		fmt.Println("buy asset arguments error: account, issuer and code can't be nil; count must be a number and greater than 0.")
-		return shim.Error("buy asset arguments error: account, issuer and code can't be nil; count must be a number and greater than 0.")
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "buy asset arguments error: account, issuer and code can't be nil; count must be a number and greater than 0.")
 	}
 
-	_, account, isExist, err := c.checkAccout(stub, id)
+	_, account, isExist, err := assetcore.CheckCashAccount(stub, id)
 	if err != nil {
-		e := fmt.Sprintf("Check account=%s error:%s", id, err)
-		fmt.Println(e)
-		return shim.Error(e)
+		return assetcore.Errorf("Check account=%s error:%s", id, err)
 	} else if !isExist {
-		e := fmt.Sprintf("Account=%s not exists.", id)
-		fmt.Println(e)
-		return shim.Error(e)
+		return assetcore.Error(assetcore.AccountNotFound(id))
+	}
+
+	err = assetcore.CheckOwner(stub, account.Owner, account.ID)
+	if err != nil {
+		return assetcore.Fail(assetcore.CodeUnauthorized, "Permission denied:%s", err)
 	}
 
-	_, asset, isExist, key, err := c.checkAsset(stub, issuer, code)
+	err = assetcore.CheckActive(stub, issuer, code, id)
 	if err != nil {
-		e := fmt.Sprintf("Check asset issuer=%s&code=%s error:%s", issuer, code, err)
-		fmt.Println(e)
-		return shim.Error(e)
+		return assetcore.Errorf("%s.", err)
+	}
+	// 购买帐户必须有资格持有该资产
+	err = assetcore.CheckEligible(stub, issuer, code, id)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+
+	_, asset, isExist, key, err := assetcore.CheckAsset(stub, issuer, code)
+	if err != nil {
+		return assetcore.Errorf("Check asset issuer=%s&code=%s error:%s", issuer, code, err)
 	} else if !isExist {
-		e := fmt.Sprintf("Asset issuer=%s&code=%s not exists.", issuer, code)
-		fmt.Println(e)
-		return shim.Error(e)
+		return assetcore.Error(assetcore.AssetNotFound(issuer, code))
 	}
 
 	if account.Balance < count {
-		e := fmt.Sprintf("Account balance=%v < buy count=%v.", account.Balance, count)
-		fmt.Println(e)
-		return shim.Error(e)
+		return assetcore.Error(assetcore.InsufficientFunds(id, "", "", account.Balance, count, "Account balance=%v < buy count=%v.", account.Balance, count))
 	}
 	if asset.Amount < count {
-		e := fmt.Sprintf("Asset amount=%v < buy count=%v.", asset.Amount, count)
-		fmt.Println(e)
-		return shim.Error(e)
+		return assetcore.Fail(assetcore.CodeInsufficientFunds, "Asset amount=%v < buy count=%v.", asset.Amount, count)
 	}
 
 	account.Balance = account.Balance - count
 	asset.Amount = asset.Amount - count
 
-	err = c.save(stub, account.ID, account)
+	err = assetcore.Save(stub, account.ID, account)
 	if err != nil {
-		e := fmt.Sprintf("save account=%+v error:%s", account, err)
-		fmt.Println(e)
-		return shim.Error(e)
+		return assetcore.Errorf("save account=%+v error:%s", account, err)
 	}
 
-	err = c.save(stub, key, asset)
+	err = assetcore.Save(stub, key, asset)
 	if err != nil {
-		e := fmt.Sprintf("save asset=%+v error:%s", asset, err)
-		fmt.Println(e)
-		return shim.Error(e)
+		return assetcore.Errorf("save asset=%+v error:%s", asset, err)
 	}
 
-	sum, key, err := c.checkAccoutAsset(stub, account.ID, asset.Issuer, asset.Code)
+	sum, err := c.store.Holding(stub, account.ID, asset.Issuer, asset.Code)
 	if err != nil {
-		e := fmt.Sprintf("Check account=%s, asset issuer=%s&code=%s error:%s", account.ID, asset.Issuer, asset.Code, err)
-		fmt.Println(e)
-		return shim.Error(e)
+		return assetcore.Errorf("Check account=%s, asset issuer=%s&code=%s error:%s", account.ID, asset.Issuer, asset.Code, err)
 	}
 
-	err = stub.PutState(key, []byte(strconv.Itoa(sum+count)))
+	err = c.store.SetHolding(stub, account.ID, asset.Issuer, asset.Code, sum+count)
 	if err != nil {
-		e := fmt.Sprintf("PutState error:%s", err)
-		fmt.Println(e)
-		return shim.Error(e)
+		return assetcore.Errorf("PutState error:%s", err)
+	}
+
+	err = c.emit(stub, Event{
+		Type:      "Buy",
+		To:        account.ID,
+		Issuer:    asset.Issuer,
+		Code:      asset.Code,
+		Amount:    count,
+		ToBalance: sum + count,
+		Balance:   account.Balance,
+		Supply:    asset.Amount,
+	})
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
 	}
 
 	return shim.Success(nil)
@@ -276,93 +326,165 @@ func (c *SimpleChaincode) buy(stub shim.ChaincodeStubInterface, args []string) p
 func (c *SimpleChaincode) transfer(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 	fmt.Println("=========== transfer ==========")
 	if len(args) < 5 {
-		return shim.Error("Incorrect number of arguments. Expecting atleast 5")
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 5")
 	}
 
 	from := args[0]
 	to := args[1]
 	issuer := args[2]
 	code := args[3]
-	count, err := strconv.Atoi(args[4])
+	count, err := strconv.ParseInt(args[4], 10, 64)
 	if from == "" || to == "" || issuer == "" || code == "" || err != nil || count <= 0 {
-		fmt.Println("transfer asset arguments error: account, issuer and code can't be nil; amount must be a number and greater than 0.")
-		return shim.Error("transfer asset arguments error: account, issuer and code can't be nil; amount must be a number and greater than 0.")
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "transfer asset arguments error: account, issuer and code can't be nil; amount must be a number and greater than 0.")
+	}
+
+	// 超过审批阈值的转移需要签名人审批后执行
+	policy, required, err := assetcore.CheckApproval(stub, issuer, code, count)
+	if err != nil {
+		return assetcore.Errorf("Check approval policy of issuer=%s&code=%s error:%s", issuer, code, err)
+	} else if required {
+		return c.proposeTransfer(stub, from, to, issuer, code, count, policy)
+	}
+
+	r, err := c.moveAsset(stub, from, to, issuer, code, count, func(a Account) error {
+		// 只有账户所有者才能转出资产
+		return assetcore.CheckOwner(stub, a.Owner, a.ID)
+	})
+	if err != nil {
+		return assetcore.Error(err)
 	}
 
-	_, accountF, isExist, err := c.checkAccout(stub, from)
+	err = c.emit(stub, Event{
+		Type:        "Transfer",
+		From:        from,
+		To:          to,
+		Issuer:      issuer,
+		Code:        code,
+		Amount:      count,
+		FromBalance: r.FromBalance,
+		ToBalance:   r.ToBalance,
+		Fee:         r.Fee,
+		Collector:   r.Collector,
+	})
 	if err != nil {
-		e := fmt.Sprintf("Check account=%s error:%s", from, err)
-		fmt.Println(e)
-		return shim.Error(e)
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+
+	return c.transferResponse(r)
+}
+
+// 在两个帐户间转移资产，返回转移结果，包括转移后两个帐户该资产的数量及手续费
+// authorize校验交易提交者是否有权从转出帐户转出资产
+func (c *SimpleChaincode) moveAsset(stub shim.ChaincodeStubInterface, from, to, issuer, code string, count int64, authorize func(Account) error) (r TransferResult, err error) {
+	r = TransferResult{From: from, To: to, Issuer: issuer, Code: code, Amount: count}
+
+	_, accountF, isExist, err := assetcore.CheckCashAccount(stub, from)
+	if err != nil {
+		return r, fmt.Errorf("Check account=%s error:%s", from, err)
 	} else if !isExist {
-		e := fmt.Sprintf("Account=%s not exists.", from)
-		fmt.Println(e)
-		return shim.Error(e)
+		return r, assetcore.AccountNotFound(from)
+	}
+
+	err = authorize(accountF)
+	if err != nil {
+		return r, assetcore.NewError(assetcore.CodeUnauthorized, "Permission denied:%s", err)
 	}
 
-	_, accountT, isExist, err := c.checkAccout(stub, to)
+	err = assetcore.CheckActive(stub, issuer, code, from, to)
+	if err != nil {
+		return r, assetcore.Wrapf("%s.", err)
+	}
+	// 接收帐户必须有资格持有该资产
+	err = assetcore.CheckEligible(stub, issuer, code, to)
 	if err != nil {
-		e := fmt.Sprintf("Check account=%s error:%s", to, err)
-		fmt.Println(e)
-		return shim.Error(e)
+		return r, assetcore.Wrapf("%s.", err)
+	}
+
+	if from == to {
+		return r, assetcore.NewError(assetcore.CodeInvalidArgument, "Account=%s can't transfer to itself.", from)
+	}
+
+	_, accountT, isExist, err := assetcore.CheckCashAccount(stub, to)
+	if err != nil {
+		return r, fmt.Errorf("Check account=%s error:%s", to, err)
 	} else if !isExist {
-		e := fmt.Sprintf("Account=%s not exists.", to)
-		fmt.Println(e)
-		return shim.Error(e)
+		return r, assetcore.AccountNotFound(to)
 	}
 
-	sumF, keyF, err := c.checkAccoutAsset(stub, accountF.ID, issuer, code)
+	// 计算手续费，由转出帐户另外支付
+	r.Fee, r.Collector, err = assetcore.TransferFee(stub, from, issuer, code, count)
 	if err != nil {
-		e := fmt.Sprintf("Check account=%s, asset issuer=%s&code=%s error:%s", accountF.ID, issuer, code, err)
-		fmt.Println(e)
-		return shim.Error(e)
+		return r, assetcore.Wrapf("%s.", err)
 	}
 
-	sumT, keyT, err := c.checkAccoutAsset(stub, accountT.ID, issuer, code)
+	sumF, err := c.store.Holding(stub, accountF.ID, issuer, code)
 	if err != nil {
-		e := fmt.Sprintf("Check account=%s, asset issuer=%s&code=%s error:%s", accountT.ID, issuer, code, err)
-		fmt.Println(e)
-		return shim.Error(e)
+		return r, fmt.Errorf("Check account=%s, asset issuer=%s&code=%s error:%s", accountF.ID, issuer, code, err)
 	}
 
-	if sumF < count {
-		e := fmt.Sprintf("Account=%s issuer=%s&code=%s&count=%v < buy count=%v.", accountT.ID, issuer, code, sumF, count)
-		fmt.Println(e)
-		return shim.Error(e)
+	sumT, err := c.store.Holding(stub, accountT.ID, issuer, code)
+	if err != nil {
+		return r, fmt.Errorf("Check account=%s, asset issuer=%s&code=%s error:%s", accountT.ID, issuer, code, err)
 	}
 
-	err = stub.PutState(keyF, []byte(strconv.Itoa(sumF-count)))
+	if sumF < count+r.Fee {
+		return r, assetcore.InsufficientFunds(accountF.ID, issuer, code, sumF, count+r.Fee, "Account=%s issuer=%s&code=%s&count=%v < transfer count=%v plus fee=%v.", accountF.ID, issuer, code, sumF, count, r.Fee)
+	}
+	// 归属计划锁定的数量不能转出
+	err = assetcore.CheckUnlocked(stub, accountF.ID, issuer, code, sumF, count+r.Fee)
 	if err != nil {
-		e := fmt.Sprintf("PutState error:%s", err)
-		fmt.Println(e)
-		return shim.Error(e)
+		return r, assetcore.Wrapf("%s.", err)
+	}
+
+	r.FromBalance = sumF - count - r.Fee
+	r.ToBalance = sumT + count
+
+	// 手续费转入收费帐户，收费帐户为接收账户时直接累加
+	if r.Fee > 0 {
+		if r.Collector == to {
+			r.ToBalance += r.Fee
+		} else {
+			_, _, isExist, err := assetcore.CheckCashAccount(stub, r.Collector)
+			if err != nil {
+				return r, fmt.Errorf("Check account=%s error:%s", r.Collector, err)
+			} else if !isExist {
+				return r, assetcore.AccountNotFound(r.Collector)
+			}
+			sumC, err := c.store.Holding(stub, r.Collector, issuer, code)
+			if err != nil {
+				return r, fmt.Errorf("Check account=%s, asset issuer=%s&code=%s error:%s", r.Collector, issuer, code, err)
+			}
+			err = c.store.SetHolding(stub, r.Collector, issuer, code, sumC+r.Fee)
+			if err != nil {
+				return r, fmt.Errorf("PutState error:%s", err)
+			}
+		}
 	}
-	err = stub.PutState(keyT, []byte(strconv.Itoa(sumT+count)))
+
+	err = c.store.SetHolding(stub, accountF.ID, issuer, code, r.FromBalance)
+	if err != nil {
+		return r, fmt.Errorf("PutState error:%s", err)
+	}
+	err = c.store.SetHolding(stub, accountT.ID, issuer, code, r.ToBalance)
 	if err != nil {
-		e := fmt.Sprintf("PutState error:%s", err)
-		fmt.Println(e)
-		return shim.Error(e)
+		return r, fmt.Errorf("PutState error:%s", err)
 	}
 
-	return shim.Success(nil)
+	return r, nil
 }
 
 func (c *SimpleChaincode) accountInfo(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 	fmt.Println("=========== accountInfo ==========")
 	if len(args) < 1 {
-		return shim.Error("Incorrect number of arguments. Expecting atleast 1")
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 1")
 	}
 
 	id := args[0]
-	b, _, isExist, err := c.checkAccout(stub, id)
+	b, _, isExist, err := assetcore.CheckCashAccount(stub, id)
 	if err != nil {
-		e := fmt.Sprintf("Check account=%s error:%s", id, err)
-		fmt.Println(e)
-		return shim.Error(e)
+		return assetcore.Errorf("Check account=%s error:%s", id, err)
 	} else if !isExist {
-		e := fmt.Sprintf("Account=%s not exists.", id)
-		fmt.Println(e)
-		return shim.Error(e)
+		return assetcore.Error(assetcore.AccountNotFound(id))
 	}
 
 	return shim.Success(b)
@@ -371,20 +493,16 @@ func (c *SimpleChaincode) accountInfo(stub shim.ChaincodeStubInterface, args []s
 func (c *SimpleChaincode) assetInfo(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 	fmt.Println("=========== assetInfo ==========")
 	if len(args) < 2 {
-		return shim.Error("Incorrect number of arguments. Expecting atleast 2")
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 2")
 	}
 
 	issuer := args[0]
 	code := args[1]
-	b, _, isExist, _, err := c.checkAsset(stub, issuer, code)
+	b, _, isExist, _, err := assetcore.CheckAsset(stub, issuer, code)
 	if err != nil {
-		e := fmt.Sprintf("Check asset issuer=%s&code=%s error:%s", issuer, code, err)
-		fmt.Println(e)
-		return shim.Error(e)
+		return assetcore.Errorf("Check asset issuer=%s&code=%s error:%s", issuer, code, err)
 	} else if !isExist {
-		e := fmt.Sprintf("Asset issuer=%s&code=%s not exists.", issuer, code)
-		fmt.Println(e)
-		return shim.Error(e)
+		return assetcore.Error(assetcore.AssetNotFound(issuer, code))
 	}
 	return shim.Success(b)
 }
@@ -392,48 +510,26 @@ func (c *SimpleChaincode) assetInfo(stub shim.ChaincodeStubInterface, args []str
 func (c *SimpleChaincode) myAssets(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 	fmt.Println("=========== myAsset ==========")
 	if len(args) < 1 {
-		return shim.Error("Incorrect number of arguments. Expecting atleast 1")
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 1")
 	}
 
 	id := args[0]
-	assetsIterator, err := stub.GetStateByPartialCompositeKey(AccountAssetObjectType, []string{id})
+	holdings, err := c.store.Holdings(stub, id)
 	if err != nil {
-
+		return assetcore.Errorf("Get assets of account=%s error:%s", id, err)
 	}
-	defer assetsIterator.Close()
 
 	myAssets := struct {
 		ID     string  `json:"id"`
 		Assets []Asset `json:"assets"`
 	}{ID: id}
-
-	for assetsIterator.HasNext() {
-		kv, _ := assetsIterator.Next()
-		count, err := strconv.Atoi(string(kv.Value))
-		if err != nil {
-			fmt.Println("strconv.Atoi error:", err, string(kv.Value))
-			continue
-		}
-
-		_, compositeKeyParts, err := stub.SplitCompositeKey(kv.Key)
-		if err != nil {
-			fmt.Println("SplitCompositeKey error:", err)
-			continue
-		}
-
-		asset := Asset{
-			Issuer: compositeKeyParts[1],
-			Code:   compositeKeyParts[2],
-			Amount: count,
-		}
-		myAssets.Assets = append(myAssets.Assets, asset)
+	for _, h := range holdings {
+		myAssets.Assets = append(myAssets.Assets, Asset{Issuer: h.Issuer, Code: h.Code, Amount: h.Amount})
 	}
 
 	b, err := json.Marshal(myAssets)
 	if err != nil {
-		e := fmt.Sprintf("Marshal myAssets error:%s", err)
-		fmt.Println(e)
-		return shim.Error(e)
+		return assetcore.Errorf("Marshal myAssets error:%s", err)
 	}
 	return shim.Success(b)
 }
@@ -441,11 +537,11 @@ func (c *SimpleChaincode) myAssets(stub shim.ChaincodeStubInterface, args []stri
 func (c *SimpleChaincode) issuerAssets(stub shim.ChaincodeStubInterface, args []string) pb.Response {
 	fmt.Println("=========== issuerAsset ==========")
 	if len(args) < 1 {
-		return shim.Error("Incorrect number of arguments. Expecting atleast 1")
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 1")
 	}
 
 	issuer := args[0]
-	assetsIterator, err := stub.GetStateByPartialCompositeKey(AssetObjectType, []string{issuer})
+	assetsIterator, err := stub.GetStateByPartialCompositeKey(assetcore.AssetObjectType, []string{issuer})
 	if err != nil {
 		fmt.Println("GetStateByPartialCompositeKey err:", err)
 	}
@@ -470,67 +566,14 @@ func (c *SimpleChaincode) issuerAssets(stub shim.ChaincodeStubInterface, args []
 
 	b, err := json.Marshal(issuerAssets)
 	if err != nil {
-		e := fmt.Sprintf("Marshal myAssets error:%s", err)
-		fmt.Println(e)
-		return shim.Error(e)
+		return assetcore.Errorf("Marshal myAssets error:%s", err)
 	}
 	return shim.Success(b)
 }
 
-func (c *SimpleChaincode) checkAccout(stub shim.ChaincodeStubInterface, id string) (b []byte, a Account, isExist bool, err error) {
-
-	b, err = stub.GetState(id)
-	if err != nil {
-		return b, a, a.ID != "", err
-	}
-	if b != nil && len(b) > 0 {
-		err = json.Unmarshal(b, &a)
-	}
-
-	return b, a, a.ID != "", err
-}
-
-func (c *SimpleChaincode) checkAsset(stub shim.ChaincodeStubInterface, issuer, code string) (b []byte, a Asset, isExist bool, key string, err error) {
-	key, err = stub.CreateCompositeKey(AssetObjectType, []string{issuer, code})
-	if err != nil {
-		return b, a, a.Code != "", key, err
-	}
-	b, err = stub.GetState(key)
-	if err != nil {
-		return b, a, a.Code != "", key, err
-	}
-	if b != nil && len(b) > 0 {
-		err = json.Unmarshal(b, &a)
-	}
-	return b, a, a.Code != "", key, err
-}
-
-func (c *SimpleChaincode) checkAccoutAsset(stub shim.ChaincodeStubInterface, id, issuer, code string) (count int, key string, err error) {
-	key, err = stub.CreateCompositeKey(AccountAssetObjectType, []string{id, issuer, code})
-	b, err := stub.GetState(key)
-	if err != nil {
-		return count, key, err
-	}
-	if b != nil && len(b) > 0 {
-		count, _ = strconv.Atoi(string(b))
-	}
-	return count, key, err
-}
-
-func (c *SimpleChaincode) save(stub shim.ChaincodeStubInterface, k string, v interface{}) error {
-	val, err := json.Marshal(v)
-	if err != nil {
-		return err
-	}
-	err = stub.PutState(k, val)
-	if err != nil {
-		return err
-	}
-	return nil
-}
-
 func main() {
-	err := shim.Start(new(SimpleChaincode))
+	// cc2的持有量以复合键保存
+	err := shim.Start(newChaincode(assetcore.HoldingStore{}))
 	if err != nil {
 		fmt.Printf("Error starting Simple chaincode: %s", err)
 	}
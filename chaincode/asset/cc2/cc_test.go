@@ -0,0 +1,1033 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ChainNova/samples/chaincode/asset/assetcore"
+	"github.com/ChainNova/samples/chaincode/asset/memstub"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+var (
+	admin     = memstub.MustIdentity("AdminMSP", "admin", nil)
+	xiaozhang = memstub.MustIdentity("Org1MSP", "xiaozhang", nil)
+	xiaowang  = memstub.MustIdentity("Org1MSP", "xiaowang", nil)
+	exchange  = memstub.MustIdentity("Org1MSP", "exchange", nil)
+)
+
+// 初始化链码：AAA、BBB两个发行机构及管理员角色，Init时创建A1、B1各10000股
+func newStub(t *testing.T) *memstub.Stub {
+	stub := memstub.New("cc2", newChaincode(assetcore.HoldingStore{}))
+	resp := stub.As(admin).Init("init",
+		`[{"name":"AAA","mspId":"AAAMSP"},{"name":"BBB","mspId":"BBBMSP"}]`,
+		`{"mspId":"AdminMSP"}`)
+	if resp.Status != shim.OK {
+		t.Fatalf("Init: %s", resp.Message)
+	}
+	return stub
+}
+
+type step struct {
+	name   string
+	caller []byte
+	args   []string
+	ok     bool
+}
+
+func run(t *testing.T, stub *memstub.Stub, steps []step) {
+	for _, s := range steps {
+		resp := stub.As(s.caller).Invoke(s.args...)
+		if (resp.Status == shim.OK) != s.ok {
+			t.Errorf("%s: status=%d message=%q, want ok=%v", s.name, resp.Status, resp.Message, s.ok)
+		}
+	}
+}
+
+// 通过MyAssets查询帐户各资产数量
+func holdings(t *testing.T, stub *memstub.Stub, id string) map[string]int64 {
+	resp := stub.As(xiaozhang).Invoke("MyAssets", id)
+	if resp.Status != shim.OK {
+		t.Fatalf("MyAssets %s: %s", id, resp.Message)
+	}
+	var r struct {
+		Assets []Asset `json:"assets"`
+	}
+	if err := json.Unmarshal(resp.Payload, &r); err != nil {
+		t.Fatalf("MyAssets %s: %s", id, err)
+	}
+	m := map[string]int64{}
+	for _, v := range r.Assets {
+		m[v.Issuer+"/"+v.Code] = v.Amount
+	}
+	return m
+}
+
+func checkHoldings(t *testing.T, stub *memstub.Stub, id string, want map[string]int64) {
+	got := holdings(t, stub, id)
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("account=%s %s: got %d, want %d", id, k, got[k], v)
+		}
+	}
+	for k, v := range got {
+		if _, ok := want[k]; !ok && v != 0 {
+			t.Errorf("account=%s %s: got %d, want 0", id, k, v)
+		}
+	}
+}
+
+func checkBalance(t *testing.T, stub *memstub.Stub, id string, want int64) {
+	resp := stub.Invoke("AccountInfo", id)
+	if resp.Status != shim.OK {
+		t.Fatalf("AccountInfo %s: %s", id, resp.Message)
+	}
+	var a Account
+	if err := json.Unmarshal(resp.Payload, &a); err != nil {
+		t.Fatalf("AccountInfo %s: %s", id, err)
+	}
+	if a.Balance != want {
+		t.Errorf("account=%s balance: got %d, want %d", id, a.Balance, want)
+	}
+}
+
+func checkAssetAmount(t *testing.T, stub *memstub.Stub, issuer, code string, want int64) {
+	resp := stub.Invoke("AssetInfo", issuer, code)
+	if resp.Status != shim.OK {
+		t.Fatalf("AssetInfo %s/%s: %s", issuer, code, resp.Message)
+	}
+	var a Asset
+	if err := json.Unmarshal(resp.Payload, &a); err != nil {
+		t.Fatalf("AssetInfo %s/%s: %s", issuer, code, err)
+	}
+	if a.Amount != want {
+		t.Errorf("asset=%s/%s amount: got %d, want %d", issuer, code, a.Amount, want)
+	}
+}
+
+// 通过AuditSupply查询各资产的对账结果，有不一致的资产时报错
+func audit(t *testing.T, stub *memstub.Stub) map[string]SupplyAudit {
+	resp := stub.Invoke("AuditSupply")
+	var r struct {
+		Assets        []SupplyAudit `json:"assets"`
+		Discrepancies []SupplyAudit `json:"discrepancies"`
+	}
+	if resp.Status != shim.OK || json.Unmarshal(resp.Payload, &r) != nil {
+		t.Fatalf("AuditSupply: status=%d message=%q", resp.Status, resp.Message)
+	}
+	if len(r.Discrepancies) != 0 {
+		t.Errorf("AuditSupply discrepancies: got %+v", r.Discrepancies)
+	}
+	m := map[string]SupplyAudit{}
+	for _, a := range r.Assets {
+		m[a.Issuer+"/"+a.Code] = a
+	}
+	return m
+}
+
+// 业务场景：小张开户，购买A1 100股及B1 200股，再转移50股A1给小王
+func TestScenario(t *testing.T) {
+	stub := newStub(t)
+
+	run(t, stub, []step{
+		{"xiaozhang开户", xiaozhang, []string{"CreateAccount", "xiaozhang", "1000"}, true},
+		{"重复开户", xiaowang, []string{"CreateAccount", "xiaozhang", "1000"}, false},
+		{"余额为0", xiaowang, []string{"CreateAccount", "xiaowang", "0"}, false},
+		{"xiaowang开户", xiaowang, []string{"CreateAccount", "xiaowang", "100"}, true},
+		{"xiaozhang购买A1", xiaozhang, []string{"Buy", "xiaozhang", "AAA", "A1", "100"}, true},
+		{"xiaozhang购买B1", xiaozhang, []string{"Buy", "xiaozhang", "BBB", "B1", "200"}, true},
+		{"非所有者不能购买", xiaowang, []string{"Buy", "xiaozhang", "AAA", "A1", "10"}, false},
+		{"余额不足", xiaowang, []string{"Buy", "xiaowang", "AAA", "A1", "101"}, false},
+		{"资产不存在", xiaozhang, []string{"Buy", "xiaozhang", "CCC", "C1", "10"}, false},
+		{"xiaozhang转移50股A1给xiaowang", xiaozhang, []string{"Transfer", "xiaozhang", "xiaowang", "AAA", "A1", "50"}, true},
+		{"非所有者不能转移", xiaowang, []string{"Transfer", "xiaozhang", "xiaowang", "AAA", "A1", "10"}, false},
+		{"持有数量不足", xiaozhang, []string{"Transfer", "xiaozhang", "xiaowang", "AAA", "A1", "51"}, false},
+		{"转移给不存在的帐户", xiaozhang, []string{"Transfer", "xiaozhang", "xiaoli", "AAA", "A1", "10"}, false},
+		{"数量不是数字", xiaozhang, []string{"Transfer", "xiaozhang", "xiaowang", "AAA", "A1", "ten"}, false},
+		{"缺少参数", xiaozhang, []string{"Transfer", "xiaozhang", "xiaowang", "AAA", "A1"}, false},
+		{"未知方法", xiaozhang, []string{"Unknown"}, false},
+		{"多余参数", xiaozhang, []string{"Transfer", "xiaozhang", "xiaowang", "AAA", "A1", "10", "extra"}, false},
+		{"数量超出范围", xiaozhang, []string{"Buy", "xiaozhang", "AAA", "A1", "0"}, false},
+		{"列出函数", xiaozhang, []string{"ListFunctions"}, true},
+	})
+
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 50, "BBB/B1": 200})
+	checkHoldings(t, stub, "xiaowang", map[string]int64{"AAA/A1": 50})
+	checkBalance(t, stub, "xiaozhang", 700)
+	checkBalance(t, stub, "xiaowang", 100)
+	checkAssetAmount(t, stub, "AAA", "A1", 9900)
+	checkAssetAmount(t, stub, "BBB", "B1", 9800)
+
+	ev := stub.LastEvent()
+	if ev == nil || ev.EventName != "Transfer" {
+		t.Fatalf("last event: got %+v, want Transfer", ev)
+	}
+}
+
+// 设置手续费后，转出帐户另外支付手续费，失败的交易不改变状态
+func TestTransferFee(t *testing.T) {
+	stub := newStub(t)
+
+	run(t, stub, []step{
+		{"xiaozhang开户", xiaozhang, []string{"CreateAccount", "xiaozhang", "1000"}, true},
+		{"xiaowang开户", xiaowang, []string{"CreateAccount", "xiaowang", "100"}, true},
+		{"exchange开户", exchange, []string{"CreateAccount", "exchange", "100"}, true},
+		{"xiaozhang购买A1", xiaozhang, []string{"Buy", "xiaozhang", "AAA", "A1", "100"}, true},
+		{"非管理员不能设置手续费", xiaozhang, []string{"SetTransferFee", "AAA", "A1", "flat", "2", "exchange"}, false},
+		{"收费帐户不存在", admin, []string{"SetTransferFee", "AAA", "A1", "flat", "2", "xiaoli"}, false},
+		{"设置A1手续费", admin, []string{"SetTransferFee", "AAA", "A1", "flat", "2", "exchange"}, true},
+		{"xiaozhang转移50股A1给xiaowang", xiaozhang, []string{"Transfer", "xiaozhang", "xiaowang", "AAA", "A1", "50"}, true},
+		{"不足以支付手续费", xiaozhang, []string{"Transfer", "xiaozhang", "xiaowang", "AAA", "A1", "47"}, false},
+	})
+
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 48})
+	checkHoldings(t, stub, "xiaowang", map[string]int64{"AAA/A1": 50})
+	checkHoldings(t, stub, "exchange", map[string]int64{"AAA/A1": 2})
+
+	// 收取冻结时由被冻结帐户另外支付手续费，券款对付和挂单不收费因此拒绝
+	run(t, stub, []step{
+		{"xiaowang冻结20股A1给xiaozhang", xiaowang, []string{"Hold", "xiaowang", "h1", "xiaozhang", "AAA", "A1", "20", "3600"}, true},
+		{"xiaozhang收取10股", xiaozhang, []string{"CaptureHold", "xiaowang", "h1", "10"}, true},
+		{"xiaowang冻结剩余38股", xiaowang, []string{"Hold", "xiaowang", "h2", "xiaozhang", "AAA", "A1", "38", "3600"}, true},
+		{"可用持有量不足以支付手续费", xiaozhang, []string{"CaptureHold", "xiaowang", "h2"}, false},
+		{"xiaozhang收取30股", xiaozhang, []string{"CaptureHold", "xiaowang", "h2", "30"}, true},
+		{"收费资产不能券款对付", xiaowang, []string{"SettleTrade", "t1", "xiaowang", "xiaozhang", "AAA", "A1", "5", "1"}, false},
+		{"收费资产不能挂卖单", xiaowang, []string{"PlaceOrder", "xiaowang", "AAA", "A1", "sell", "1", "5"}, false},
+		{"收费资产不能挂买单", xiaozhang, []string{"PlaceOrder", "xiaozhang", "AAA", "A1", "buy", "1", "5"}, false},
+		{"收费帐户卖出不收费", exchange, []string{"SettleTrade", "t2", "exchange", "xiaozhang", "AAA", "A1", "2", "1"}, true},
+	})
+
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 88})
+	checkHoldings(t, stub, "xiaowang", map[string]int64{"AAA/A1": 6})
+	checkHoldings(t, stub, "exchange", map[string]int64{"AAA/A1": 4})
+	audit(t, stub)
+}
+
+// ListFunctions列出所有函数及参数
+// 同一帐户出现在多笔转移中时按顺序累计，任意一笔失败则全部不执行
+func TestBatchTransfer(t *testing.T) {
+	stub := newStub(t)
+	run(t, stub, []step{
+		{"xiaozhang开户", xiaozhang, []string{"CreateAccount", "xiaozhang", "1000"}, true},
+		{"xiaozhang开第二个户", xiaozhang, []string{"CreateAccount", "xiaozhang2", "100"}, true},
+		{"xiaowang开户", xiaowang, []string{"CreateAccount", "xiaowang", "100"}, true},
+		{"xiaozhang购买A1", xiaozhang, []string{"Buy", "xiaozhang", "AAA", "A1", "100"}, true},
+		{"xiaozhang购买B1", xiaozhang, []string{"Buy", "xiaozhang", "BBB", "B1", "50"}, true},
+	})
+
+	a1 := func(from, to string, amount int) string {
+		return fmt.Sprintf(`{"from":"%s","to":"%s","issuer":"AAA","code":"A1","amount":%d}`, from, to, amount)
+	}
+	tests := []struct {
+		name string
+		legs []string
+		ok   bool
+	}{
+		{"最后一笔持有量不足", []string{a1("xiaozhang", "xiaozhang2", 60), a1("xiaozhang2", "xiaowang", 20), a1("xiaozhang", "xiaowang", 30), a1("xiaozhang", "xiaowang", 20)}, false},
+		{"最后一笔转给不存在的帐户", []string{a1("xiaozhang", "xiaowang", 10), a1("xiaozhang", "xiaowang", 10), a1("xiaozhang", "xiaoli", 10)}, false},
+		{"最后一笔转出帐户不属于调用者", []string{a1("xiaozhang", "xiaowang", 10), a1("xiaowang", "xiaozhang", 5)}, false},
+		{"最后一笔转给自己", []string{a1("xiaozhang", "xiaowang", 10), a1("xiaozhang", "xiaozhang", 5)}, false},
+	}
+	for _, tt := range tests {
+		run(t, stub, []step{{tt.name, xiaozhang, []string{"BatchTransfer", "[" + strings.Join(tt.legs, ",") + "]"}, tt.ok}})
+		checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 100, "BBB/B1": 50})
+		checkHoldings(t, stub, "xiaozhang2", map[string]int64{})
+		checkHoldings(t, stub, "xiaowang", map[string]int64{})
+	}
+
+	legs := []string{a1("xiaozhang", "xiaozhang2", 60), a1("xiaozhang2", "xiaowang", 20), a1("xiaozhang", "xiaowang", 30),
+		`{"from":"xiaozhang","to":"xiaowang","issuer":"BBB","code":"B1","amount":50}`}
+	run(t, stub, []step{{"批量转移", xiaozhang, []string{"BatchTransfer", "[" + strings.Join(legs, ",") + "]"}, true}})
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 10})
+	checkHoldings(t, stub, "xiaozhang2", map[string]int64{"AAA/A1": 40})
+	checkHoldings(t, stub, "xiaowang", map[string]int64{"AAA/A1": 50, "BBB/B1": 50})
+}
+
+// 双方确认时各自冻结一端，第二方确认时一次交收；未交收的成交可以撤销，到期后不能再确认
+func TestSettleTrade(t *testing.T) {
+	stub := newStub(t)
+	run(t, stub, []step{
+		{"xiaozhang开户", xiaozhang, []string{"CreateAccount", "xiaozhang", "1000"}, true},
+		{"xiaowang开户", xiaowang, []string{"CreateAccount", "xiaowang", "500"}, true},
+		{"exchange开户", exchange, []string{"CreateAccount", "exchange", "100"}, true},
+		{"xiaozhang购买A1", xiaozhang, []string{"Buy", "xiaozhang", "AAA", "A1", "100"}, true},
+		{"卖方确认t1", xiaozhang, []string{"SettleTrade", "t1", "xiaozhang", "xiaowang", "AAA", "A1", "60", "5"}, true},
+		{"卖方重复确认", xiaozhang, []string{"SettleTrade", "t1", "xiaozhang", "xiaowang", "AAA", "A1", "60", "5"}, false},
+		{"条款不一致", xiaowang, []string{"SettleTrade", "t1", "xiaozhang", "xiaowang", "AAA", "A1", "50", "5"}, false},
+		{"非当事人确认", exchange, []string{"SettleTrade", "t1", "xiaozhang", "xiaowang", "AAA", "A1", "60", "5"}, false},
+		{"已冻结的资产不能再卖出", xiaozhang, []string{"SettleTrade", "t2", "xiaozhang", "xiaowang", "AAA", "A1", "50", "1"}, false},
+		{"已冻结的资产不能转移", xiaozhang, []string{"Transfer", "xiaozhang", "xiaowang", "AAA", "A1", "50"}, false},
+	})
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 40})
+	checkBalance(t, stub, "xiaowang", 500)
+
+	run(t, stub, []step{
+		{"买方确认t1，交收", xiaowang, []string{"SettleTrade", "t1", "xiaozhang", "xiaowang", "AAA", "A1", "60", "5"}, true},
+		{"已交收的成交不能撤销", xiaowang, []string{"CancelTrade", "t1"}, false},
+		{"已交收的成交不能再确认", xiaowang, []string{"SettleTrade", "t1", "xiaozhang", "xiaowang", "AAA", "A1", "60", "5"}, false},
+	})
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 40})
+	checkHoldings(t, stub, "xiaowang", map[string]int64{"AAA/A1": 60})
+	checkBalance(t, stub, "xiaozhang", 1200)
+	checkBalance(t, stub, "xiaowang", 200)
+	if ev := stub.LastEvent(); ev == nil || ev.EventName != "SettleTrade" {
+		t.Fatalf("last event: got %+v, want SettleTrade", ev)
+	}
+
+	run(t, stub, []step{
+		{"买方确认t3", xiaowang, []string{"SettleTrade", "t3", "xiaozhang", "xiaowang", "AAA", "A1", "10", "10"}, true},
+		{"已冻结的现金不能再买入", xiaowang, []string{"SettleTrade", "t4", "xiaozhang", "xiaowang", "AAA", "A1", "20", "10"}, false},
+		{"卖方确认t5，60秒有效", xiaozhang, []string{"SettleTrade", "t5", "xiaozhang", "xiaowang", "AAA", "A1", "20", "1", "60"}, true},
+		{"非当事人不能撤销", exchange, []string{"CancelTrade", "t3"}, false},
+	})
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 20})
+	checkBalance(t, stub, "xiaowang", 100)
+
+	// 卖方冻结的数量计入对账
+	if a := audit(t, stub)["AAA/A1"]; a.Traded != 20 {
+		t.Errorf("AuditSupply AAA/A1: got %+v", a)
+	}
+
+	stub.Advance(61 * time.Second)
+	run(t, stub, []step{
+		{"到期后不能确认", xiaowang, []string{"SettleTrade", "t5", "xiaozhang", "xiaowang", "AAA", "A1", "20", "1"}, false},
+		{"卖方撤销买方确认的t3", xiaozhang, []string{"CancelTrade", "t3"}, true},
+		{"买方撤销到期的t5", xiaowang, []string{"CancelTrade", "t5"}, true},
+		{"撤销后不存在", xiaowang, []string{"CancelTrade", "t5"}, false},
+	})
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 40})
+	checkHoldings(t, stub, "xiaowang", map[string]int64{"AAA/A1": 60})
+	checkBalance(t, stub, "xiaozhang", 1200)
+	checkBalance(t, stub, "xiaowang", 200)
+}
+
+// 按价格优先、时间优先撮合，成交价格为挂单价格，买单以低于委托价成交时退还差价
+func TestPlaceOrder(t *testing.T) {
+	stub := newStub(t)
+	run(t, stub, []step{
+		{"xiaozhang开户", xiaozhang, []string{"CreateAccount", "xiaozhang", "1000"}, true},
+		{"xiaowang开户", xiaowang, []string{"CreateAccount", "xiaowang", "1000"}, true},
+		{"exchange开户", exchange, []string{"CreateAccount", "exchange", "1000"}, true},
+		{"xiaozhang购买A1", xiaozhang, []string{"Buy", "xiaozhang", "AAA", "A1", "100"}, true},
+		{"xiaowang购买A1", xiaowang, []string{"Buy", "xiaowang", "AAA", "A1", "100"}, true},
+	})
+
+	place := func(caller []byte, args ...string) []Fill {
+		t.Helper()
+		stub.Advance(time.Second)
+		resp := stub.As(caller).Invoke(append([]string{"PlaceOrder"}, args...)...)
+		var r struct {
+			Fills []Fill `json:"fills"`
+		}
+		if resp.Status != shim.OK || json.Unmarshal(resp.Payload, &r) != nil {
+			t.Fatalf("PlaceOrder %v: status=%d message=%q", args, resp.Status, resp.Message)
+		}
+		return r.Fills
+	}
+
+	// 挂单时冻结卖出的资产及买入所需的现金
+	place(xiaozhang, "xiaozhang", "AAA", "A1", "sell", "5", "30")
+	place(xiaowang, "xiaowang", "AAA", "A1", "sell", "5", "30")
+	place(xiaowang, "xiaowang", "AAA", "A1", "sell", "4", "20")
+	place(exchange, "exchange", "AAA", "A1", "buy", "2", "10")
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 70})
+	checkHoldings(t, stub, "xiaowang", map[string]int64{"AAA/A1": 50})
+	checkBalance(t, stub, "exchange", 980)
+
+	run(t, stub, []step{
+		{"冻结后余额不足", exchange, []string{"PlaceOrder", "exchange", "AAA", "A1", "buy", "100", "10"}, false},
+		{"冻结后持有量不足", xiaowang, []string{"PlaceOrder", "xiaowang", "AAA", "A1", "sell", "5", "51"}, false},
+		{"方向错误", xiaowang, []string{"PlaceOrder", "xiaowang", "AAA", "A1", "hold", "5", "1"}, false},
+	})
+
+	// 先与价格最低的卖单成交，同价格的卖单先挂先成交
+	fills := place(exchange, "exchange", "AAA", "A1", "buy", "6", "60")
+	want := []struct {
+		seller       string
+		count, price int64
+	}{{"xiaowang", 20, 4}, {"xiaozhang", 30, 5}, {"xiaowang", 10, 5}}
+	if len(fills) != len(want) {
+		t.Fatalf("fills: got %+v, want %+v", fills, want)
+	}
+	for i, w := range want {
+		if fills[i].Seller != w.seller || fills[i].Buyer != "exchange" || fills[i].Count != w.count || fills[i].Price != w.price {
+			t.Errorf("fill #%d: got %+v, want %+v", i, fills[i], w)
+		}
+	}
+	// 委托价6，成交金额20*4+30*5+10*5=280，差价退还
+	checkBalance(t, stub, "exchange", 700)
+	checkBalance(t, stub, "xiaozhang", 1050)
+	checkBalance(t, stub, "xiaowang", 1030)
+	checkHoldings(t, stub, "exchange", map[string]int64{"AAA/A1": 60})
+
+	// 卖单部分成交，剩余部分挂单
+	fills = place(xiaozhang, "xiaozhang", "AAA", "A1", "sell", "2", "50")
+	if len(fills) != 1 || fills[0].Buyer != "exchange" || fills[0].Count != 10 || fills[0].Price != 2 {
+		t.Errorf("fills: got %+v", fills)
+	}
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 20})
+	checkHoldings(t, stub, "exchange", map[string]int64{"AAA/A1": 70})
+	checkBalance(t, stub, "xiaozhang", 1070)
+
+	resp := stub.Invoke("GetOrderBook", "AAA", "A1")
+	var book struct {
+		Bids []Order `json:"bids"`
+		Asks []Order `json:"asks"`
+	}
+	if resp.Status != shim.OK || json.Unmarshal(resp.Payload, &book) != nil {
+		t.Fatalf("GetOrderBook: status=%d message=%q", resp.Status, resp.Message)
+	}
+	if len(book.Bids) != 0 || len(book.Asks) != 2 ||
+		book.Asks[0].Account != "xiaozhang" || book.Asks[0].Price != 2 || book.Asks[0].Remaining != 40 ||
+		book.Asks[1].Account != "xiaowang" || book.Asks[1].Price != 5 || book.Asks[1].Remaining != 20 {
+		t.Errorf("order book: got %s", resp.Payload)
+	}
+}
+
+// 挂单以委托编号索引，撤单时通过索引找到挂单并退回冻结的资产
+func TestCancelOrder(t *testing.T) {
+	stub := newStub(t)
+	run(t, stub, []step{
+		{"xiaozhang开户", xiaozhang, []string{"CreateAccount", "xiaozhang", "1000"}, true},
+		{"xiaozhang购买A1", xiaozhang, []string{"Buy", "xiaozhang", "AAA", "A1", "100"}, true},
+	})
+
+	resp := stub.As(xiaozhang).Invoke("PlaceOrder", "xiaozhang", "AAA", "A1", "sell", "5", "40")
+	var r struct {
+		Order Order `json:"order"`
+	}
+	if resp.Status != shim.OK || json.Unmarshal(resp.Payload, &r) != nil {
+		t.Fatalf("PlaceOrder: status=%d message=%q", resp.Status, resp.Message)
+	}
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 60})
+
+	var index OrderIndex
+	if err := json.Unmarshal(stub.State(mustKey(t, stub, OrderObjectType, r.Order.ID)), &index); err != nil || index.DocType != OrderIndexDocType || index.BookKey == "" {
+		t.Errorf("order index: got %+v, %v", index, err)
+	}
+
+	run(t, stub, []step{
+		{"非所有者不能撤单", xiaowang, []string{"CancelOrder", r.Order.ID}, false},
+		{"xiaozhang撤单", xiaozhang, []string{"CancelOrder", r.Order.ID}, true},
+		{"重复撤单", xiaozhang, []string{"CancelOrder", r.Order.ID}, false},
+	})
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 100})
+	if stub.State(index.BookKey) != nil {
+		t.Errorf("order book entry not deleted")
+	}
+}
+
+// 冻结的资产不能转移；受益帐户到期前可以部分收取，到期后不能收取，由帐户所有者收回
+func TestHold(t *testing.T) {
+	stub := newStub(t)
+	run(t, stub, []step{
+		{"xiaozhang开户", xiaozhang, []string{"CreateAccount", "xiaozhang", "1000"}, true},
+		{"xiaowang开户", xiaowang, []string{"CreateAccount", "xiaowang", "100"}, true},
+		{"xiaozhang购买A1", xiaozhang, []string{"Buy", "xiaozhang", "AAA", "A1", "100"}, true},
+		{"冻结60股", xiaozhang, []string{"Hold", "xiaozhang", "h1", "xiaowang", "AAA", "A1", "60", "3600"}, true},
+		{"非所有者不能冻结", xiaowang, []string{"Hold", "xiaozhang", "h2", "xiaowang", "AAA", "A1", "10", "3600"}, false},
+		{"冻结名称重复", xiaozhang, []string{"Hold", "xiaozhang", "h1", "xiaowang", "AAA", "A1", "10", "3600"}, false},
+		{"冻结后持有量不足", xiaozhang, []string{"Hold", "xiaozhang", "h2", "xiaowang", "AAA", "A1", "41", "3600"}, false},
+		{"冻结的资产不能转移", xiaozhang, []string{"Transfer", "xiaozhang", "xiaowang", "AAA", "A1", "41"}, false},
+		{"到期前不能收回", xiaozhang, []string{"ReleaseHold", "xiaozhang", "h1"}, false},
+		{"非受益帐户不能收取", xiaozhang, []string{"CaptureHold", "xiaozhang", "h1"}, false},
+		{"收取数量超过冻结数量", xiaowang, []string{"CaptureHold", "xiaozhang", "h1", "61"}, false},
+	})
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 40})
+	checkHoldings(t, stub, "xiaowang", map[string]int64{})
+
+	run(t, stub, []step{
+		{"部分收取，剩余退回", xiaowang, []string{"CaptureHold", "xiaozhang", "h1", "25"}, true},
+		{"收取后冻结不存在", xiaowang, []string{"CaptureHold", "xiaozhang", "h1"}, false},
+		{"冻结30股，60秒到期", xiaozhang, []string{"Hold", "xiaozhang", "h2", "xiaowang", "AAA", "A1", "30", "60"}, true},
+		{"冻结20股，60秒到期", xiaozhang, []string{"Hold", "xiaozhang", "h3", "xiaowang", "AAA", "A1", "20", "60"}, true},
+	})
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 25})
+	checkHoldings(t, stub, "xiaowang", map[string]int64{"AAA/A1": 25})
+
+	stub.Advance(61 * time.Second)
+	run(t, stub, []step{
+		{"到期后不能收取", xiaowang, []string{"CaptureHold", "xiaozhang", "h2"}, false},
+		{"到期后所有者收回", xiaozhang, []string{"ReleaseHold", "xiaozhang", "h2"}, true},
+		{"受益帐户释放", xiaowang, []string{"ReleaseHold", "xiaozhang", "h3"}, true},
+	})
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 75})
+	checkHoldings(t, stub, "xiaowang", map[string]int64{"AAA/A1": 25})
+}
+
+// 发行机构设置赎回价格后，持有者按赎回价格赎回得到现金，赎回的资产计入销毁数量
+func TestRedeem(t *testing.T) {
+	aaa := memstub.MustIdentity("AAAMSP", "issuer.aaa", nil)
+	stub := newStub(t)
+	run(t, stub, []step{
+		{"xiaozhang开户", xiaozhang, []string{"CreateAccount", "xiaozhang", "1000"}, true},
+		{"xiaozhang购买A1", xiaozhang, []string{"Buy", "xiaozhang", "AAA", "A1", "100"}, true},
+		{"未设置赎回价格不能赎回", xiaozhang, []string{"Redeem", "xiaozhang", "AAA", "A1", "10"}, false},
+		{"非发行机构不能设置赎回价格", xiaozhang, []string{"SetRedemptionPrice", "AAA", "A1", "3"}, false},
+		{"AAA设置赎回价格", aaa, []string{"SetRedemptionPrice", "AAA", "A1", "3"}, true},
+		{"赎回30股", xiaozhang, []string{"Redeem", "xiaozhang", "AAA", "A1", "30"}, true},
+		{"非所有者不能赎回", xiaowang, []string{"Redeem", "xiaozhang", "AAA", "A1", "10"}, false},
+		{"持有量不足", xiaozhang, []string{"Redeem", "xiaozhang", "AAA", "A1", "71"}, false},
+	})
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 70})
+	checkBalance(t, stub, "xiaozhang", 990)
+	checkAssetAmount(t, stub, "AAA", "A1", 9900)
+
+	a := audit(t, stub)["AAA/A1"]
+	if a.Issued != 10000 || a.Redeemed != 30 || a.Unissued != 9900 || a.Circulating != 70 {
+		t.Errorf("AuditSupply AAA/A1: got %+v", a)
+	}
+}
+
+// 购买、赎回、冻结及挂单后，未发行、帐户持有、冻结中及卖单冻结的数量与发行总量一致
+func TestAuditSupply(t *testing.T) {
+	aaa := memstub.MustIdentity("AAAMSP", "issuer.aaa", nil)
+	stub := newStub(t)
+	run(t, stub, []step{
+		{"xiaozhang开户", xiaozhang, []string{"CreateAccount", "xiaozhang", "1000"}, true},
+		{"xiaowang开户", xiaowang, []string{"CreateAccount", "xiaowang", "100"}, true},
+		{"xiaozhang购买A1", xiaozhang, []string{"Buy", "xiaozhang", "AAA", "A1", "100"}, true},
+		{"xiaozhang购买B1", xiaozhang, []string{"Buy", "xiaozhang", "BBB", "B1", "50"}, true},
+		{"AAA设置赎回价格", aaa, []string{"SetRedemptionPrice", "AAA", "A1", "1"}, true},
+		{"赎回10股A1", xiaozhang, []string{"Redeem", "xiaozhang", "AAA", "A1", "10"}, true},
+		{"冻结20股A1", xiaozhang, []string{"Hold", "xiaozhang", "h1", "xiaowang", "AAA", "A1", "20", "3600"}, true},
+		{"挂单卖出30股A1", xiaozhang, []string{"PlaceOrder", "xiaozhang", "AAA", "A1", "sell", "5", "30"}, true},
+	})
+
+	audits := audit(t, stub)
+	if a := audits["AAA/A1"]; a.Issued != 10000 || a.Redeemed != 10 || a.Unissued != 9900 || a.Circulating != 40 || a.Held != 20 || a.Ordered != 30 {
+		t.Errorf("AuditSupply AAA/A1: got %+v", a)
+	}
+	if a := audits["BBB/B1"]; a.Issued != 10000 || a.Unissued != 9950 || a.Circulating != 50 || a.Held != 0 || a.Ordered != 0 {
+		t.Errorf("AuditSupply BBB/B1: got %+v", a)
+	}
+}
+
+// 按书签翻页列出帐户及帐户持有的资产，最后一页书签为空
+func TestListPages(t *testing.T) {
+	stub := newStub(t)
+	ids := []string{"a1", "a2", "a3", "a4", "a5"}
+	for _, id := range ids {
+		run(t, stub, []step{{"开户" + id, xiaozhang, []string{"CreateAccount", id, "1000"}, true}})
+	}
+	run(t, stub, []step{
+		{"a1购买A1", xiaozhang, []string{"Buy", "a1", "AAA", "A1", "10"}, true},
+		{"a1购买B1", xiaozhang, []string{"Buy", "a1", "BBB", "B1", "20"}, true},
+		{"a2购买B1", xiaozhang, []string{"Buy", "a2", "BBB", "B1", "30"}, true},
+		{"pageSize为0", xiaozhang, []string{"ListAccounts", "0"}, false},
+	})
+
+	// 依次取每一页，返回所有记录
+	list := func(pageSize int, args ...string) []json.RawMessage {
+		t.Helper()
+		records := []json.RawMessage{}
+		bookmark := ""
+		for i := 0; i < 10; i++ {
+			resp := stub.Invoke(append(args, strconv.Itoa(pageSize), bookmark)...)
+			var page struct {
+				Records  []json.RawMessage `json:"records"`
+				Count    int32             `json:"count"`
+				Bookmark string            `json:"bookmark"`
+			}
+			if resp.Status != shim.OK || json.Unmarshal(resp.Payload, &page) != nil {
+				t.Fatalf("%v: status=%d message=%q", args, resp.Status, resp.Message)
+			}
+			if page.Count != int32(len(page.Records)) || len(page.Records) > pageSize {
+				t.Errorf("%v page #%d: got %s", args, i, resp.Payload)
+			}
+			records = append(records, page.Records...)
+			if bookmark = page.Bookmark; bookmark == "" {
+				break
+			}
+		}
+		return records
+	}
+
+	got := []string{}
+	for _, r := range list(2, "ListAccounts") {
+		var a Account
+		if err := json.Unmarshal(r, &a); err != nil {
+			t.Fatalf("ListAccounts: %s", err)
+		}
+		got = append(got, a.ID)
+	}
+	if strings.Join(got, ",") != strings.Join(ids, ",") {
+		t.Errorf("ListAccounts: got %v, want %v", got, ids)
+	}
+
+	got = []string{}
+	for _, r := range list(1, "ListHoldings", "a1") {
+		var a Asset
+		if err := json.Unmarshal(r, &a); err != nil {
+			t.Fatalf("ListHoldings: %s", err)
+		}
+		got = append(got, fmt.Sprintf("%s/%s=%d", a.Issuer, a.Code, a.Amount))
+	}
+	if want := "AAA/A1=10,BBB/B1=20"; strings.Join(got, ",") != want {
+		t.Errorf("ListHoldings: got %v, want %s", got, want)
+	}
+}
+
+// 代理帐户在额度内转移所有者的资产，每次转移扣减额度，额度为0时取消授权
+func TestTransferFrom(t *testing.T) {
+	stub := newStub(t)
+	allowance := func() int64 {
+		resp := stub.Invoke("Allowance", "xiaozhang", "exchange", "AAA", "A1")
+		var a assetcore.Allowance
+		if resp.Status != shim.OK || json.Unmarshal(resp.Payload, &a) != nil {
+			t.Fatalf("Allowance: status=%d message=%q", resp.Status, resp.Message)
+		}
+		return a.Amount
+	}
+	run(t, stub, []step{
+		{"xiaozhang开户", xiaozhang, []string{"CreateAccount", "xiaozhang", "1000"}, true},
+		{"xiaowang开户", xiaowang, []string{"CreateAccount", "xiaowang", "100"}, true},
+		{"exchange开户", exchange, []string{"CreateAccount", "exchange", "100"}, true},
+		{"xiaozhang购买A1", xiaozhang, []string{"Buy", "xiaozhang", "AAA", "A1", "100"}, true},
+		{"非所有者不能授权", xiaowang, []string{"Approve", "xiaozhang", "exchange", "AAA", "A1", "50"}, false},
+		{"授权50股", xiaozhang, []string{"Approve", "xiaozhang", "exchange", "AAA", "A1", "50"}, true},
+		{"代理转移30股", exchange, []string{"TransferFrom", "exchange", "xiaozhang", "xiaowang", "AAA", "A1", "30"}, true},
+		{"超出剩余额度", exchange, []string{"TransferFrom", "exchange", "xiaozhang", "xiaowang", "AAA", "A1", "21"}, false},
+		{"非代理帐户所有者不能转移", xiaowang, []string{"TransferFrom", "exchange", "xiaozhang", "xiaowang", "AAA", "A1", "10"}, false},
+	})
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 70})
+	checkHoldings(t, stub, "xiaowang", map[string]int64{"AAA/A1": 30})
+	if got := allowance(); got != 20 {
+		t.Errorf("allowance: got %d, want 20", got)
+	}
+
+	run(t, stub, []step{
+		{"额度为0时取消授权", xiaozhang, []string{"Approve", "xiaozhang", "exchange", "AAA", "A1", "0"}, true},
+		{"取消授权后不能转移", exchange, []string{"TransferFrom", "exchange", "xiaozhang", "xiaowang", "AAA", "A1", "1"}, false},
+	})
+	if got := allowance(); got != 0 {
+		t.Errorf("allowance: got %d, want 0", got)
+	}
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 70})
+}
+
+// 设置审批策略后，超过阈值的数量不能冻结、收取、交收或挂单，只能通过Transfer发起审批
+func TestApprovalThreshold(t *testing.T) {
+	aaa := memstub.MustIdentity("AAAMSP", "issuer.aaa", nil)
+	stub := newStub(t)
+	signers := `[{"mspId":"AdminMSP","subject":"CN=admin"}]`
+	run(t, stub, []step{
+		{"xiaozhang开户", xiaozhang, []string{"CreateAccount", "xiaozhang", "1000"}, true},
+		{"xiaowang开户", xiaowang, []string{"CreateAccount", "xiaowang", "1000"}, true},
+		{"xiaozhang购买A1", xiaozhang, []string{"Buy", "xiaozhang", "AAA", "A1", "100"}, true},
+		{"阈值50", aaa, []string{"SetApprovalPolicy", "AAA", "A1", "50", "1", "3600", signers}, true},
+		{"冻结超过阈值", xiaozhang, []string{"Hold", "xiaozhang", "h1", "xiaowang", "AAA", "A1", "51", "3600"}, false},
+		{"交收超过阈值", xiaozhang, []string{"SettleTrade", "t1", "xiaozhang", "xiaowang", "AAA", "A1", "51", "1"}, false},
+		{"卖单超过阈值", xiaozhang, []string{"PlaceOrder", "xiaozhang", "AAA", "A1", "sell", "1", "51"}, false},
+		{"买单超过阈值", xiaowang, []string{"PlaceOrder", "xiaowang", "AAA", "A1", "buy", "1", "51"}, false},
+		{"冻结不超过阈值", xiaozhang, []string{"Hold", "xiaozhang", "h1", "xiaowang", "AAA", "A1", "40", "3600"}, true},
+		{"卖方确认不超过阈值的成交", xiaozhang, []string{"SettleTrade", "t2", "xiaozhang", "xiaowang", "AAA", "A1", "40", "1"}, true},
+		{"阈值降为30", aaa, []string{"SetApprovalPolicy", "AAA", "A1", "30", "1", "3600", signers}, true},
+		{"收取超过阈值", xiaowang, []string{"CaptureHold", "xiaozhang", "h1"}, false},
+		{"买方确认超过阈值的成交", xiaowang, []string{"SettleTrade", "t2", "xiaozhang", "xiaowang", "AAA", "A1", "40", "1"}, false},
+		{"收取不超过阈值", xiaowang, []string{"CaptureHold", "xiaozhang", "h1", "30"}, true},
+		{"撤销成交", xiaozhang, []string{"CancelTrade", "t2"}, true},
+	})
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 70})
+	checkHoldings(t, stub, "xiaowang", map[string]int64{"AAA/A1": 30})
+	checkBalance(t, stub, "xiaowang", 1000)
+}
+
+// 超过阈值的转移生成待审批转移，签名人审批数达到要求时执行；重复审批、非签名人审批及到期后审批被拒绝
+func TestApproveTransfer(t *testing.T) {
+	aaa := memstub.MustIdentity("AAAMSP", "issuer.aaa", nil)
+	alice := memstub.MustIdentity("SignerMSP", "alice", nil)
+	bob := memstub.MustIdentity("SignerMSP", "bob", nil)
+	stub := newStub(t)
+	run(t, stub, []step{
+		{"xiaozhang开户", xiaozhang, []string{"CreateAccount", "xiaozhang", "1000"}, true},
+		{"xiaowang开户", xiaowang, []string{"CreateAccount", "xiaowang", "100"}, true},
+		{"xiaozhang购买A1", xiaozhang, []string{"Buy", "xiaozhang", "AAA", "A1", "200"}, true},
+		{"阈值50，两人审批", aaa, []string{"SetApprovalPolicy", "AAA", "A1", "50", "2", "3600", `[{"mspId":"SignerMSP","subject":"CN=alice"},{"mspId":"SignerMSP","subject":"CN=bob"}]`}, true},
+	})
+
+	propose := func() string {
+		t.Helper()
+		resp := stub.As(xiaozhang).Invoke("Transfer", "xiaozhang", "xiaowang", "AAA", "A1", "60")
+		var p assetcore.PendingTransfer
+		if resp.Status != shim.OK || json.Unmarshal(resp.Payload, &p) != nil || p.ID == "" {
+			t.Fatalf("Transfer: status=%d message=%q", resp.Status, resp.Message)
+		}
+		return p.ID
+	}
+
+	p1 := propose()
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 200})
+	run(t, stub, []step{
+		{"非签名人不能审批", xiaowang, []string{"ApproveTransfer", p1}, false},
+		{"alice审批", alice, []string{"ApproveTransfer", p1}, true},
+		{"alice重复审批", alice, []string{"ApproveTransfer", p1}, false},
+	})
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 200})
+
+	// 审批人不是转出帐户所有者，执行转移时不再校验所有者
+	run(t, stub, []step{
+		{"bob审批后执行", bob, []string{"ApproveTransfer", p1}, true},
+		{"执行后不存在", xiaozhang, []string{"PendingTransfer", p1}, false},
+	})
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 140})
+	checkHoldings(t, stub, "xiaowang", map[string]int64{"AAA/A1": 60})
+	var ev Event
+	if e := stub.LastEvent(); e == nil || e.EventName != "ApproveTransfer" || json.Unmarshal(e.Payload, &ev) != nil || !ev.Executed || ev.Approvals != 2 {
+		t.Errorf("last event: got %+v, want executed ApproveTransfer", e)
+	}
+
+	p2, p3, p4 := propose(), propose(), propose()
+	run(t, stub, []step{
+		{"接收帐户不能取消", xiaowang, []string{"CancelTransfer", p2}, false},
+		{"转出帐户所有者取消", xiaozhang, []string{"CancelTransfer", p2}, true},
+		{"取消后不能审批", alice, []string{"ApproveTransfer", p2}, false},
+		{"签名人取消", bob, []string{"CancelTransfer", p3}, true},
+		{"alice审批p4", alice, []string{"ApproveTransfer", p4}, true},
+	})
+	stub.Advance(3601 * time.Second)
+	run(t, stub, []step{{"到期后不能审批", bob, []string{"ApproveTransfer", p4}, false}})
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 140})
+	checkHoldings(t, stub, "xiaowang", map[string]int64{"AAA/A1": 60})
+}
+
+// 归属计划锁定期内全部锁定，之后按时间线性解锁，锁定的数量不能转移
+func TestGrantVested(t *testing.T) {
+	aaa := memstub.MustIdentity("AAAMSP", "issuer.aaa", nil)
+	stub := newStub(t)
+	transfer := func(amount string) []string {
+		return []string{"Transfer", "xiaozhang", "xiaowang", "AAA", "A1", amount}
+	}
+	grant := []string{"GrantVested", "xiaozhang", "AAA", "A1", "100", "100", "400"}
+	run(t, stub, []step{
+		{"xiaozhang开户", xiaozhang, []string{"CreateAccount", "xiaozhang", "1000"}, true},
+		{"xiaowang开户", xiaowang, []string{"CreateAccount", "xiaowang", "100"}, true},
+		{"xiaozhang购买A1", xiaozhang, []string{"Buy", "xiaozhang", "AAA", "A1", "20"}, true},
+		{"非发行机构不能授予", xiaozhang, grant, false},
+		{"授予100股，锁定100秒，400秒解锁完", aaa, grant, true},
+		{"未解锁完时不能再授予", aaa, grant, false},
+		{"转移锁定的数量", xiaozhang, transfer("21"), false},
+		{"转移未锁定的数量", xiaozhang, transfer("20"), true},
+	})
+	checkAssetAmount(t, stub, "AAA", "A1", 9880)
+	stub.Advance(99 * time.Second)
+	run(t, stub, []step{{"锁定期内不能转移", xiaozhang, transfer("1"), false}})
+
+	stub.Advance(101 * time.Second)
+	resp := stub.Invoke("VestingStatus", "xiaozhang", "AAA", "A1")
+	var status assetcore.VestingStatus
+	if resp.Status != shim.OK || json.Unmarshal(resp.Payload, &status) != nil {
+		t.Fatalf("VestingStatus: status=%d message=%q", resp.Status, resp.Message)
+	}
+	if status.Vested != 50 || status.Locked != 50 || status.Balance != 100 || status.Unlocked != 50 {
+		t.Errorf("VestingStatus: got %+v", status)
+	}
+	run(t, stub, []step{
+		{"超过已解锁的数量", xiaozhang, transfer("51"), false},
+		{"转移已解锁的数量", xiaozhang, transfer("50"), true},
+	})
+
+	stub.Advance(200 * time.Second)
+	run(t, stub, []step{{"全部解锁后转移", xiaozhang, transfer("50"), true}})
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{})
+	checkHoldings(t, stub, "xiaowang", map[string]int64{"AAA/A1": 120})
+}
+
+// 拆分后每条持仓及冻结按比例向下取整，舍弃的数量计入fractional；有挂单、待交收成交或赎回价格溢出时不能拆分
+func TestSplitAsset(t *testing.T) {
+	aaa := memstub.MustIdentity("AAAMSP", "issuer.aaa", nil)
+	stub := newStub(t)
+	split := []string{"SplitAsset", "AAA", "A1", "2", "3"}
+	run(t, stub, []step{
+		{"xiaozhang开户", xiaozhang, []string{"CreateAccount", "xiaozhang", "1000"}, true},
+		{"xiaowang开户", xiaowang, []string{"CreateAccount", "xiaowang", "100"}, true},
+		{"xiaozhang购买A1", xiaozhang, []string{"Buy", "xiaozhang", "AAA", "A1", "10"}, true},
+		{"xiaowang购买A1", xiaowang, []string{"Buy", "xiaowang", "AAA", "A1", "5"}, true},
+		{"冻结3股", xiaozhang, []string{"Hold", "xiaozhang", "h1", "xiaowang", "AAA", "A1", "3", "3600"}, true},
+		{"非管理员不能拆分", aaa, split, false},
+		{"挂单", xiaozhang, []string{"PlaceOrder", "xiaozhang", "AAA", "A1", "sell", "1", "1"}, true},
+		{"有挂单时不能拆分", admin, split, false},
+	})
+	resp := stub.As(xiaozhang).Invoke("GetOrderBook", "AAA", "A1")
+	var book struct {
+		Asks []Order `json:"asks"`
+	}
+	if resp.Status != shim.OK || json.Unmarshal(resp.Payload, &book) != nil || len(book.Asks) != 1 {
+		t.Fatalf("GetOrderBook: status=%d payload=%s", resp.Status, resp.Payload)
+	}
+	run(t, stub, []step{
+		{"撤单", xiaozhang, []string{"CancelOrder", book.Asks[0].ID}, true},
+		{"卖方确认成交", xiaozhang, []string{"SettleTrade", "t1", "xiaozhang", "xiaowang", "AAA", "A1", "1", "1"}, true},
+		{"有待交收成交时不能拆分", admin, split, false},
+		{"撤销成交", xiaozhang, []string{"CancelTrade", "t1"}, true},
+		{"赎回价格过大", aaa, []string{"SetRedemptionPrice", "AAA", "A1", strconv.FormatInt(math.MaxInt64/2, 10)}, true},
+		{"赎回价格调整后溢出", admin, split, false},
+		{"赎回价格", aaa, []string{"SetRedemptionPrice", "AAA", "A1", "3"}, true},
+	})
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 7})
+
+	resp = stub.As(admin).Invoke(split...)
+	var s SplitSummary
+	if resp.Status != shim.OK || json.Unmarshal(resp.Payload, &s) != nil {
+		t.Fatalf("SplitAsset: status=%d message=%q", resp.Status, resp.Message)
+	}
+	// 7*2/3=4，5*2/3=3，3*2/3=2，合计9，按总量15*2/3应得10；未发行9985*2/3=6656
+	if s.Holders != 2 || s.Holds != 1 || s.Before != 15 || s.After != 9 || s.Fractional != 1 || s.Unissued != 6656 {
+		t.Errorf("split summary: got %+v", s)
+	}
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 4})
+	checkHoldings(t, stub, "xiaowang", map[string]int64{"AAA/A1": 3})
+	if a := audit(t, stub)["AAA/A1"]; a.Issued != 6665 || a.Unissued != 6656 || a.Circulating != 7 || a.Held != 2 {
+		t.Errorf("AuditSupply AAA/A1: got %+v", a)
+	}
+
+	// 赎回价格按反比例调整：3*3/2=4
+	run(t, stub, []step{{"按调整后的价格赎回", xiaozhang, []string{"Redeem", "xiaozhang", "AAA", "A1", "1"}, true}})
+	checkBalance(t, stub, "xiaozhang", 994)
+}
+
+// 派息按帐户持有、冻结中、卖单及待交收成交冻结的总量分配
+func TestDistributeDividend(t *testing.T) {
+	aaa := memstub.MustIdentity("AAAMSP", "issuer.aaa", nil)
+	stub := memstub.New("cc2", newChaincode(assetcore.HoldingStore{}))
+	resp := stub.As(admin).Init("init",
+		`[{"name":"AAA","mspId":"AAAMSP","account":"aaafund"},{"name":"BBB","mspId":"BBBMSP"}]`,
+		`{"mspId":"AdminMSP"}`)
+	if resp.Status != shim.OK {
+		t.Fatalf("Init: %s", resp.Message)
+	}
+
+	run(t, stub, []step{
+		{"aaafund开户", aaa, []string{"CreateAccount", "aaafund", "1000"}, true},
+		{"xiaozhang开户", xiaozhang, []string{"CreateAccount", "xiaozhang", "1000"}, true},
+		{"xiaowang开户", xiaowang, []string{"CreateAccount", "xiaowang", "1000"}, true},
+		{"exchange开户", exchange, []string{"CreateAccount", "exchange", "100"}, true},
+		{"xiaozhang购买A1", xiaozhang, []string{"Buy", "xiaozhang", "AAA", "A1", "100"}, true},
+		{"xiaowang购买A1", xiaowang, []string{"Buy", "xiaowang", "AAA", "A1", "100"}, true},
+		{"xiaozhang冻结30股A1", xiaozhang, []string{"Hold", "xiaozhang", "escrow", "exchange", "AAA", "A1", "30", "3600"}, true},
+		{"xiaowang挂单卖出20股A1", xiaowang, []string{"PlaceOrder", "xiaowang", "AAA", "A1", "sell", "5", "20"}, true},
+		{"xiaowang确认卖出20股A1", xiaowang, []string{"SettleTrade", "t1", "xiaowang", "exchange", "AAA", "A1", "20", "1"}, true},
+		{"非发行机构不能派息", xiaozhang, []string{"DistributeDividend", "AAA", "A1", "200"}, false},
+	})
+
+	resp = stub.As(aaa).Invoke("DistributeDividend", "AAA", "A1", "200")
+	var d Distribution
+	if resp.Status != shim.OK || json.Unmarshal(resp.Payload, &d) != nil {
+		t.Fatalf("DistributeDividend: status=%d message=%q", resp.Status, resp.Message)
+	}
+	if d.Units != 200 || d.Holders != 2 {
+		t.Errorf("distribution: got %+v, want units=200 holders=2", d)
+	}
+
+	// 冻结、卖单及待交收成交中的数量同样参与派息，两人各得100
+	checkBalance(t, stub, "xiaozhang", 1000)
+	checkBalance(t, stub, "xiaowang", 1000)
+	checkBalance(t, stub, "exchange", 100)
+	checkBalance(t, stub, "aaafund", 800)
+}
+
+func TestListFunctions(t *testing.T) {
+	stub := newStub(t)
+	resp := stub.Invoke("ListFunctions")
+	var functions []assetcore.Function
+	if resp.Status != shim.OK || json.Unmarshal(resp.Payload, &functions) != nil {
+		t.Fatalf("ListFunctions: status=%d message=%q", resp.Status, resp.Message)
+	}
+	m := map[string]assetcore.Function{}
+	for _, f := range functions {
+		m[f.Name] = f
+	}
+	if f := m["Transfer"]; len(f.Args) != 5 || f.Args[4].Name != "count" || f.Args[4].Type != assetcore.ArgInt || f.Query {
+		t.Errorf("Transfer: got %+v", f)
+	}
+	if f := m["AccountInfo"]; !f.Query {
+		t.Errorf("AccountInfo: got %+v, want query", f)
+	}
+	if len(m) != len(functions) || len(m) != len((&SimpleChaincode{}).functions())+1 {
+		t.Errorf("ListFunctions: got %d functions", len(functions))
+	}
+}
+
+// cc1与cc2对同类错误返回相同的错误码
+func TestErrorCodes(t *testing.T) {
+	stub := newStub(t)
+	run(t, stub, []step{
+		{"xiaozhang开户", xiaozhang, []string{"CreateAccount", "xiaozhang", "1000"}, true},
+		{"xiaowang开户", xiaowang, []string{"CreateAccount", "xiaowang", "100"}, true},
+		{"xiaozhang购买A1", xiaozhang, []string{"Buy", "xiaozhang", "AAA", "A1", "100"}, true},
+	})
+
+	tests := []struct {
+		name   string
+		caller []byte
+		args   []string
+		code   string
+	}{
+		{"重复开户", xiaozhang, []string{"CreateAccount", "xiaozhang", "1000"}, assetcore.CodeAlreadyExists},
+		{"帐户不存在", xiaozhang, []string{"AccountInfo", "xiaoli"}, assetcore.CodeAccountNotFound},
+		{"转移给不存在的帐户", xiaozhang, []string{"Transfer", "xiaozhang", "xiaoli", "AAA", "A1", "10"}, assetcore.CodeAccountNotFound},
+		{"持有数量不足", xiaozhang, []string{"Transfer", "xiaozhang", "xiaowang", "AAA", "A1", "101"}, assetcore.CodeInsufficientFunds},
+		{"余额不足", xiaowang, []string{"Buy", "xiaowang", "AAA", "A1", "101"}, assetcore.CodeInsufficientFunds},
+		{"资产不存在", xiaozhang, []string{"Buy", "xiaozhang", "CCC", "C1", "10"}, assetcore.CodeAssetNotFound},
+		{"非所有者不能转移", xiaowang, []string{"Transfer", "xiaozhang", "xiaowang", "AAA", "A1", "10"}, assetcore.CodeUnauthorized},
+		{"数量不是数字", xiaozhang, []string{"Transfer", "xiaozhang", "xiaowang", "AAA", "A1", "ten"}, assetcore.CodeInvalidArgument},
+		{"未知方法", xiaozhang, []string{"Unknown"}, assetcore.CodeInvalidArgument},
+	}
+	for _, tt := range tests {
+		resp := stub.As(tt.caller).Invoke(tt.args...)
+		var e assetcore.CodedError
+		if err := json.Unmarshal([]byte(resp.Message), &e); err != nil || e.Code != tt.code || resp.Status < shim.ERRORTHRESHOLD {
+			t.Errorf("%s: status=%d message=%q, want code=%s", tt.name, resp.Status, resp.Message, tt.code)
+		}
+	}
+}
+
+// legacyChaincode 以cc1的格式写入状态
+// put：id、持有资产JSON，以调用者为所有者写入帐户；record：对象类型、属性（以逗号分隔）、记录JSON
+type legacyChaincode struct{}
+
+func (legacyChaincode) Init(stub shim.ChaincodeStubInterface) pb.Response {
+	return shim.Success(nil)
+}
+
+func (legacyChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
+	fn, args := stub.GetFunctionAndParameters()
+	key, value := args[0], []byte(args[1])
+	if fn == "record" {
+		var err error
+		key, err = stub.CreateCompositeKey(args[0], strings.Split(args[1], ","))
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		value = []byte(args[2])
+	} else {
+		owner, err := assetcore.Caller(stub)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		value, _ = json.Marshal(map[string]interface{}{"accountId": args[0], "assets": json.RawMessage(args[1]), "owner": owner})
+	}
+	if err := stub.PutState(key, value); err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success(nil)
+}
+
+// 从cc1升级后分多笔交易迁移持有量，重复调用不改变结果
+// 冻结、归属计划、帐户冻结及KYC记录升级后继续生效，发行总量记录合并到资产记录
+func TestMigrate(t *testing.T) {
+	stub := memstub.New("asset", legacyChaincode{})
+	for _, s := range []step{
+		// xiaozhang的10股A1冻结给xiaowang，已从帐户中扣除
+		{"xiaozhang", xiaozhang, []string{"put", "xiaozhang", `[{"issuer":"AAA","code":"A1","amount":50},{"issuer":"BBB","code":"B1","amount":200}]`}, true},
+		{"xiaowang", xiaowang, []string{"put", "xiaowang", `[{"issuer":"AAA","code":"A1","amount":50},{"issuer":"BBB","code":"B1","amount":0}]`}, true},
+		{"xiaoli", exchange, []string{"put", "xiaoli", `[]`}, true},
+		{"hold", xiaozhang, []string{"record", assetcore.HoldObjectType, "xiaozhang,escrow", `{"name":"escrow","account":"xiaozhang","to":"xiaowang","issuer":"AAA","code":"A1","amount":10,"expiry":4102444800}`}, true},
+		{"vesting", admin, []string{"record", assetcore.VestingObjectType, "xiaozhang,BBB,B1", `{"id":"xiaozhang","issuer":"BBB","code":"B1","total":200,"start":0,"cliff":4102444800,"duration":4102444800}`}, true},
+		{"freeze", admin, []string{"record", assetcore.FreezeObjectType, "xiaoli", `{"active":true,"reason":"court order"}`}, true},
+		{"kyc", admin, []string{"record", assetcore.KYCObjectType, "xiaowang", `{"id":"xiaowang","status":"verified","tier":2}`}, true},
+		{"supply A1", admin, []string{"record", assetcore.SupplyObjectType, "AAA,A1", `{"issuer":"AAA","code":"A1","issued":110,"redeemed":0}`}, true},
+		{"supply B1", admin, []string{"record", assetcore.SupplyObjectType, "BBB,B1", `{"issuer":"BBB","code":"B1","issued":300,"redeemed":100}`}, true},
+	} {
+		if resp := stub.As(s.caller).Invoke(s.args...); resp.Status != shim.OK {
+			t.Fatalf("put %s: %s", s.name, resp.Message)
+		}
+	}
+	resp := stub.As(admin).Upgrade(newChaincode(assetcore.HoldingStore{}), "init",
+		`[{"name":"AAA","mspId":"AAAMSP"},{"name":"BBB","mspId":"BBBMSP"}]`,
+		`{"mspId":"AdminMSP"}`,
+		`{"mspId":"AdminMSP"}`)
+	if resp.Status != shim.OK {
+		t.Fatalf("Upgrade: %s", resp.Message)
+	}
+
+	run(t, stub, []step{
+		{"非管理员不能迁移", xiaozhang, []string{"Migrate", "2"}, false},
+		{"数量为0", admin, []string{"Migrate", "0"}, false},
+	})
+
+	// 每次处理2个帐户，第二次完成并合并发行总量记录
+	var m Migration
+	for i, want := range []Migration{
+		{LastKey: "xiaowang", Accounts: 2, Holdings: 1},
+		{LastKey: "xiaozhang", Accounts: 3, Holdings: 3, Supplies: 2, Done: true},
+		{LastKey: "xiaozhang", Accounts: 3, Holdings: 3, Supplies: 2, Done: true},
+	} {
+		resp := stub.As(admin).Invoke("Migrate", "2")
+		m = Migration{}
+		if resp.Status != shim.OK || json.Unmarshal(resp.Payload, &m) != nil {
+			t.Fatalf("Migrate #%d: status=%d message=%q", i, resp.Status, resp.Message)
+		}
+		if m.LastKey != want.LastKey || m.Accounts != want.Accounts || m.Holdings != want.Holdings || m.Supplies != want.Supplies || m.Done != want.Done {
+			t.Errorf("Migrate #%d: got %+v, want %+v", i, m, want)
+		}
+
+		// 迁移完成前只能查询及继续迁移
+		if i == 0 {
+			run(t, stub, []step{
+				{"迁移中不能转移", xiaozhang, []string{"Transfer", "xiaozhang", "xiaowang", "AAA", "A1", "10"}, false},
+				{"迁移中不能开户", xiaozhang, []string{"CreateAccount", "xiaozhang2", "100"}, false},
+				{"迁移中可以查询", xiaozhang, []string{"ListAccounts", "10"}, true},
+			})
+		}
+	}
+
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 50, "BBB/B1": 200})
+	checkHoldings(t, stub, "xiaowang", map[string]int64{"AAA/A1": 50})
+	checkHoldings(t, stub, "xiaoli", map[string]int64{})
+	checkBalance(t, stub, "xiaozhang", 0)
+
+	// 发行总量合并到Init创建的资产记录，对账无差异
+	resp = stub.Invoke("AuditSupply")
+	var audit struct {
+		Assets        []SupplyAudit `json:"assets"`
+		Discrepancies []SupplyAudit `json:"discrepancies"`
+	}
+	if resp.Status != shim.OK || json.Unmarshal(resp.Payload, &audit) != nil {
+		t.Fatalf("AuditSupply: status=%d message=%q", resp.Status, resp.Message)
+	}
+	if len(audit.Assets) != 2 || len(audit.Discrepancies) != 0 {
+		t.Errorf("AuditSupply: got %s", resp.Payload)
+	}
+	for _, a := range audit.Assets {
+		if a.Issuer == "AAA" && (a.Issued != 10110 || a.Held != 10) || a.Issuer == "BBB" && (a.Issued != 10300 || a.Redeemed != 100) {
+			t.Errorf("AuditSupply: got %+v", a)
+		}
+	}
+	if stub.State(mustKey(t, stub, assetcore.SupplyObjectType, "AAA", "A1")) != nil {
+		t.Errorf("supply record of AAA/A1 not deleted")
+	}
+
+	// 冻结、归属计划、帐户冻结及KYC在升级后继续生效
+	run(t, stub, []step{
+		{"xiaozhang转移10股A1给xiaowang", xiaozhang, []string{"Transfer", "xiaozhang", "xiaowang", "AAA", "A1", "10"}, true},
+		{"B1全部锁定", xiaozhang, []string{"Transfer", "xiaozhang", "xiaowang", "BBB", "B1", "1"}, false},
+		{"xiaoli已冻结", xiaozhang, []string{"Transfer", "xiaozhang", "xiaoli", "AAA", "A1", "1"}, false},
+		{"A1需要KYC等级2", admin, []string{"SetEligibility", "AAA", "A1", "2"}, true},
+		{"xiaowang已登记KYC", xiaozhang, []string{"Transfer", "xiaozhang", "xiaowang", "AAA", "A1", "5"}, true},
+		{"xiaowang收取冻结", xiaowang, []string{"CaptureHold", "xiaozhang", "escrow"}, true},
+	})
+	checkHoldings(t, stub, "xiaozhang", map[string]int64{"AAA/A1": 35, "BBB/B1": 200})
+	checkHoldings(t, stub, "xiaowang", map[string]int64{"AAA/A1": 75})
+
+	resp = stub.Invoke("VestingStatus", "xiaozhang", "BBB", "B1")
+	var v assetcore.VestingStatus
+	if resp.Status != shim.OK || json.Unmarshal(resp.Payload, &v) != nil || v.Locked != 200 || v.Unlocked != 0 {
+		t.Errorf("VestingStatus: status=%d message=%q payload=%s", resp.Status, resp.Message, resp.Payload)
+	}
+}
+
+// 创建复合键
+func mustKey(t *testing.T, stub *memstub.Stub, objectType string, attributes ...string) string {
+	key, err := stub.CreateCompositeKey(objectType, attributes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+
+	"github.com/ChainNova/samples/chaincode/asset/assetcore"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// Distribution 一次派息记录
+type Distribution struct {
+	DocType string `json:"docType,omitempty"` //文档类型
+	ID      string `json:"id"`                //派息编号（交易ID）
+	Issuer  string `json:"issuer"`            //资产发行机构
+	Code    string `json:"code"`              //资产代码
+	Funding string `json:"funding"`           //出资帐户
+	Total   int64  `json:"total"`             //派息总额
+	Units   int64  `json:"units"`             //登记的持有总量
+	Holders int    `json:"holders"`           //获得派息的帐户数
+	Time    int64  `json:"time"`              //派息时间（秒）
+}
+
+// DividendReceipt 单个帐户的派息回单
+type DividendReceipt struct {
+	DocType      string `json:"docType,omitempty"` //文档类型
+	Distribution string `json:"distribution"`      //派息编号
+	ID           string `json:"id"`                //帐户id
+	Units        int64  `json:"units"`             //登记的持有数量
+	Amount       int64  `json:"amount"`            //派息金额
+	Balance      int64  `json:"balance"`           //派息后的现金余额
+}
+
+// 按持有比例向资产持有者派息，资金从发行机构的资金帐户扣除
+// 每个帐户得到 总额*持有量/持有总量 向下取整，余数按小数部分从大到小逐个分配1，小数部分相同时按帐户id排序
+// 参数：issuer、code、派息总额
+func (c *SimpleChaincode) distributeDividend(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== distributeDividend ==========")
+	if len(args) < 3 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 3")
+	}
+
+	issuer := args[0]
+	code := args[1]
+	total, err := strconv.ParseInt(args[2], 10, 64)
+	if issuer == "" || code == "" || err != nil || total <= 0 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "distribute dividend arguments error: issuer and code can't be nil; total must be a number and greater than 0.")
+	}
+
+	err = assetcore.CheckIssuer(stub, issuer)
+	if err != nil {
+		return assetcore.Fail(assetcore.CodeUnauthorized, "Permission denied:%s", err)
+	}
+	info, _, err := assetcore.CheckIssuerInfo(stub, issuer)
+	if err != nil {
+		return assetcore.Errorf("Check issuer=%s error:%s", issuer, err)
+	} else if info.Account == "" {
+		return assetcore.Fail(assetcore.CodeFailedPrecondition, "Issuer=%s has no funding account.", issuer)
+	}
+	err = assetcore.CheckActive(stub, "", "", info.Account)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+
+	_, _, isExist, _, err := assetcore.CheckAsset(stub, issuer, code)
+	if err != nil {
+		return assetcore.Errorf("Check asset issuer=%s&code=%s error:%s", issuer, code, err)
+	} else if !isExist {
+		return assetcore.Error(assetcore.AssetNotFound(issuer, code))
+	}
+
+	// 登记持有者，持仓key以帐户在前，需要遍历后按issuer、code筛选；出资帐户不参与派息
+	receipts, units, err := c.dividendHolders(stub, issuer, code, info.Account)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	} else if units == 0 {
+		return assetcore.Fail(assetcore.CodeFailedPrecondition, "Asset issuer=%s&code=%s has no holders.", issuer, code)
+	}
+	allocateDividend(receipts, total, units)
+
+	d := Distribution{
+		DocType: DistributionDocType,
+		ID:      stub.GetTxID(),
+		Issuer:  issuer,
+		Code:    code,
+		Funding: info.Account,
+		Total:   total,
+		Units:   units,
+		Holders: len(receipts),
+	}
+	d.Time, err = assetcore.TxTime(stub)
+	if err != nil {
+		return assetcore.Errorf("GetTxTimestamp error:%s", err)
+	}
+
+	cache := newStateCache(c, stub)
+	fromCash, err := cache.addBalance(info.Account, -total)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+	for i := range receipts {
+		receipts[i].Distribution = d.ID
+		receipts[i].Balance, err = cache.addBalance(receipts[i].ID, receipts[i].Amount)
+		if err != nil {
+			return assetcore.Errorf("%s.", err)
+		}
+		key, err := stub.CreateCompositeKey(DividendReceiptObjectType, []string{d.ID, receipts[i].ID})
+		if err == nil {
+			err = assetcore.Save(stub, key, receipts[i])
+		}
+		if err != nil {
+			return assetcore.Errorf("save dividend receipt=%+v error:%s", receipts[i], err)
+		}
+	}
+	err = cache.flush()
+	if err != nil {
+		return assetcore.Error(err)
+	}
+
+	key, err := stub.CreateCompositeKey(DistributionObjectType, []string{issuer, code, d.ID})
+	if err == nil {
+		err = assetcore.Save(stub, key, d)
+	}
+	if err != nil {
+		return assetcore.Errorf("save distribution=%+v error:%s", d, err)
+	}
+
+	err = c.emit(stub, Event{Type: "DistributeDividend", From: info.Account, Issuer: issuer, Code: code, Amount: total, FromCash: fromCash, DistributionID: d.ID})
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+
+	b, err := json.Marshal(d)
+	if err != nil {
+		return assetcore.Errorf("Marshal distribution error:%s", err)
+	}
+	return shim.Success(b)
+}
+
+// 登记资产的所有持有者及持有总量，结果按帐户id排序
+// 持有量包括帐户持有、冻结中、卖单及待交收成交冻结的数量，与AuditSupply的统计口径一致
+func (c *SimpleChaincode) dividendHolders(stub shim.ChaincodeStubInterface, issuer, code, exclude string) ([]DividendReceipt, int64, error) {
+	units := map[string]int64{}
+
+	iterator, err := stub.GetStateByPartialCompositeKey(AccountAssetObjectType, []string{})
+	if err != nil {
+		return nil, 0, fmt.Errorf("GetStateByPartialCompositeKey error:%s", err)
+	}
+	defer iterator.Close()
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, 0, fmt.Errorf("Iterate holdings error:%s", err)
+		}
+		_, parts, err := stub.SplitCompositeKey(kv.Key)
+		if err != nil || len(parts) < 3 || parts[1] != issuer || parts[2] != code {
+			continue
+		}
+		count, err := assetcore.ParseHolding(kv.Value)
+		if err != nil {
+			fmt.Println("parseHolding error:", err, string(kv.Value))
+			continue
+		}
+		units[parts[0]] += count
+	}
+
+	holdsIterator, err := stub.GetStateByPartialCompositeKey(assetcore.HoldObjectType, []string{})
+	if err != nil {
+		return nil, 0, fmt.Errorf("GetStateByPartialCompositeKey error:%s", err)
+	}
+	defer holdsIterator.Close()
+	for holdsIterator.HasNext() {
+		kv, err := holdsIterator.Next()
+		if err != nil {
+			return nil, 0, fmt.Errorf("Iterate holds error:%s", err)
+		}
+		var h assetcore.Hold
+		err = json.Unmarshal(kv.Value, &h)
+		if err != nil {
+			return nil, 0, fmt.Errorf("Unmarshal hold=%s error:%s", string(kv.Value), err)
+		}
+		if h.Issuer == issuer && h.Code == code {
+			units[h.Account] += h.Amount
+		}
+	}
+
+	// 卖单按issuer、code、方向在订单簿中连续排列
+	ordersIterator, err := stub.GetStateByPartialCompositeKey(OrderBookObjectType, []string{issuer, code, OrderSell})
+	if err != nil {
+		return nil, 0, fmt.Errorf("GetStateByPartialCompositeKey error:%s", err)
+	}
+	defer ordersIterator.Close()
+	for ordersIterator.HasNext() {
+		kv, err := ordersIterator.Next()
+		if err != nil {
+			return nil, 0, fmt.Errorf("Iterate orders error:%s", err)
+		}
+		var o Order
+		err = json.Unmarshal(kv.Value, &o)
+		if err != nil {
+			return nil, 0, fmt.Errorf("Unmarshal order=%s error:%s", string(kv.Value), err)
+		}
+		units[o.Account] += o.Remaining
+	}
+
+	tradesIterator, err := stub.GetStateByPartialCompositeKey(TradeObjectType, []string{})
+	if err != nil {
+		return nil, 0, fmt.Errorf("GetStateByPartialCompositeKey error:%s", err)
+	}
+	defer tradesIterator.Close()
+	for tradesIterator.HasNext() {
+		kv, err := tradesIterator.Next()
+		if err != nil {
+			return nil, 0, fmt.Errorf("Iterate trades error:%s", err)
+		}
+		var t Trade
+		err = json.Unmarshal(kv.Value, &t)
+		if err != nil {
+			return nil, 0, fmt.Errorf("Unmarshal trade=%s error:%s", string(kv.Value), err)
+		}
+		if t.Status == TradePending && t.SellerConfirmed && t.Issuer == issuer && t.Code == code {
+			units[t.Seller] += t.Count
+		}
+	}
+
+	receipts := []DividendReceipt{}
+	total := int64(0)
+	for id, count := range units {
+		if id == exclude || count <= 0 {
+			continue
+		}
+		receipts = append(receipts, DividendReceipt{DocType: DividendReceiptDocType, ID: id, Units: count})
+		total += count
+	}
+	sort.Slice(receipts, func(i, j int) bool { return receipts[i].ID < receipts[j].ID })
+	return receipts, total, nil
+}
+
+// 按持有比例分配派息总额，余数按最大余数法分配
+func allocateDividend(receipts []DividendReceipt, total, units int64) {
+	remainders := make([]*big.Int, len(receipts))
+	allocated := int64(0)
+	for i := range receipts {
+		q, r := new(big.Int).QuoRem(new(big.Int).Mul(big.NewInt(total), big.NewInt(receipts[i].Units)), big.NewInt(units), new(big.Int))
+		receipts[i].Amount = q.Int64()
+		remainders[i] = r
+		allocated += receipts[i].Amount
+	}
+
+	order := make([]int, len(receipts))
+	for i := range order {
+		order[i] = i
+	}
+	// receipts已按帐户id排序，稳定排序保证余数相同时按帐户id分配
+	sort.SliceStable(order, func(i, j int) bool { return remainders[order[i]].Cmp(remainders[order[j]]) > 0 })
+	for i := 0; int64(i) < total-allocated; i++ {
+		receipts[order[i]].Amount++
+	}
+}
+
+// 分页列出资产的历次派息
+// 参数：issuer、code、每页数量、书签（可选）
+func (c *SimpleChaincode) listDistributions(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== listDistributions ==========")
+	if len(args) < 3 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 3")
+	}
+
+	issuer := args[0]
+	code := args[1]
+	if issuer == "" || code == "" {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "list distributions arguments error: issuer and code can't be nil.")
+	}
+	pageSize, bookmark, err := c.parsePage(args[2:])
+	if err != nil {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "list distributions arguments error: %s.", err)
+	}
+
+	iterator, metadata, err := stub.GetStateByPartialCompositeKeyWithPagination(DistributionObjectType, []string{issuer, code}, pageSize, bookmark)
+	if err != nil {
+		return assetcore.Errorf("GetStateByPartialCompositeKeyWithPagination error:%s", err)
+	}
+	defer iterator.Close()
+
+	distributions := []Distribution{}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return assetcore.Errorf("Iterate distributions error:%s", err)
+		}
+		var d Distribution
+		err = json.Unmarshal(kv.Value, &d)
+		if err != nil {
+			fmt.Println("json.Unmarshal error:", err, string(kv.Value))
+			continue
+		}
+		distributions = append(distributions, d)
+	}
+
+	return c.pageResponse(distributions, metadata)
+}
+
+// 分页列出一次派息的回单
+// 参数：派息编号、每页数量、书签（可选）
+func (c *SimpleChaincode) listDividendReceipts(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== listDividendReceipts ==========")
+	if len(args) < 2 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 2")
+	}
+
+	id := args[0]
+	if id == "" {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "list dividend receipts arguments error: distribution id can't be nil.")
+	}
+	pageSize, bookmark, err := c.parsePage(args[1:])
+	if err != nil {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "list dividend receipts arguments error: %s.", err)
+	}
+
+	iterator, metadata, err := stub.GetStateByPartialCompositeKeyWithPagination(DividendReceiptObjectType, []string{id}, pageSize, bookmark)
+	if err != nil {
+		return assetcore.Errorf("GetStateByPartialCompositeKeyWithPagination error:%s", err)
+	}
+	defer iterator.Close()
+
+	receipts := []DividendReceipt{}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return assetcore.Errorf("Iterate dividend receipts error:%s", err)
+		}
+		var r DividendReceipt
+		err = json.Unmarshal(kv.Value, &r)
+		if err != nil {
+			fmt.Println("json.Unmarshal error:", err, string(kv.Value))
+			continue
+		}
+		receipts = append(receipts, r)
+	}
+
+	return c.pageResponse(receipts, metadata)
+}
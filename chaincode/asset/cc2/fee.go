@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/ChainNova/samples/chaincode/asset/assetcore"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// 设置资产的转移手续费，只有管理员可以操作
+// 参数：issuer、code、收费方式（flat或bps）、费率、收费帐户，费率为0时取消收费
+func (c *SimpleChaincode) setTransferFee(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== setTransferFee ==========")
+	if len(args) < 4 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 4")
+	}
+
+	f := assetcore.FeeSchedule{
+		Issuer: args[0],
+		Code:   args[1],
+		Mode:   args[2],
+	}
+	rate, err := strconv.ParseInt(args[3], 10, 64)
+	if f.Issuer == "" || f.Code == "" || err != nil || rate < 0 || (f.Mode != assetcore.FeeModeFlat && f.Mode != assetcore.FeeModeBps) || (f.Mode == assetcore.FeeModeBps && rate > 10000) {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "set transfer fee arguments error: issuer and code can't be nil; mode must be flat or bps; rate must be a number and not less than 0, bps rate can't be greater than 10000.")
+	}
+	f.Rate = rate
+	if len(args) > 4 {
+		f.Collector = args[4]
+	}
+
+	err = assetcore.SetTransferFee(stub, f)
+	if err != nil {
+		return assetcore.Error(err)
+	}
+
+	err = c.emit(stub, Event{Type: "SetTransferFee", Issuer: f.Issuer, Code: f.Code, Fee: f.Rate, Collector: f.Collector})
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+
+	return shim.Success(nil)
+}
+
+// 查询资产的转移手续费设置，未设置时rate为0
+// 参数：issuer、code
+func (c *SimpleChaincode) getTransferFee(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== getTransferFee ==========")
+	if len(args) < 2 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 2")
+	}
+	if args[0] == "" || args[1] == "" {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "transfer fee arguments error: issuer and code can't be nil.")
+	}
+
+	f, err := assetcore.CheckTransferFee(stub, args[0], args[1])
+	if err != nil {
+		return assetcore.Errorf("Check transfer fee of issuer=%s&code=%s error:%s", args[0], args[1], err)
+	}
+
+	b, err := json.Marshal(f)
+	if err != nil {
+		return assetcore.Errorf("Marshal transfer fee error:%s", err)
+	}
+	return shim.Success(b)
+}
+
+// 返回转移结果
+func (c *SimpleChaincode) transferResponse(r TransferResult) pb.Response {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return assetcore.Errorf("Marshal transfer result error:%s", err)
+	}
+	return shim.Success(b)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/ChainNova/samples/chaincode/asset/assetcore"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// 冻结/解冻帐户，只有管理员可以操作
+// 参数：帐户、原因
+func (c *SimpleChaincode) freezeAccount(stub shim.ChaincodeStubInterface, args []string, active bool) pb.Response {
+	fmt.Println("=========== freezeAccount ==========")
+	if len(args) < 2 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 2")
+	}
+
+	id := args[0]
+	reason := args[1]
+	if id == "" || reason == "" {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "freeze account arguments error: id and reason can't be nil.")
+	}
+
+	_, _, isExist, err := assetcore.CheckCashAccount(stub, id)
+	if err != nil {
+		return assetcore.Errorf("Check account=%s error:%s", id, err)
+	} else if !isExist {
+		return assetcore.Error(assetcore.AccountNotFound(id))
+	}
+
+	err = assetcore.SetRestriction(stub, assetcore.FreezeObjectType, []string{id}, active, reason)
+	if err != nil {
+		return assetcore.Errorf("Freeze account=%s error:%s", id, err)
+	}
+
+	ev := Event{Type: "FreezeAccount", To: id, Reason: reason}
+	if !active {
+		ev.Type = "UnfreezeAccount"
+	}
+	err = c.emit(stub, ev)
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+	return shim.Success(nil)
+}
+
+// 停牌/复牌资产，只有管理员可以操作
+// 参数：issuer、code、原因
+func (c *SimpleChaincode) haltAsset(stub shim.ChaincodeStubInterface, args []string, active bool) pb.Response {
+	fmt.Println("=========== haltAsset ==========")
+	if len(args) < 3 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 3")
+	}
+
+	issuer := args[0]
+	code := args[1]
+	reason := args[2]
+	if issuer == "" || code == "" || reason == "" {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "halt asset arguments error: issuer, code and reason can't be nil.")
+	}
+
+	_, _, isExist, _, err := assetcore.CheckAsset(stub, issuer, code)
+	if err != nil {
+		return assetcore.Errorf("Check asset issuer=%s&code=%s error:%s", issuer, code, err)
+	} else if !isExist {
+		return assetcore.Error(assetcore.AssetNotFound(issuer, code))
+	}
+
+	err = assetcore.SetRestriction(stub, assetcore.HaltObjectType, []string{issuer, code}, active, reason)
+	if err != nil {
+		return assetcore.Errorf("Halt asset issuer=%s&code=%s error:%s", issuer, code, err)
+	}
+
+	ev := Event{Type: "HaltAsset", Issuer: issuer, Code: code, Reason: reason}
+	if !active {
+		ev.Type = "ResumeAsset"
+	}
+	err = c.emit(stub, ev)
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+	return shim.Success(nil)
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"github.com/ChainNova/samples/chaincode/asset/assetcore"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// 可调用的函数及参数，参数按位置传入
+func (c *SimpleChaincode) functions() []assetcore.Function {
+	var (
+		Str  = assetcore.Str
+		Int  = assetcore.Int
+		JSON = assetcore.JSON
+	)
+	page := []assetcore.Arg{
+		Int("pageSize", "每页数量").Range(1, 1<<31-1),
+		Str("bookmark", "书签，第一页为空").AllowEmpty().Opt(),
+	}
+	withPage := func(args ...assetcore.Arg) []assetcore.Arg {
+		return append(args, page...)
+	}
+
+	return []assetcore.Function{
+		{Name: "CreateAccount", Desc: "创建帐户", Handler: c.createAccount, Args: []assetcore.Arg{
+			Str("id", "帐户id"),
+			Int("balance", "余额").AtLeast(1),
+		}},
+		{Name: "CreateAsset", Desc: "发行机构发行资产", Handler: c.createAsset, Args: []assetcore.Arg{
+			Str("issuer", "发行机构"),
+			Str("code", "资产代码"),
+			Int("amount", "发行数量").AtLeast(1),
+		}},
+		{Name: "Buy", Desc: "以帐户余额购买资产", Handler: c.buy, Args: []assetcore.Arg{
+			Str("id", "帐户id"),
+			Str("issuer", "发行机构"),
+			Str("code", "资产代码"),
+			Int("count", "购买数量").AtLeast(1),
+		}},
+		{Name: "Transfer", Desc: "转移资产", Handler: c.transfer, Args: []assetcore.Arg{
+			Str("from", "转出帐户id"),
+			Str("to", "转移目的帐户id"),
+			Str("issuer", "发行机构"),
+			Str("code", "资产代码"),
+			Int("count", "转移数量").AtLeast(1),
+		}},
+		{Name: "Approve", Desc: "授权代理帐户转移资产的额度", Handler: c.approve, Args: []assetcore.Arg{
+			Str("owner", "资产所有者帐户id"),
+			Str("spender", "代理帐户id"),
+			Str("issuer", "发行机构"),
+			Str("code", "资产代码"),
+			Int("amount", "额度").AtLeast(0),
+		}},
+		{Name: "Allowance", Desc: "查询代理帐户的剩余额度", Query: true, Handler: c.allowance, Args: []assetcore.Arg{
+			Str("owner", "资产所有者帐户id"),
+			Str("spender", "代理帐户id"),
+			Str("issuer", "发行机构"),
+			Str("code", "资产代码"),
+		}},
+		{Name: "TransferFrom", Desc: "代理帐户在额度内转移资产", Handler: c.transferFrom, Args: []assetcore.Arg{
+			Str("spender", "代理帐户id"),
+			Str("from", "转出帐户id"),
+			Str("to", "转移目的帐户id"),
+			Str("issuer", "发行机构"),
+			Str("code", "资产代码"),
+			Int("count", "转移数量").AtLeast(1),
+		}},
+		{Name: "SetApprovalPolicy", Desc: "设置资产的多方审批策略", Handler: c.setApprovalPolicy, Args: []assetcore.Arg{
+			Str("issuer", "发行机构"),
+			Str("code", "资产代码"),
+			Int("threshold", "需要审批的转移数量阈值"),
+			Int("quorum", "执行所需的审批数").AtLeast(0),
+			Int("duration", "待审批转移的有效时长（秒），与signers同时设置").Opt(),
+			JSON("signers", `签名人列表，如[{"mspId":"","cn":""}]`).Opt(),
+		}},
+		{Name: "ApproveTransfer", Desc: "审批待审批转移", Handler: c.approveTransfer, Args: []assetcore.Arg{
+			Str("id", "待审批转移编号"),
+		}},
+		{Name: "CancelTransfer", Desc: "取消待审批转移", Handler: c.cancelTransfer, Args: []assetcore.Arg{
+			Str("id", "待审批转移编号"),
+		}},
+		{Name: "PendingTransfer", Desc: "查询待审批转移", Query: true, Handler: c.getPendingTransfer, Args: []assetcore.Arg{
+			Str("id", "待审批转移编号"),
+		}},
+		{Name: "GrantVested", Desc: "发行按时间解锁的资产", Handler: c.grantVested, Args: []assetcore.Arg{
+			Str("id", "帐户id"),
+			Str("issuer", "发行机构"),
+			Str("code", "资产代码"),
+			Int("count", "授予数量").AtLeast(1),
+			Int("cliff", "锁定期（秒）").AtLeast(0),
+			Int("duration", "归属总时长（秒）").AtLeast(1),
+			Int("start", "开始时间（秒），默认为交易时间").Opt(),
+		}},
+		{Name: "VestingStatus", Desc: "查询资产解锁进度", Query: true, Handler: c.vestingStatus, Args: []assetcore.Arg{
+			Str("id", "帐户id"),
+			Str("issuer", "发行机构"),
+			Str("code", "资产代码"),
+		}},
+		{Name: "SplitAsset", Desc: "按比例拆分或合并资产", Handler: c.splitAsset, Args: []assetcore.Arg{
+			Str("issuer", "发行机构"),
+			Str("code", "资产代码"),
+			Int("numerator", "拆分比例分子").AtLeast(1),
+			Int("denominator", "拆分比例分母").AtLeast(1),
+		}},
+		{Name: "SetTransferFee", Desc: "设置资产的转移手续费", Handler: c.setTransferFee, Args: []assetcore.Arg{
+			Str("issuer", "发行机构"),
+			Str("code", "资产代码"),
+			Str("mode", "收费方式：flat或bps"),
+			Int("rate", "flat为每笔数量，bps为万分比").AtLeast(0),
+			Str("collector", "收费帐户").Opt(),
+		}},
+		{Name: "TransferFee", Desc: "查询资产的转移手续费", Query: true, Handler: c.getTransferFee, Args: []assetcore.Arg{
+			Str("issuer", "发行机构"),
+			Str("code", "资产代码"),
+		}},
+		{Name: "DistributeDividend", Desc: "按持有比例向持有人派发分红", Handler: c.distributeDividend, Args: []assetcore.Arg{
+			Str("issuer", "发行机构"),
+			Str("code", "资产代码"),
+			Int("total", "分红总额").AtLeast(1),
+		}},
+		{Name: "ListDistributions", Desc: "分页列出资产的分红记录", Query: true, Handler: c.listDistributions, Args: withPage(
+			Str("issuer", "发行机构"),
+			Str("code", "资产代码"),
+		)},
+		{Name: "ListDividendReceipts", Desc: "分页列出一次分红的到帐明细", Query: true, Handler: c.listDividendReceipts, Args: withPage(
+			Str("id", "分红编号"),
+		)},
+		{Name: "SetKYC", Desc: "登记帐户的KYC认证", Handler: c.setKYC, Args: []assetcore.Arg{
+			Str("id", "帐户id"),
+			Str("status", "认证状态：verified、suspended或revoked"),
+			Int("tier", "认证等级").AtLeast(0),
+			Int("expiry", "到期时间（秒），0为不过期").AtLeast(0).Opt(),
+		}},
+		{Name: "KYC", Desc: "查询帐户的KYC认证", Query: true, Handler: c.getKYC, Args: []assetcore.Arg{
+			Str("id", "帐户id"),
+		}},
+		{Name: "SetEligibility", Desc: "设置开户或资产持有所需的最低认证等级", Handler: c.setEligibility, Args: []assetcore.Arg{
+			Str("issuer", "发行机构，与code同时为空时为开户资格").AllowEmpty(),
+			Str("code", "资产代码").AllowEmpty(),
+			Int("minTier", "最低认证等级").AtLeast(0),
+		}},
+		{Name: "BatchTransfer", Desc: "批量转移资产，全部成功或全部失败", Handler: c.batchTransfer, Args: []assetcore.Arg{
+			JSON("legs", `[{"from":"","to":"","issuer":"","code":"","amount":0}]`, "from", "to", "issuer", "code"),
+		}},
+		{Name: "SettleTrade", Desc: "券款对付结算", Handler: c.settleTrade, Args: []assetcore.Arg{
+			Str("id", "成交编号"),
+			Str("seller", "卖方帐户id"),
+			Str("buyer", "买方帐户id"),
+			Str("issuer", "发行机构"),
+			Str("code", "资产代码"),
+			Int("count", "成交数量").AtLeast(1),
+			Int("price", "成交价格").AtLeast(1),
+			Int("duration", "等待对手方确认的有效时长（秒），默认一天").AtLeast(1).Opt(),
+		}},
+		{Name: "CancelTrade", Desc: "撤销未交收的成交，退回冻结的资产和现金", Handler: c.cancelTrade, Args: []assetcore.Arg{
+			Str("id", "成交编号"),
+		}},
+		{Name: "PlaceOrder", Desc: "挂单，与对手方订单撮合成交", Handler: c.placeOrder, Args: []assetcore.Arg{
+			Str("account", "帐户id"),
+			Str("issuer", "发行机构"),
+			Str("code", "资产代码"),
+			Str("side", "买卖方向：buy或sell"),
+			Int("price", "价格").AtLeast(1),
+			Int("count", "数量").AtLeast(1),
+		}},
+		{Name: "CancelOrder", Desc: "撤单", Handler: c.cancelOrder, Args: []assetcore.Arg{
+			Str("id", "订单编号"),
+		}},
+		{Name: "GetOrderBook", Desc: "查询资产的订单簿", Query: true, Handler: c.getOrderBook, Args: []assetcore.Arg{
+			Str("issuer", "发行机构"),
+			Str("code", "资产代码"),
+		}},
+		{Name: "Hold", Desc: "冻结帐户的部分资产", Handler: c.hold, Args: []assetcore.Arg{
+			Str("account", "被冻结帐户id"),
+			Str("name", "冻结名称"),
+			Str("to", "受益帐户id"),
+			Str("issuer", "发行机构"),
+			Str("code", "资产代码"),
+			Int("amount", "冻结数量").AtLeast(1),
+			Int("duration", "有效时长（秒）").AtLeast(1),
+		}},
+		{Name: "ReleaseHold", Desc: "释放冻结的资产", Handler: c.releaseHold, Args: []assetcore.Arg{
+			Str("account", "被冻结帐户id"),
+			Str("name", "冻结名称"),
+		}},
+		{Name: "CaptureHold", Desc: "将冻结的资产转给受益帐户", Handler: c.captureHold, Args: []assetcore.Arg{
+			Str("account", "被冻结帐户id"),
+			Str("name", "冻结名称"),
+			Int("amount", "收取数量，默认收取全部").AtLeast(1).Opt(),
+		}},
+		{Name: "FreezeAccount", Desc: "冻结帐户", Handler: c.restrictAccount(true), Args: []assetcore.Arg{
+			Str("id", "帐户id"),
+			Str("reason", "原因"),
+		}},
+		{Name: "UnfreezeAccount", Desc: "解冻帐户", Handler: c.restrictAccount(false), Args: []assetcore.Arg{
+			Str("id", "帐户id"),
+			Str("reason", "原因"),
+		}},
+		{Name: "HaltAsset", Desc: "资产停牌", Handler: c.restrictAsset(true), Args: []assetcore.Arg{
+			Str("issuer", "发行机构"),
+			Str("code", "资产代码"),
+			Str("reason", "原因"),
+		}},
+		{Name: "ResumeAsset", Desc: "资产复牌", Handler: c.restrictAsset(false), Args: []assetcore.Arg{
+			Str("issuer", "发行机构"),
+			Str("code", "资产代码"),
+			Str("reason", "原因"),
+		}},
+		{Name: "SetRedemptionPrice", Desc: "设置资产的赎回价格", Handler: c.setRedemptionPrice, Args: []assetcore.Arg{
+			Str("issuer", "发行机构"),
+			Str("code", "资产代码"),
+			Int("price", "赎回价格").AtLeast(0),
+		}},
+		{Name: "Redeem", Desc: "向发行机构赎回资产", Handler: c.redeem, Args: []assetcore.Arg{
+			Str("id", "帐户id"),
+			Str("issuer", "发行机构"),
+			Str("code", "资产代码"),
+			Int("count", "赎回数量").AtLeast(1),
+		}},
+		{Name: "Migrate", Desc: "将cc1帐户中的持有量迁移到复合键，反复调用直到done为true", Handler: c.migrate, Args: []assetcore.Arg{
+			Int("pageSize", "每次处理的帐户数").Range(1, 1<<31-1),
+		}},
+		{Name: "AuditSupply", Desc: "核对各资产的发行量与帐户持有量", Query: true, Handler: c.auditSupply},
+		{Name: "ListAccounts", Desc: "分页列出帐户", Query: true, Handler: c.listAccounts, Args: withPage()},
+		{Name: "ListHoldings", Desc: "分页列出帐户持有的资产", Query: true, Handler: c.listHoldings, Args: withPage(
+			Str("id", "帐户id"),
+		)},
+		{Name: "ListIssuerAssets", Desc: "分页列出发行机构的资产", Query: true, Handler: c.listIssuerAssets, Args: withPage(
+			Str("issuer", "发行机构"),
+		)},
+		{Name: "QueryAssets", Desc: "按CouchDB选择器分页查询资产", Query: true, Handler: c.queryAssets, Args: withPage(
+			JSON("selector", `CouchDB选择器，如{"issuer":"AAA"}`),
+		)},
+		{Name: "AccountInfo", Desc: "查询帐户", Query: true, Handler: c.accountInfo, Args: []assetcore.Arg{
+			Str("id", "帐户id"),
+		}},
+		{Name: "AssetInfo", Desc: "查询资产", Query: true, Handler: c.assetInfo, Args: []assetcore.Arg{
+			Str("issuer", "发行机构"),
+			Str("code", "资产代码"),
+		}},
+		{Name: "MyAssets", Desc: "查询帐户持有的资产", Query: true, Handler: c.myAssets, Args: []assetcore.Arg{
+			Str("id", "帐户id"),
+		}},
+		{Name: "IssuerAssets", Desc: "查询发行机构的资产", Query: true, Handler: c.issuerAssets, Args: []assetcore.Arg{
+			Str("issuer", "发行机构"),
+		}},
+		{Name: "AccountHistory", Desc: "查询帐户或帐户持有某资产的历史版本", Query: true, Handler: c.accountHistory, Args: []assetcore.Arg{
+			Str("id", "帐户id"),
+			Str("issuer", "发行机构，与code同时设置").Opt(),
+			Str("code", "资产代码").Opt(),
+		}},
+	}
+}
+
+// 冻结或解冻帐户
+func (c *SimpleChaincode) restrictAccount(freeze bool) assetcore.Handler {
+	return func(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+		return c.freezeAccount(stub, args, freeze)
+	}
+}
+
+// 资产停牌或复牌
+func (c *SimpleChaincode) restrictAsset(halt bool) assetcore.Handler {
+	return func(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+		return c.haltAsset(stub, args, halt)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ChainNova/samples/chaincode/asset/assetcore"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// Modification 一个key的历史版本
+type Modification struct {
+	TxID      string      `json:"txId"`      //交易ID
+	Timestamp time.Time   `json:"timestamp"` //交易时间
+	IsDelete  bool        `json:"isDelete"`  //是否为删除操作
+	Value     interface{} `json:"value"`     //该版本的值（Account或持有数量）
+}
+
+// HoldingHistory 账户某类资产持有量的历史
+type HoldingHistory struct {
+	Issuer  string         `json:"issuer"`  //资产发行机构
+	Code    string         `json:"code"`    //资产代码
+	History []Modification `json:"history"` //历史版本
+}
+
+func (c *SimpleChaincode) accountHistory(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== accountHistory ==========")
+	if len(args) < 1 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 1")
+	}
+
+	id := args[0]
+	if id == "" {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "account history arguments error: id can't be nil.")
+	}
+	// 可选参数：issuer、code，只查询该资产的持有历史
+	keys := []string{id}
+	if len(args) >= 3 {
+		keys = append(keys, args[1], args[2])
+	}
+
+	accountHistory := struct {
+		ID       string           `json:"id"`
+		Account  []Modification   `json:"account"`
+		Holdings []HoldingHistory `json:"holdings"`
+	}{ID: id, Holdings: []HoldingHistory{}}
+
+	var err error
+	accountHistory.Account, err = c.keyHistory(stub, id, func(b []byte) (interface{}, error) {
+		var a Account
+		err := json.Unmarshal(b, &a)
+		return a, err
+	})
+	if err != nil {
+		return assetcore.Errorf("Get history of account=%s error:%s", id, err)
+	}
+
+	assetsIterator, err := stub.GetStateByPartialCompositeKey(AccountAssetObjectType, keys)
+	if err != nil {
+		return assetcore.Errorf("GetStateByPartialCompositeKey error:%s", err)
+	}
+	defer assetsIterator.Close()
+
+	for assetsIterator.HasNext() {
+		kv, err := assetsIterator.Next()
+		if err != nil {
+			return assetcore.Errorf("Iterate assets of account=%s error:%s", id, err)
+		}
+
+		_, compositeKeyParts, err := stub.SplitCompositeKey(kv.Key)
+		if err != nil {
+			fmt.Println("SplitCompositeKey error:", err)
+			continue
+		}
+
+		history, err := c.keyHistory(stub, kv.Key, func(b []byte) (interface{}, error) {
+			return assetcore.ParseHolding(b)
+		})
+		if err != nil {
+			return assetcore.Errorf("Get history of account=%s, asset issuer=%s&code=%s error:%s", id, compositeKeyParts[1], compositeKeyParts[2], err)
+		}
+
+		accountHistory.Holdings = append(accountHistory.Holdings, HoldingHistory{
+			Issuer:  compositeKeyParts[1],
+			Code:    compositeKeyParts[2],
+			History: history,
+		})
+	}
+
+	b, err := json.Marshal(accountHistory)
+	if err != nil {
+		return assetcore.Errorf("Marshal accountHistory error:%s", err)
+	}
+	return shim.Success(b)
+}
+
+// 遍历key的所有历史版本，并用decode解析每个版本的值
+func (c *SimpleChaincode) keyHistory(stub shim.ChaincodeStubInterface, key string, decode func([]byte) (interface{}, error)) ([]Modification, error) {
+	historyIterator, err := stub.GetHistoryForKey(key)
+	if err != nil {
+		return nil, err
+	}
+	defer historyIterator.Close()
+
+	history := []Modification{}
+	for historyIterator.HasNext() {
+		km, err := historyIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		m := Modification{
+			TxID:     km.TxId,
+			IsDelete: km.IsDelete,
+		}
+		if km.Timestamp != nil {
+			m.Timestamp, _ = ptypes.Timestamp(km.Timestamp)
+		}
+		if !km.IsDelete && len(km.Value) > 0 {
+			m.Value, err = decode(km.Value)
+			if err != nil {
+				fmt.Println("decode history value error:", err, string(km.Value))
+			}
+		}
+		history = append(history, m)
+	}
+
+	return history, nil
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/ChainNova/samples/chaincode/asset/assetcore"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// 冻结资产
+// 参数：帐户、冻结名称、受益帐户、issuer、code、数量、有效时长（秒）
+func (c *SimpleChaincode) hold(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== hold ==========")
+	if len(args) < 7 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 7")
+	}
+
+	h := assetcore.Hold{
+		DocType: assetcore.HoldDocType,
+		Account: args[0],
+		Name:    args[1],
+		To:      args[2],
+		Issuer:  args[3],
+		Code:    args[4],
+	}
+	amount, err1 := strconv.ParseInt(args[5], 10, 64)
+	duration, err2 := strconv.ParseInt(args[6], 10, 64)
+	if h.Account == "" || h.Name == "" || h.To == "" || h.Issuer == "" || h.Code == "" || err1 != nil || err2 != nil || amount <= 0 || duration <= 0 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "hold arguments error: account, name, to, issuer and code can't be nil; amount and duration must be numbers and greater than 0.")
+	}
+	if h.Account == h.To {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Account=%s can't hold for itself.", h.Account)
+	}
+	h.Amount = amount
+
+	cache := newStateCache(c, stub)
+	account, err := cache.getAccount(h.Account)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+	err = assetcore.CheckOwner(stub, account.Owner, account.ID)
+	if err != nil {
+		return assetcore.Fail(assetcore.CodeUnauthorized, "Permission denied:%s", err)
+	}
+	_, err = cache.getAccount(h.To)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+	err = assetcore.CheckActive(stub, h.Issuer, h.Code, h.Account, h.To)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+	// 接收帐户必须有资格持有该资产
+	err = assetcore.CheckEligible(stub, h.Issuer, h.Code, h.To)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+	// 冻结的资产可以被受益帐户收取，超过审批阈值的数量只能通过Transfer发起审批
+	_, required, err := assetcore.CheckApproval(stub, h.Issuer, h.Code, h.Amount)
+	if err != nil {
+		return assetcore.Errorf("Check approval policy of issuer=%s&code=%s error:%s", h.Issuer, h.Code, err)
+	} else if required {
+		return assetcore.Fail(assetcore.CodeFailedPrecondition, "Amount=%v of issuer=%s&code=%s requires approval, use Transfer.", h.Amount, h.Issuer, h.Code)
+	}
+
+	_, isExist, key, err := assetcore.CheckHold(stub, h.Account, h.Name)
+	if err != nil {
+		return assetcore.Errorf("Check hold=%s of account=%s error:%s", h.Name, h.Account, err)
+	} else if isExist {
+		return assetcore.Fail(assetcore.CodeAlreadyExists, "Hold=%s of account=%s already exists.", h.Name, h.Account)
+	}
+
+	now, err := assetcore.TxTime(stub)
+	if err != nil {
+		return assetcore.Errorf("GetTxTimestamp error:%s", err)
+	}
+	h.Expiry = now + duration
+
+	balance, err := cache.addHolding(h.Account, h.Issuer, h.Code, -h.Amount)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+	err = cache.flush()
+	if err != nil {
+		return assetcore.Error(err)
+	}
+	err = assetcore.Save(stub, key, h)
+	if err != nil {
+		return assetcore.Errorf("save hold=%+v error:%s", h, err)
+	}
+
+	err = c.emit(stub, Event{Type: "Hold", From: h.Account, To: h.To, Issuer: h.Issuer, Code: h.Code, Amount: h.Amount, FromBalance: balance})
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+	return shim.Success(nil)
+}
+
+// 释放冻结，冻结资产退回原账户
+// 受益帐户可随时释放，被冻结账户的所有者只能在到期后收回
+// 参数：帐户、冻结名称
+func (c *SimpleChaincode) releaseHold(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== releaseHold ==========")
+	if len(args) < 2 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 2")
+	}
+
+	cache := newStateCache(c, stub)
+	h, key, now, err := c.loadHold(stub, cache, args[0], args[1])
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+
+	to, _ := cache.getAccount(h.To)
+	if assetcore.CheckOwner(stub, to.Owner, to.ID) != nil {
+		account, _ := cache.getAccount(h.Account)
+		err = assetcore.CheckOwner(stub, account.Owner, account.ID)
+		if err != nil {
+			return assetcore.Fail(assetcore.CodeUnauthorized, "Permission denied:%s", err)
+		}
+		if now < h.Expiry {
+			return assetcore.Fail(assetcore.CodeFailedPrecondition, "Hold=%s of account=%s not expired until %v.", h.Name, h.Account, h.Expiry)
+		}
+	}
+
+	balance, err := cache.addHolding(h.Account, h.Issuer, h.Code, h.Amount)
+	if err == nil {
+		err = cache.flush()
+	}
+	if err != nil {
+		return assetcore.Error(err)
+	}
+	err = stub.DelState(key)
+	if err != nil {
+		return assetcore.Errorf("DelState error:%s", err)
+	}
+
+	err = c.emit(stub, Event{Type: "ReleaseHold", To: h.Account, Issuer: h.Issuer, Code: h.Code, Amount: h.Amount, ToBalance: balance})
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+	return shim.Success(nil)
+}
+
+// 收取冻结，冻结资产转入受益帐户，未收取的部分退回原账户
+// 只有受益帐户的所有者能在到期前收取，手续费由被冻结帐户另外支付
+// 参数：帐户、冻结名称、收取数量（可选，默认全部）
+func (c *SimpleChaincode) captureHold(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== captureHold ==========")
+	if len(args) < 2 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 2")
+	}
+
+	cache := newStateCache(c, stub)
+	h, key, now, err := c.loadHold(stub, cache, args[0], args[1])
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+
+	amount := h.Amount
+	if len(args) > 2 {
+		amount, err = strconv.ParseInt(args[2], 10, 64)
+		if err != nil || amount <= 0 || amount > h.Amount {
+			return assetcore.Fail(assetcore.CodeInvalidArgument, "capture hold arguments error: amount must be a number, greater than 0 and not greater than %v.", h.Amount)
+		}
+	}
+
+	to, _ := cache.getAccount(h.To)
+	err = assetcore.CheckOwner(stub, to.Owner, to.ID)
+	if err != nil {
+		return assetcore.Fail(assetcore.CodeUnauthorized, "Permission denied:%s", err)
+	}
+	err = assetcore.CheckActive(stub, h.Issuer, h.Code, h.Account, h.To)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+	// 接收帐户必须有资格持有该资产
+	err = assetcore.CheckEligible(stub, h.Issuer, h.Code, h.To)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+	if now >= h.Expiry {
+		return assetcore.Fail(assetcore.CodeFailedPrecondition, "Hold=%s of account=%s expired at %v.", h.Name, h.Account, h.Expiry)
+	}
+	// 冻结后设置的审批策略同样适用于收取
+	_, required, err := assetcore.CheckApproval(stub, h.Issuer, h.Code, amount)
+	if err != nil {
+		return assetcore.Errorf("Check approval policy of issuer=%s&code=%s error:%s", h.Issuer, h.Code, err)
+	} else if required {
+		return assetcore.Fail(assetcore.CodeFailedPrecondition, "Amount=%v of issuer=%s&code=%s requires approval, use Transfer.", amount, h.Issuer, h.Code)
+	}
+
+	// 收取视同转移，手续费由被冻结帐户从可用持有量中另外支付
+	fee, collector, err := assetcore.TransferFee(stub, h.Account, h.Issuer, h.Code, amount)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+
+	toBalance, err := cache.addHolding(h.To, h.Issuer, h.Code, amount)
+	if err != nil {
+		return assetcore.Error(err)
+	}
+	// 未收取的部分退回原账户，并扣除手续费
+	fromBalance := int64(0)
+	if amount < h.Amount || fee > 0 {
+		fromBalance, err = cache.addHolding(h.Account, h.Issuer, h.Code, h.Amount-amount-fee)
+		if err != nil {
+			return assetcore.Errorf("%s.", err)
+		}
+	}
+	// 手续费转入收费帐户
+	if fee > 0 {
+		_, err = cache.getAccount(collector)
+		if err != nil {
+			return assetcore.Errorf("fee collector %s.", err)
+		}
+		balance, err := cache.addHolding(collector, h.Issuer, h.Code, fee)
+		if err != nil {
+			return assetcore.Error(err)
+		}
+		if collector == h.To {
+			toBalance = balance
+		}
+	}
+	err = cache.flush()
+	if err != nil {
+		return assetcore.Error(err)
+	}
+	err = stub.DelState(key)
+	if err != nil {
+		return assetcore.Errorf("DelState error:%s", err)
+	}
+
+	err = c.emit(stub, Event{Type: "CaptureHold", From: h.Account, To: h.To, Issuer: h.Issuer, Code: h.Code, Amount: amount, FromBalance: fromBalance, ToBalance: toBalance, Fee: fee, Collector: collector})
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+	return shim.Success(nil)
+}
+
+// 获取冻结信息并加载相关账户，冻结或账户不存在时返回错误
+func (c *SimpleChaincode) loadHold(stub shim.ChaincodeStubInterface, cache *stateCache, id, name string) (h assetcore.Hold, key string, now int64, err error) {
+	if id == "" || name == "" {
+		return h, key, now, assetcore.NewError(assetcore.CodeInvalidArgument, "hold arguments error: account and name can't be nil")
+	}
+
+	h, isExist, key, err := assetcore.CheckHold(stub, id, name)
+	if err != nil {
+		return h, key, now, fmt.Errorf("Check hold=%s of account=%s error:%s", name, id, err)
+	} else if !isExist {
+		return h, key, now, assetcore.NewError(assetcore.CodeNotFound, "Hold=%s of account=%s not exists", name, id)
+	}
+
+	_, err = cache.getAccount(h.Account)
+	if err != nil {
+		return h, key, now, err
+	}
+	_, err = cache.getAccount(h.To)
+	if err != nil {
+		return h, key, now, err
+	}
+
+	now, err = assetcore.TxTime(stub)
+	if err != nil {
+		return h, key, now, fmt.Errorf("GetTxTimestamp error:%s", err)
+	}
+	return h, key, now, nil
+}
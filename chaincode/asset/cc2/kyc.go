@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/ChainNova/samples/chaincode/asset/assetcore"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// 登记或更新帐户的KYC状态，只有合规角色可以操作
+// 参数：帐户、状态（verified/suspended/revoked）、等级、到期时间（秒，可选，0为不过期）
+func (c *SimpleChaincode) setKYC(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== setKYC ==========")
+	if len(args) < 3 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 3")
+	}
+
+	k := assetcore.KYC{
+		ID:     args[0],
+		Status: args[1],
+	}
+	tier, err1 := strconv.Atoi(args[2])
+	var expiry int64
+	var err2 error
+	if len(args) > 3 {
+		expiry, err2 = strconv.ParseInt(args[3], 10, 64)
+	}
+	if k.ID == "" || (k.Status != assetcore.KYCVerified && k.Status != assetcore.KYCSuspended && k.Status != assetcore.KYCRevoked) || err1 != nil || err2 != nil || tier < 0 || expiry < 0 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "set KYC arguments error: id can't be nil; status must be verified, suspended or revoked; tier and expiry must be numbers and not less than 0.")
+	}
+	k.Tier = tier
+	k.Expiry = expiry
+
+	err := assetcore.SetKYC(stub, &k)
+	if err != nil {
+		return assetcore.Error(err)
+	}
+
+	err = c.emit(stub, Event{Type: "SetKYC", To: k.ID, Reason: k.Status})
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+
+	return shim.Success(nil)
+}
+
+// 查询帐户的KYC登记
+// 参数：帐户
+func (c *SimpleChaincode) getKYC(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== getKYC ==========")
+	if len(args) < 1 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 1")
+	}
+
+	id := args[0]
+	k, isExist, err := assetcore.CheckKYC(stub, id)
+	if err != nil {
+		return assetcore.Errorf("Check KYC of account=%s error:%s", id, err)
+	} else if !isExist {
+		return assetcore.Fail(assetcore.CodeNotFound, "KYC of account=%s not exists.", id)
+	}
+
+	b, err := json.Marshal(k)
+	if err != nil {
+		return assetcore.Errorf("Marshal KYC error:%s", err)
+	}
+	return shim.Success(b)
+}
+
+// 设置资产的持有资格，只有合规角色可以操作
+// 参数：issuer、code、最低认证等级，issuer和code为空时设置开户资格，等级为0时取消限制
+func (c *SimpleChaincode) setEligibility(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== setEligibility ==========")
+	if len(args) < 3 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 3")
+	}
+
+	r := assetcore.Eligibility{
+		Issuer: args[0],
+		Code:   args[1],
+	}
+	minTier, err := strconv.Atoi(args[2])
+	if (r.Issuer == "") != (r.Code == "") || err != nil || minTier < 0 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "set eligibility arguments error: issuer and code must be both set or both nil; minTier must be a number and not less than 0.")
+	}
+	r.MinTier = minTier
+
+	err = assetcore.SetEligibility(stub, r)
+	if err != nil {
+		return assetcore.Error(err)
+	}
+
+	err = c.emit(stub, Event{Type: "SetEligibility", Issuer: r.Issuer, Code: r.Code, Amount: int64(r.MinTier)})
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+
+	return shim.Success(nil)
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/ChainNova/samples/chaincode/asset/assetcore"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// MigrationObjectType 迁移进度的复合键，不在帐户的范围查询中
+const MigrationObjectType = "Migration~name"
+
+// MigrationDocType 迁移进度的文档类型
+const MigrationDocType = "migration"
+
+// Migration 从cc1迁移到cc2的进度
+type Migration struct {
+	DocType  string `json:"docType,omitempty"` //文档类型
+	LastKey  string `json:"lastKey"`           //已处理的最后一个帐户key，下次从其后开始
+	Accounts int    `json:"accounts"`          //已迁移的帐户数
+	Holdings int    `json:"holdings"`          //已迁移的持有量数
+	Supplies int    `json:"supplies"`          //已转换为资产记录的发行总量记录数
+	Done     bool   `json:"done"`              //是否已全部迁移
+}
+
+// 将cc1帐户文档中的持有量迁移到AccountAsset~id~issuer~code复合键下，并改写为cc2帐户
+// 全部帐户迁移后，将cc1的发行总量记录合并到资产记录中
+// 参数：每次处理的帐户key数
+// 升级到cc2后由管理员反复调用，直到返回的done为true。已迁移的帐户被跳过，可重复调用
+// 冻结、归属计划、KYC等记录两个版本使用同一组key，无需迁移
+func (c *SimpleChaincode) migrate(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== migrate ==========")
+	if len(args) < 1 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 1")
+	}
+	pageSize, err := strconv.Atoi(args[0])
+	if err != nil || pageSize <= 0 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "migrate arguments error: page size must be a number and greater than 0.")
+	}
+
+	err = assetcore.CheckAdmin(stub)
+	if err != nil {
+		return assetcore.Fail(assetcore.CodeUnauthorized, "Permission denied:%s", err)
+	}
+
+	m, _, key, err := c.checkMigration(stub)
+	if err != nil {
+		return assetcore.Errorf("Check migration error:%s", err)
+	} else if m.Done {
+		return c.migrationResponse(m)
+	}
+
+	// 分页查询的交易不能写入状态，以最后处理的key作为下次的起点
+	startKey := ""
+	if m.LastKey != "" {
+		startKey = m.LastKey + "\x00"
+	}
+	it, err := stub.GetStateByRange(startKey, "")
+	if err != nil {
+		return assetcore.Errorf("GetStateByRange error:%s", err)
+	}
+	defer it.Close()
+
+	count := 0
+	for ; count < pageSize && it.HasNext(); count++ {
+		kv, err := it.Next()
+		if err != nil {
+			return assetcore.Errorf("Iterate accounts error:%s", err)
+		}
+		m.LastKey = kv.Key
+
+		n, err := c.migrateAccount(stub, kv.Key, kv.Value)
+		if err != nil {
+			return assetcore.Errorf("Migrate account=%s error:%s", kv.Key, err)
+		} else if n >= 0 {
+			m.Accounts++
+			m.Holdings += n
+		}
+	}
+	m.Done = !it.HasNext()
+	if m.Done {
+		m.Supplies, err = c.migrateSupplies(stub)
+		if err != nil {
+			return assetcore.Errorf("Migrate supplies error:%s", err)
+		}
+	}
+
+	err = assetcore.Save(stub, key, m)
+	if err != nil {
+		return assetcore.Errorf("save migration=%+v error:%s", m, err)
+	}
+
+	return c.migrationResponse(m)
+}
+
+// 迁移一个cc1帐户，返回迁移的持有量数，不是cc1帐户时返回-1
+func (c *SimpleChaincode) migrateAccount(stub shim.ChaincodeStubInterface, key string, value []byte) (int, error) {
+	var doc struct {
+		DocType string           `json:"docType"`
+		Assets  *json.RawMessage `json:"assets"`
+	}
+	err := json.Unmarshal(value, &doc)
+	if err != nil || doc.DocType != "" || doc.Assets == nil {
+		// cc2帐户或其他文档
+		return -1, nil
+	}
+
+	var legacy assetcore.Account
+	err = json.Unmarshal(value, &legacy)
+	if err != nil {
+		return 0, err
+	}
+	id := key
+
+	assets, err := assetcore.AccountStore{}.Holdings(stub, id)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, a := range assets {
+		if a.Amount == 0 {
+			continue
+		}
+		err = assetcore.HoldingStore{}.SetHolding(stub, id, a.Issuer, a.Code, a.Amount)
+		if err != nil {
+			return 0, err
+		}
+		n++
+	}
+
+	// cc1帐户没有余额
+	a := Account{
+		DocType: assetcore.AccountDocType,
+		ID:      id,
+		Owner:   legacy.Owner,
+	}
+	err = assetcore.Save(stub, id, a)
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// 将cc1的发行总量记录合并到资产记录中并删除，返回合并的记录数
+// cc1没有未发行数量，资产记录不存在时未发行数量为0
+func (c *SimpleChaincode) migrateSupplies(stub shim.ChaincodeStubInterface) (int, error) {
+	it, err := stub.GetStateByPartialCompositeKey(assetcore.SupplyObjectType, []string{})
+	if err != nil {
+		return 0, err
+	}
+	defer it.Close()
+
+	n := 0
+	for it.HasNext() {
+		kv, err := it.Next()
+		if err != nil {
+			return n, err
+		}
+		var supply assetcore.Supply
+		err = json.Unmarshal(kv.Value, &supply)
+		if err != nil {
+			return n, fmt.Errorf("Unmarshal supply=%s error:%s", string(kv.Value), err)
+		}
+
+		_, asset, isExist, key, err := assetcore.CheckAsset(stub, supply.Issuer, supply.Code)
+		if err != nil {
+			return n, fmt.Errorf("Check asset issuer=%s&code=%s error:%s", supply.Issuer, supply.Code, err)
+		} else if !isExist {
+			asset = Asset{DocType: assetcore.AssetDocType, Issuer: supply.Issuer, Code: supply.Code}
+		}
+		asset.Issued += supply.Issued
+		asset.Redeemed += supply.Redeemed
+		err = assetcore.Save(stub, key, asset)
+		if err != nil {
+			return n, fmt.Errorf("save asset=%+v error:%s", asset, err)
+		}
+		err = stub.DelState(kv.Key)
+		if err != nil {
+			return n, fmt.Errorf("DelState error:%s", err)
+		}
+		n++
+	}
+	return n, nil
+}
+
+// 获取迁移进度，未调用过Migrate时isExist为false
+func (c *SimpleChaincode) checkMigration(stub shim.ChaincodeStubInterface) (m Migration, isExist bool, key string, err error) {
+	key, err = stub.CreateCompositeKey(MigrationObjectType, []string{"cc1"})
+	if err != nil {
+		return m, false, key, err
+	}
+	_, isExist, err = assetcore.Load(stub, key, &m)
+	m.DocType = MigrationDocType
+	return m, isExist, key, err
+}
+
+// 迁移进行中部分帐户仍是cc1格式，修改状态的调用会读到不完整的持有量，因此拒绝
+// 升级后第一次调用Migrate开始迁移，直到返回的done为true
+func (c *SimpleChaincode) rejectDuringMigration(name string, h assetcore.Handler) assetcore.Handler {
+	return func(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+		m, isExist, _, err := c.checkMigration(stub)
+		if err != nil {
+			return assetcore.Errorf("Check migration error:%s", err)
+		} else if isExist && !m.Done {
+			return assetcore.Fail(assetcore.CodeFailedPrecondition, "Migration from cc1 is in progress, %s is not allowed until Migrate returns done.", name)
+		}
+		return h(stub, args)
+	}
+}
+
+func (c *SimpleChaincode) migrationResponse(m Migration) pb.Response {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return assetcore.Errorf("Marshal migration error:%s", err)
+	}
+	return shim.Success(b)
+}
@@ -0,0 +1,427 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/ChainNova/samples/chaincode/asset/assetcore"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// 委托方向
+const (
+	OrderBuy  = "buy"  //买入
+	OrderSell = "sell" //卖出
+)
+
+// Order 限价委托
+// 买单挂单时冻结price*remaining的现金，卖单挂单时冻结remaining的资产
+type Order struct {
+	DocType   string `json:"docType,omitempty"` //文档类型
+	ID        string `json:"id"`                //委托编号（下单交易ID）
+	Account   string `json:"account"`           //委托帐户
+	Issuer    string `json:"issuer"`            //资产发行机构
+	Code      string `json:"code"`              //资产代码
+	Side      string `json:"side"`              //委托方向
+	Price     int64  `json:"price"`             //委托价格
+	Count     int64  `json:"count"`             //委托数量
+	Remaining int64  `json:"remaining"`         //未成交数量
+	Time      int64  `json:"time"`              //下单时间（纳秒）
+}
+
+// OrderIndex 委托编号索引，以Order~id为key，指向挂单key
+type OrderIndex struct {
+	DocType string `json:"docType,omitempty"` //文档类型
+	BookKey string `json:"bookKey"`           //挂单key
+}
+
+// Fill 一笔撮合成交
+type Fill struct {
+	BuyOrder  string `json:"buyOrder"`  //买单编号
+	SellOrder string `json:"sellOrder"` //卖单编号
+	Buyer     string `json:"buyer"`     //买方帐户
+	Seller    string `json:"seller"`    //卖方帐户
+	Count     int64  `json:"count"`     //成交数量
+	Price     int64  `json:"price"`     //成交价格（挂单价格）
+}
+
+// 限价委托下单，按价格优先、时间优先与对手方挂单撮合，未成交部分挂单
+// 参数：帐户、issuer、code、方向（buy/sell）、价格、数量
+func (c *SimpleChaincode) placeOrder(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== placeOrder ==========")
+	if len(args) < 6 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 6")
+	}
+
+	order := Order{
+		DocType: OrderDocType,
+		ID:      stub.GetTxID(),
+		Account: args[0],
+		Issuer:  args[1],
+		Code:    args[2],
+		Side:    args[3],
+	}
+	price, err1 := strconv.ParseInt(args[4], 10, 64)
+	count, err2 := strconv.ParseInt(args[5], 10, 64)
+	if order.Account == "" || order.Issuer == "" || order.Code == "" || (order.Side != OrderBuy && order.Side != OrderSell) || err1 != nil || err2 != nil || price <= 0 || count <= 0 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "place order arguments error: account, issuer and code can't be nil; side must be buy or sell; price and count must be numbers and greater than 0.")
+	}
+	if mulOverflow(count, price) {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Order count=%v * price=%v overflows.", count, price)
+	}
+	order.Price = price
+	order.Count = count
+	order.Remaining = count
+
+	ts, err := stub.GetTxTimestamp()
+	if err != nil {
+		return assetcore.Errorf("GetTxTimestamp error:%s", err)
+	}
+	order.Time = ts.GetSeconds()*1e9 + int64(ts.GetNanos())
+
+	_, _, isExist, _, err := assetcore.CheckAsset(stub, order.Issuer, order.Code)
+	if err != nil {
+		return assetcore.Errorf("Check asset issuer=%s&code=%s error:%s", order.Issuer, order.Code, err)
+	} else if !isExist {
+		return assetcore.Error(assetcore.AssetNotFound(order.Issuer, order.Code))
+	}
+
+	cache := newStateCache(c, stub)
+	account, err := cache.getAccount(order.Account)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+	err = assetcore.CheckOwner(stub, account.Owner, account.ID)
+	if err != nil {
+		return assetcore.Fail(assetcore.CodeUnauthorized, "Permission denied:%s", err)
+	}
+
+	err = assetcore.CheckActive(stub, order.Issuer, order.Code, order.Account)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+	// 买方必须有资格持有该资产
+	if order.Side == OrderBuy {
+		err = assetcore.CheckEligible(stub, order.Issuer, order.Code, order.Account)
+		if err != nil {
+			return assetcore.Errorf("%s.", err)
+		}
+	}
+
+	// 每笔撮合成交的数量不超过委托数量，超过审批阈值的委托只能通过Transfer发起审批
+	_, required, err := assetcore.CheckApproval(stub, order.Issuer, order.Code, order.Count)
+	if err != nil {
+		return assetcore.Errorf("Check approval policy of issuer=%s&code=%s error:%s", order.Issuer, order.Code, err)
+	} else if required {
+		return assetcore.Fail(assetcore.CodeFailedPrecondition, "Amount=%v of issuer=%s&code=%s requires approval, use Transfer.", order.Count, order.Issuer, order.Code)
+	}
+	// 撮合成交不收取转移手续费，设置了手续费的资产不能挂单
+	f, err := assetcore.CheckTransferFee(stub, order.Issuer, order.Code)
+	if err != nil {
+		return assetcore.Errorf("Check transfer fee of issuer=%s&code=%s error:%s", order.Issuer, order.Code, err)
+	} else if f.Rate > 0 {
+		return assetcore.Fail(assetcore.CodeFailedPrecondition, "Issuer=%s&code=%s charges transfer fee, use Transfer.", order.Issuer, order.Code)
+	}
+
+	// 冻结委托所需的现金或资产
+	if order.Side == OrderBuy {
+		_, err = cache.addBalance(order.Account, -order.Price*order.Count)
+	} else {
+		_, err = cache.addHolding(order.Account, order.Issuer, order.Code, -order.Count)
+	}
+	if err != nil {
+		return assetcore.Errorf("Reserve order error:%s.", err)
+	}
+
+	fills, err := c.matchOrder(stub, cache, &order)
+	if err != nil {
+		return assetcore.Errorf("Match order=%s error:%s", order.ID, err)
+	}
+
+	// 未成交部分挂单
+	if order.Remaining > 0 {
+		err = c.saveOrder(stub, order)
+		if err != nil {
+			return assetcore.Errorf("save order=%+v error:%s", order, err)
+		}
+	}
+
+	err = cache.flush()
+	if err != nil {
+		return assetcore.Error(err)
+	}
+
+	err = c.emit(stub, Event{Type: "PlaceOrder", From: order.Account, Issuer: order.Issuer, Code: order.Code, Amount: order.Count, Price: order.Price, Fills: fills})
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+
+	b, err := json.Marshal(struct {
+		Order Order  `json:"order"`
+		Fills []Fill `json:"fills"`
+	}{order, fills})
+	if err != nil {
+		return assetcore.Errorf("Marshal order error:%s", err)
+	}
+	return shim.Success(b)
+}
+
+// 与对手方挂单撮合，成交价格为挂单价格
+// 买方得到资产，卖方得到现金；新买单以低于委托价成交时退还差价
+func (c *SimpleChaincode) matchOrder(stub shim.ChaincodeStubInterface, cache *stateCache, order *Order) ([]Fill, error) {
+	side := OrderSell
+	if order.Side == OrderSell {
+		side = OrderBuy
+	}
+
+	bookIterator, err := stub.GetStateByPartialCompositeKey(OrderBookObjectType, []string{order.Issuer, order.Code, side})
+	if err != nil {
+		return nil, err
+	}
+	defer bookIterator.Close()
+
+	fills := []Fill{}
+	for order.Remaining > 0 && bookIterator.HasNext() {
+		kv, err := bookIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var resting Order
+		err = json.Unmarshal(kv.Value, &resting)
+		if err != nil {
+			return nil, err
+		}
+		resting.DocType = OrderDocType
+
+		// 挂单按最优价格排列，价格不再相交时停止
+		if (order.Side == OrderBuy && resting.Price > order.Price) || (order.Side == OrderSell && resting.Price < order.Price) {
+			break
+		}
+		// 不与自己的挂单、已冻结帐户的挂单及已无持有资格的买单成交
+		if resting.Account == order.Account || assetcore.CheckActive(stub, "", "", resting.Account) != nil {
+			continue
+		}
+		if order.Side == OrderSell && assetcore.CheckEligible(stub, order.Issuer, order.Code, resting.Account) != nil {
+			continue
+		}
+
+		fill := Fill{Count: order.Remaining, Price: resting.Price}
+		if resting.Remaining < fill.Count {
+			fill.Count = resting.Remaining
+		}
+		buy, sell := order, &resting
+		if order.Side == OrderSell {
+			buy, sell = &resting, order
+		}
+		fill.BuyOrder, fill.Buyer = buy.ID, buy.Account
+		fill.SellOrder, fill.Seller = sell.ID, sell.Account
+
+		_, err = cache.addHolding(fill.Buyer, order.Issuer, order.Code, fill.Count)
+		if err != nil {
+			return nil, err
+		}
+		_, err = cache.addBalance(fill.Seller, fill.Count*fill.Price)
+		if err != nil {
+			return nil, err
+		}
+		if buy.Price > fill.Price {
+			_, err = cache.addBalance(fill.Buyer, fill.Count*(buy.Price-fill.Price))
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		order.Remaining = order.Remaining - fill.Count
+		resting.Remaining = resting.Remaining - fill.Count
+		if resting.Remaining == 0 {
+			err = c.deleteOrder(stub, resting)
+		} else {
+			err = assetcore.Save(stub, kv.Key, resting)
+		}
+		if err != nil {
+			return nil, err
+		}
+		fills = append(fills, fill)
+	}
+
+	return fills, nil
+}
+
+// 撤单，退还未成交部分冻结的现金或资产
+// 参数：委托编号
+func (c *SimpleChaincode) cancelOrder(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== cancelOrder ==========")
+	if len(args) < 1 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 1")
+	}
+
+	id := args[0]
+	if id == "" {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "cancel order arguments error: id can't be nil.")
+	}
+
+	order, isExist, err := c.checkOrder(stub, id)
+	if err != nil {
+		return assetcore.Errorf("Check order=%s error:%s", id, err)
+	} else if !isExist {
+		return assetcore.Fail(assetcore.CodeNotFound, "Order=%s not exists.", id)
+	}
+
+	cache := newStateCache(c, stub)
+	account, err := cache.getAccount(order.Account)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+	err = assetcore.CheckOwner(stub, account.Owner, account.ID)
+	if err != nil {
+		return assetcore.Fail(assetcore.CodeUnauthorized, "Permission denied:%s", err)
+	}
+
+	if order.Side == OrderBuy {
+		_, err = cache.addBalance(order.Account, order.Price*order.Remaining)
+	} else {
+		_, err = cache.addHolding(order.Account, order.Issuer, order.Code, order.Remaining)
+	}
+	if err != nil {
+		return assetcore.Errorf("Release order error:%s.", err)
+	}
+
+	err = c.deleteOrder(stub, order)
+	if err != nil {
+		return assetcore.Errorf("Delete order=%s error:%s", id, err)
+	}
+	err = cache.flush()
+	if err != nil {
+		return assetcore.Error(err)
+	}
+
+	err = c.emit(stub, Event{Type: "CancelOrder", From: order.Account, Issuer: order.Issuer, Code: order.Code, Amount: order.Remaining, Price: order.Price})
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+	return shim.Success(nil)
+}
+
+// 查询某资产的挂单，买单、卖单均按价格优先、时间优先排列
+// 参数：issuer、code
+func (c *SimpleChaincode) getOrderBook(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== getOrderBook ==========")
+	if len(args) < 2 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 2")
+	}
+
+	book := struct {
+		Issuer string  `json:"issuer"`
+		Code   string  `json:"code"`
+		Bids   []Order `json:"bids"`
+		Asks   []Order `json:"asks"`
+	}{Issuer: args[0], Code: args[1]}
+
+	var err error
+	book.Bids, err = c.bookOrders(stub, book.Issuer, book.Code, OrderBuy)
+	if err != nil {
+		return assetcore.Errorf("Get bids of issuer=%s&code=%s error:%s", book.Issuer, book.Code, err)
+	}
+	book.Asks, err = c.bookOrders(stub, book.Issuer, book.Code, OrderSell)
+	if err != nil {
+		return assetcore.Errorf("Get asks of issuer=%s&code=%s error:%s", book.Issuer, book.Code, err)
+	}
+
+	b, err := json.Marshal(book)
+	if err != nil {
+		return assetcore.Errorf("Marshal order book error:%s", err)
+	}
+	return shim.Success(b)
+}
+
+func (c *SimpleChaincode) bookOrders(stub shim.ChaincodeStubInterface, issuer, code, side string) ([]Order, error) {
+	bookIterator, err := stub.GetStateByPartialCompositeKey(OrderBookObjectType, []string{issuer, code, side})
+	if err != nil {
+		return nil, err
+	}
+	defer bookIterator.Close()
+
+	orders := []Order{}
+	for bookIterator.HasNext() {
+		kv, err := bookIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var o Order
+		err = json.Unmarshal(kv.Value, &o)
+		if err != nil {
+			fmt.Println("json.Unmarshal error:", err, string(kv.Value))
+			continue
+		}
+		orders = append(orders, o)
+	}
+	return orders, nil
+}
+
+// 挂单key，按价格优先、时间优先排序
+// 买单价格取反，使价格高的排在前面
+func (c *SimpleChaincode) orderBookKey(stub shim.ChaincodeStubInterface, o Order) (string, error) {
+	price := o.Price
+	if o.Side == OrderBuy {
+		price = math.MaxInt64 - price
+	}
+	return stub.CreateCompositeKey(OrderBookObjectType, []string{o.Issuer, o.Code, o.Side, fmt.Sprintf("%019d", price), fmt.Sprintf("%019d", o.Time), o.ID})
+}
+
+// 保存挂单及委托编号索引
+func (c *SimpleChaincode) saveOrder(stub shim.ChaincodeStubInterface, o Order) error {
+	bookKey, err := c.orderBookKey(stub, o)
+	if err != nil {
+		return err
+	}
+	key, err := stub.CreateCompositeKey(OrderObjectType, []string{o.ID})
+	if err != nil {
+		return err
+	}
+	err = assetcore.Save(stub, bookKey, o)
+	if err != nil {
+		return err
+	}
+	return assetcore.Save(stub, key, OrderIndex{DocType: OrderIndexDocType, BookKey: bookKey})
+}
+
+// 删除挂单及委托编号索引
+func (c *SimpleChaincode) deleteOrder(stub shim.ChaincodeStubInterface, o Order) error {
+	bookKey, err := c.orderBookKey(stub, o)
+	if err != nil {
+		return err
+	}
+	key, err := stub.CreateCompositeKey(OrderObjectType, []string{o.ID})
+	if err != nil {
+		return err
+	}
+	err = stub.DelState(bookKey)
+	if err != nil {
+		return err
+	}
+	return stub.DelState(key)
+}
+
+func (c *SimpleChaincode) checkOrder(stub shim.ChaincodeStubInterface, id string) (o Order, isExist bool, err error) {
+	key, err := stub.CreateCompositeKey(OrderObjectType, []string{id})
+	if err != nil {
+		return o, false, err
+	}
+	var index OrderIndex
+	_, isExist, err = assetcore.Load(stub, key, &index)
+	if err != nil || !isExist {
+		return o, false, err
+	}
+	b, err := stub.GetState(index.BookKey)
+	if err != nil {
+		return o, false, err
+	}
+	if len(b) > 0 {
+		err = json.Unmarshal(b, &o)
+		o.DocType = OrderDocType
+	}
+	return o, o.ID != "", err
+}
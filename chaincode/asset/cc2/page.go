@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/ChainNova/samples/chaincode/asset/assetcore"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// Page 分页查询结果
+type Page struct {
+	Records  interface{} `json:"records"`  //本页记录
+	Count    int32       `json:"count"`    //本页记录数
+	Bookmark string      `json:"bookmark"` //下一页书签，传给下一次查询
+}
+
+// 分页列出所有帐户
+// 参数：每页数量、书签（可选）
+func (c *SimpleChaincode) listAccounts(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== listAccounts ==========")
+	pageSize, bookmark, err := c.parsePage(args)
+	if err != nil {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "list accounts arguments error: %s.", err)
+	}
+
+	// 帐户以id为key，不包含复合key
+	accountsIterator, metadata, err := stub.GetStateByRangeWithPagination("", "", pageSize, bookmark)
+	if err != nil {
+		return assetcore.Errorf("GetStateByRangeWithPagination error:%s", err)
+	}
+	defer accountsIterator.Close()
+
+	accounts := []Account{}
+	for accountsIterator.HasNext() {
+		kv, err := accountsIterator.Next()
+		if err != nil {
+			return assetcore.Errorf("Iterate accounts error:%s", err)
+		}
+		var a Account
+		err = json.Unmarshal(kv.Value, &a)
+		if err != nil {
+			fmt.Println("json.Unmarshal error:", err, string(kv.Value))
+			continue
+		}
+		accounts = append(accounts, a)
+	}
+
+	return c.pageResponse(accounts, metadata)
+}
+
+// 分页列出帐户持有的资产
+// 参数：帐户、每页数量、书签（可选）
+func (c *SimpleChaincode) listHoldings(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== listHoldings ==========")
+	if len(args) < 2 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 2")
+	}
+
+	id := args[0]
+	if id == "" {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "list holdings arguments error: id can't be nil.")
+	}
+	pageSize, bookmark, err := c.parsePage(args[1:])
+	if err != nil {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "list holdings arguments error: %s.", err)
+	}
+
+	assetsIterator, metadata, err := stub.GetStateByPartialCompositeKeyWithPagination(AccountAssetObjectType, []string{id}, pageSize, bookmark)
+	if err != nil {
+		return assetcore.Errorf("GetStateByPartialCompositeKeyWithPagination error:%s", err)
+	}
+	defer assetsIterator.Close()
+
+	assets := []Asset{}
+	for assetsIterator.HasNext() {
+		kv, err := assetsIterator.Next()
+		if err != nil {
+			return assetcore.Errorf("Iterate assets of account=%s error:%s", id, err)
+		}
+		count, err := assetcore.ParseHolding(kv.Value)
+		if err != nil {
+			fmt.Println("parseHolding error:", err, string(kv.Value))
+			continue
+		}
+		_, compositeKeyParts, err := stub.SplitCompositeKey(kv.Key)
+		if err != nil {
+			fmt.Println("SplitCompositeKey error:", err)
+			continue
+		}
+		assets = append(assets, Asset{
+			Issuer: compositeKeyParts[1],
+			Code:   compositeKeyParts[2],
+			Amount: count,
+		})
+	}
+
+	return c.pageResponse(assets, metadata)
+}
+
+// 分页列出发行机构的资产
+// 参数：issuer、每页数量、书签（可选）
+func (c *SimpleChaincode) listIssuerAssets(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== listIssuerAssets ==========")
+	if len(args) < 2 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 2")
+	}
+
+	issuer := args[0]
+	if issuer == "" {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "list issuer assets arguments error: issuer can't be nil.")
+	}
+	pageSize, bookmark, err := c.parsePage(args[1:])
+	if err != nil {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "list issuer assets arguments error: %s.", err)
+	}
+
+	assetsIterator, metadata, err := stub.GetStateByPartialCompositeKeyWithPagination(assetcore.AssetObjectType, []string{issuer}, pageSize, bookmark)
+	if err != nil {
+		return assetcore.Errorf("GetStateByPartialCompositeKeyWithPagination error:%s", err)
+	}
+	defer assetsIterator.Close()
+
+	assets := []Asset{}
+	for assetsIterator.HasNext() {
+		kv, err := assetsIterator.Next()
+		if err != nil {
+			return assetcore.Errorf("Iterate assets of issuer=%s error:%s", issuer, err)
+		}
+		var asset Asset
+		err = json.Unmarshal(kv.Value, &asset)
+		if err != nil {
+			fmt.Println("json.Unmarshal error:", err, string(kv.Value))
+			continue
+		}
+		assets = append(assets, asset)
+	}
+
+	return c.pageResponse(assets, metadata)
+}
+
+// 解析分页参数：每页数量、书签（可选）
+func (c *SimpleChaincode) parsePage(args []string) (pageSize int32, bookmark string, err error) {
+	if len(args) < 1 {
+		return 0, "", fmt.Errorf("page size is required")
+	}
+	size, err := strconv.ParseInt(args[0], 10, 32)
+	if err != nil || size <= 0 {
+		return 0, "", fmt.Errorf("page size must be a number and greater than 0")
+	}
+	if len(args) > 1 {
+		bookmark = args[1]
+	}
+	return int32(size), bookmark, nil
+}
+
+func (c *SimpleChaincode) pageResponse(records interface{}, metadata *pb.QueryResponseMetadata) pb.Response {
+	page := Page{Records: records}
+	if metadata != nil {
+		page.Count = metadata.FetchedRecordsCount
+		page.Bookmark = metadata.Bookmark
+	}
+
+	b, err := json.Marshal(page)
+	if err != nil {
+		return assetcore.Errorf("Marshal page error:%s", err)
+	}
+	return shim.Success(b)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ChainNova/samples/chaincode/asset/assetcore"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// QueryRecord 富查询返回的一条记录
+type QueryRecord struct {
+	Key    string          `json:"key"`    //state key
+	Record json.RawMessage `json:"record"` //文档内容
+}
+
+// 使用CouchDB Mango selector分页查询，需要以CouchDB作为state数据库
+// 如查询AAA发行的持有量大于1000的持仓：{"docType":"holding","issuer":"AAA","amount":{"$gt":1000}}
+// 参数：selector、每页数量、书签（可选）
+func (c *SimpleChaincode) queryAssets(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== queryAssets ==========")
+	if len(args) < 2 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 2")
+	}
+
+	var selector map[string]interface{}
+	err := json.Unmarshal([]byte(args[0]), &selector)
+	if err != nil || len(selector) == 0 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "query assets arguments error: selector must be a non-empty json object.")
+	}
+	pageSize, bookmark, err := c.parsePage(args[1:])
+	if err != nil {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "query assets arguments error: %s.", err)
+	}
+
+	query, err := json.Marshal(map[string]interface{}{"selector": selector})
+	if err != nil {
+		return assetcore.Errorf("Marshal query error:%s", err)
+	}
+	resultsIterator, metadata, err := stub.GetQueryResultWithPagination(string(query), pageSize, bookmark)
+	if err != nil {
+		return assetcore.Errorf("GetQueryResultWithPagination error:%s", err)
+	}
+	defer resultsIterator.Close()
+
+	records := []QueryRecord{}
+	for resultsIterator.HasNext() {
+		kv, err := resultsIterator.Next()
+		if err != nil {
+			return assetcore.Errorf("Iterate query result error:%s", err)
+		}
+		records = append(records, QueryRecord{Key: kv.Key, Record: kv.Value})
+	}
+
+	return c.pageResponse(records, metadata)
+}
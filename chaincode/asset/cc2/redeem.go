@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/ChainNova/samples/chaincode/asset/assetcore"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// 设置资产的赎回价格，只有发行机构可以设置，价格为0时不可赎回
+// 参数：issuer、code、价格
+func (c *SimpleChaincode) setRedemptionPrice(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== setRedemptionPrice ==========")
+	if len(args) < 3 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 3")
+	}
+
+	issuer := args[0]
+	code := args[1]
+	price, err := strconv.ParseInt(args[2], 10, 64)
+	if issuer == "" || code == "" || err != nil || price < 0 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "set redemption price arguments error: issuer and code can't be nil; price must be a number and not less than 0.")
+	}
+
+	err = assetcore.CheckIssuer(stub, issuer)
+	if err != nil {
+		return assetcore.Fail(assetcore.CodeUnauthorized, "Permission denied:%s", err)
+	}
+
+	_, asset, isExist, key, err := assetcore.CheckAsset(stub, issuer, code)
+	if err != nil {
+		return assetcore.Errorf("Check asset issuer=%s&code=%s error:%s", issuer, code, err)
+	} else if !isExist {
+		return assetcore.Error(assetcore.AssetNotFound(issuer, code))
+	}
+
+	asset.RedemptionPrice = price
+	err = assetcore.Save(stub, key, asset)
+	if err != nil {
+		return assetcore.Errorf("save asset=%+v error:%s", asset, err)
+	}
+
+	err = c.emit(stub, Event{Type: "SetRedemptionPrice", Issuer: issuer, Code: code, Price: price, Supply: asset.Amount})
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+	return shim.Success(nil)
+}
+
+// 赎回资产，持有者将资产退还发行机构，退还的资产被销毁，帐户按赎回价格得到现金
+// 参数：帐户、issuer、code、数量
+func (c *SimpleChaincode) redeem(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== redeem ==========")
+	if len(args) < 4 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 4")
+	}
+
+	id := args[0]
+	issuer := args[1]
+	code := args[2]
+	count, err := strconv.ParseInt(args[3], 10, 64)
+	if id == "" || issuer == "" || code == "" || err != nil || count <= 0 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "redeem arguments error: account, issuer and code can't be nil; count must be a number and greater than 0.")
+	}
+
+	_, asset, isExist, key, err := assetcore.CheckAsset(stub, issuer, code)
+	if err != nil {
+		return assetcore.Errorf("Check asset issuer=%s&code=%s error:%s", issuer, code, err)
+	} else if !isExist {
+		return assetcore.Error(assetcore.AssetNotFound(issuer, code))
+	}
+	if asset.RedemptionPrice <= 0 {
+		return assetcore.Fail(assetcore.CodeFailedPrecondition, "Asset issuer=%s&code=%s is not redeemable.", issuer, code)
+	}
+	if mulOverflow(count, asset.RedemptionPrice) {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Redeem count=%v * price=%v overflows.", count, asset.RedemptionPrice)
+	}
+
+	cache := newStateCache(c, stub)
+	account, err := cache.getAccount(id)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+	err = assetcore.CheckOwner(stub, account.Owner, account.ID)
+	if err != nil {
+		return assetcore.Fail(assetcore.CodeUnauthorized, "Permission denied:%s", err)
+	}
+	err = assetcore.CheckActive(stub, issuer, code, id)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+
+	sum, err := cache.addHolding(id, issuer, code, -count)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+	balance, err := cache.addBalance(id, count*asset.RedemptionPrice)
+	if err == nil {
+		err = cache.flush()
+	}
+	if err != nil {
+		return assetcore.Error(err)
+	}
+
+	// 记录已销毁数量
+	asset.Redeemed = asset.Redeemed + count
+	err = assetcore.Save(stub, key, asset)
+	if err != nil {
+		return assetcore.Errorf("save asset=%+v error:%s", asset, err)
+	}
+
+	err = c.emit(stub, Event{
+		Type:        "Redeem",
+		From:        id,
+		Issuer:      issuer,
+		Code:        code,
+		Amount:      count,
+		Price:       asset.RedemptionPrice,
+		FromBalance: sum,
+		Balance:     balance,
+		Supply:      asset.Amount,
+	})
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+	return shim.Success(nil)
+}
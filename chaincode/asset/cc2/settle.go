@@ -0,0 +1,332 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/ChainNova/samples/chaincode/asset/assetcore"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// 成交状态
+const (
+	TradePending = "pending" //等待对手方确认
+	TradeSettled = "settled" //已交收
+)
+
+// 成交确认的默认有效时长（秒）
+const DefaultTradeDuration = 86400
+
+// Trade 买卖双方的一笔成交，双方都确认后进行券款对付交收
+// 卖方确认时冻结成交数量的资产，买方确认时冻结成交金额的现金，到期未交收的成交可以撤销
+type Trade struct {
+	DocType         string `json:"docType,omitempty"` //文档类型
+	ID              string `json:"id"`                //成交编号
+	Seller          string `json:"seller"`            //卖方帐户
+	Buyer           string `json:"buyer"`             //买方帐户
+	Issuer          string `json:"issuer"`            //资产发行机构
+	Code            string `json:"code"`              //资产代码
+	Count           int64  `json:"count"`             //成交数量
+	Price           int64  `json:"price"`             //成交单价
+	SellerConfirmed bool   `json:"sellerConfirmed"`   //卖方是否已确认
+	BuyerConfirmed  bool   `json:"buyerConfirmed"`    //买方是否已确认
+	Expiry          int64  `json:"expiry"`            //确认截止时间（秒）
+	Status          string `json:"status"`            //成交状态
+}
+
+// 券款对付交收
+// 参数：成交编号、卖方、买方、issuer、code、数量、单价、有效时长（秒，可选，默认一天）
+// 卖方和买方分别以相同参数调用，卖方确认时冻结资产，买方确认时冻结现金price*count
+// 双方都确认后冻结的资产转给买方，冻结的现金转给卖方
+func (c *SimpleChaincode) settleTrade(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== settleTrade ==========")
+	if len(args) < 7 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 7")
+	}
+
+	t := Trade{
+		ID:     args[0],
+		Seller: args[1],
+		Buyer:  args[2],
+		Issuer: args[3],
+		Code:   args[4],
+	}
+	count, err1 := strconv.ParseInt(args[5], 10, 64)
+	price, err2 := strconv.ParseInt(args[6], 10, 64)
+	if t.ID == "" || t.Seller == "" || t.Buyer == "" || t.Issuer == "" || t.Code == "" || err1 != nil || err2 != nil || count <= 0 || price <= 0 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "settle trade arguments error: id, seller, buyer, issuer and code can't be nil; count and price must be numbers and greater than 0.")
+	}
+	duration := int64(DefaultTradeDuration)
+	if len(args) > 7 && args[7] != "" {
+		var err error
+		duration, err = strconv.ParseInt(args[7], 10, 64)
+		if err != nil || duration <= 0 {
+			return assetcore.Fail(assetcore.CodeInvalidArgument, "settle trade arguments error: duration must be a number and greater than 0.")
+		}
+	}
+	if t.Seller == t.Buyer {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Account=%s can't trade with itself.", t.Seller)
+	}
+	if mulOverflow(count, price) {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Trade count=%v * price=%v overflows.", count, price)
+	}
+	t.Count = count
+	t.Price = price
+
+	cache := newStateCache(c, stub)
+	seller, err := cache.getAccount(t.Seller)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+	buyer, err := cache.getAccount(t.Buyer)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+
+	// 调用者必须是卖方或买方
+	isSeller := assetcore.CheckOwner(stub, seller.Owner, seller.ID) == nil
+	isBuyer := assetcore.CheckOwner(stub, buyer.Owner, buyer.ID) == nil
+	if !isSeller && !isBuyer {
+		return assetcore.Fail(assetcore.CodeUnauthorized, "Permission denied:caller is neither the seller=%s nor the buyer=%s", t.Seller, t.Buyer)
+	}
+
+	err = assetcore.CheckActive(stub, t.Issuer, t.Code, t.Seller, t.Buyer)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+	// 接收帐户必须有资格持有该资产
+	err = assetcore.CheckEligible(stub, t.Issuer, t.Code, t.Buyer)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+	// 超过审批阈值的数量只能通过Transfer发起审批
+	_, required, err := assetcore.CheckApproval(stub, t.Issuer, t.Code, t.Count)
+	if err != nil {
+		return assetcore.Errorf("Check approval policy of issuer=%s&code=%s error:%s", t.Issuer, t.Code, err)
+	} else if required {
+		return assetcore.Fail(assetcore.CodeFailedPrecondition, "Amount=%v of issuer=%s&code=%s requires approval, use Transfer.", t.Count, t.Issuer, t.Code)
+	}
+	// 券款对付不收取转移手续费，需要收费的资产只能通过Transfer转移
+	fee, _, err := assetcore.TransferFee(stub, t.Seller, t.Issuer, t.Code, t.Count)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	} else if fee > 0 {
+		return assetcore.Fail(assetcore.CodeFailedPrecondition, "Issuer=%s&code=%s charges transfer fee=%v, use Transfer.", t.Issuer, t.Code, fee)
+	}
+
+	now, err := assetcore.TxTime(stub)
+	if err != nil {
+		return assetcore.Errorf("GetTxTimestamp error:%s", err)
+	}
+
+	_, trade, isExist, key, err := c.checkTrade(stub, t.ID)
+	if err != nil {
+		return assetcore.Errorf("Check trade=%s error:%s", t.ID, err)
+	}
+	if isExist {
+		// 对手方确认，成交条款必须一致
+		if trade.Status != TradePending {
+			return assetcore.Fail(assetcore.CodeFailedPrecondition, "Trade=%s already %s.", t.ID, trade.Status)
+		}
+		if trade.Seller != t.Seller || trade.Buyer != t.Buyer || trade.Issuer != t.Issuer || trade.Code != t.Code || trade.Count != t.Count || trade.Price != t.Price {
+			return assetcore.Fail(assetcore.CodeFailedPrecondition, "Trade=%s terms %+v don't match %+v.", t.ID, t, trade)
+		}
+		if now >= trade.Expiry {
+			return assetcore.Fail(assetcore.CodeFailedPrecondition, "Trade=%s expired at %v.", t.ID, trade.Expiry)
+		}
+	} else {
+		trade = t
+		trade.DocType = TradeDocType
+		trade.Status = TradePending
+		trade.Expiry = now + duration
+	}
+
+	// 本次确认的一方冻结自己一端，重复确认返回错误
+	confirmSeller := isSeller && !trade.SellerConfirmed
+	confirmBuyer := isBuyer && !trade.BuyerConfirmed
+	if !confirmSeller && !confirmBuyer {
+		return assetcore.Fail(assetcore.CodeFailedPrecondition, "Trade=%s already confirmed by caller.", t.ID)
+	}
+	amount := t.Count * t.Price
+	sumS, sumB := int64(0), int64(0)
+	if confirmSeller {
+		sumS, err = cache.addHolding(seller.ID, t.Issuer, t.Code, -t.Count)
+		if err != nil {
+			return assetcore.Errorf("%s.", err)
+		}
+		trade.SellerConfirmed = true
+	}
+	if confirmBuyer {
+		_, err = cache.addBalance(buyer.ID, -amount)
+		if err != nil {
+			return assetcore.Errorf("%s.", err)
+		}
+		trade.BuyerConfirmed = true
+	}
+
+	event := Event{Type: "InstructTrade", From: t.Seller, To: t.Buyer, Issuer: t.Issuer, Code: t.Code, Amount: t.Count, Price: t.Price}
+	if trade.SellerConfirmed && trade.BuyerConfirmed {
+		sumB, err = cache.addHolding(buyer.ID, t.Issuer, t.Code, t.Count)
+		if err == nil {
+			_, err = cache.addBalance(seller.ID, amount)
+		}
+		if err != nil {
+			return assetcore.Errorf("%s.", err)
+		}
+		if !confirmSeller {
+			sumS, _, err = cache.getHolding(seller.ID, t.Issuer, t.Code)
+			if err != nil {
+				return assetcore.Error(err)
+			}
+		}
+		trade.Status = TradeSettled
+		event = Event{
+			Type:        "SettleTrade",
+			From:        seller.ID,
+			To:          buyer.ID,
+			Issuer:      t.Issuer,
+			Code:        t.Code,
+			Amount:      t.Count,
+			Price:       t.Price,
+			FromBalance: sumS,
+			ToBalance:   sumB,
+			FromCash:    seller.Balance,
+			ToCash:      buyer.Balance,
+		}
+	}
+
+	err = cache.flush()
+	if err != nil {
+		return assetcore.Error(err)
+	}
+	err = assetcore.Save(stub, key, trade)
+	if err != nil {
+		return assetcore.Errorf("save trade=%+v error:%s", trade, err)
+	}
+
+	err = c.emit(stub, event)
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+	return c.tradeResponse(trade)
+}
+
+// 撤销未交收的成交，退回已冻结的资产和现金
+// 参数：成交编号
+// 卖方或买方可随时撤销
+func (c *SimpleChaincode) cancelTrade(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== cancelTrade ==========")
+	if len(args) < 1 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 1")
+	}
+	id := args[0]
+	if id == "" {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "cancel trade arguments error: id can't be nil.")
+	}
+
+	_, trade, isExist, key, err := c.checkTrade(stub, id)
+	if err != nil {
+		return assetcore.Errorf("Check trade=%s error:%s", id, err)
+	} else if !isExist {
+		return assetcore.Fail(assetcore.CodeNotFound, "Trade=%s not exists.", id)
+	}
+	if trade.Status != TradePending {
+		return assetcore.Fail(assetcore.CodeFailedPrecondition, "Trade=%s already %s.", id, trade.Status)
+	}
+
+	cache := newStateCache(c, stub)
+	seller, err := cache.getAccount(trade.Seller)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+	buyer, err := cache.getAccount(trade.Buyer)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+	if assetcore.CheckOwner(stub, seller.Owner, seller.ID) != nil && assetcore.CheckOwner(stub, buyer.Owner, buyer.ID) != nil {
+		return assetcore.Fail(assetcore.CodeUnauthorized, "Permission denied:caller is neither the seller=%s nor the buyer=%s", trade.Seller, trade.Buyer)
+	}
+
+	if trade.SellerConfirmed {
+		_, err = cache.addHolding(seller.ID, trade.Issuer, trade.Code, trade.Count)
+		if err != nil {
+			return assetcore.Error(err)
+		}
+	}
+	if trade.BuyerConfirmed {
+		_, err = cache.addBalance(buyer.ID, trade.Count*trade.Price)
+		if err != nil {
+			return assetcore.Error(err)
+		}
+	}
+	err = cache.flush()
+	if err != nil {
+		return assetcore.Error(err)
+	}
+	err = stub.DelState(key)
+	if err != nil {
+		return assetcore.Errorf("DelState error:%s", err)
+	}
+
+	err = c.emit(stub, Event{Type: "CancelTrade", From: trade.Seller, To: trade.Buyer, Issuer: trade.Issuer, Code: trade.Code, Amount: trade.Count, Price: trade.Price})
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+	return c.tradeResponse(trade)
+}
+
+// 判断a*b是否溢出，a、b均大于0
+func mulOverflow(a, b int64) bool {
+	return a > math.MaxInt64/b
+}
+
+// 判断资产是否有待交收的成交
+func (c *SimpleChaincode) hasPendingTrades(stub shim.ChaincodeStubInterface, issuer, code string) (bool, error) {
+	tradesIterator, err := stub.GetStateByPartialCompositeKey(TradeObjectType, []string{})
+	if err != nil {
+		return false, err
+	}
+	defer tradesIterator.Close()
+	for tradesIterator.HasNext() {
+		kv, err := tradesIterator.Next()
+		if err != nil {
+			return false, err
+		}
+		var t Trade
+		err = json.Unmarshal(kv.Value, &t)
+		if err != nil {
+			return false, err
+		}
+		if t.Status == TradePending && t.Issuer == issuer && t.Code == code {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (c *SimpleChaincode) tradeResponse(trade Trade) pb.Response {
+	b, err := json.Marshal(trade)
+	if err != nil {
+		return assetcore.Errorf("Marshal trade error:%s", err)
+	}
+	return shim.Success(b)
+}
+
+func (c *SimpleChaincode) checkTrade(stub shim.ChaincodeStubInterface, id string) (b []byte, t Trade, isExist bool, key string, err error) {
+	key, err = stub.CreateCompositeKey(TradeObjectType, []string{id})
+	if err != nil {
+		return b, t, t.ID != "", key, err
+	}
+	b, err = stub.GetState(key)
+	if err != nil {
+		return b, t, t.ID != "", key, err
+	}
+	if b != nil && len(b) > 0 {
+		err = json.Unmarshal(b, &t)
+		t.DocType = TradeDocType
+	}
+	return b, t, t.ID != "", key, err
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/ChainNova/samples/chaincode/asset/assetcore"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// SplitSummary 拆分/合并结果
+// 每条记录的数量按 数量*Numerator/Denominator 向下取整，不足1单位的部分舍弃
+type SplitSummary struct {
+	Issuer      string `json:"issuer"`      //资产发行机构
+	Code        string `json:"code"`        //资产代码
+	Numerator   int64  `json:"numerator"`   //拆分比例分子
+	Denominator int64  `json:"denominator"` //拆分比例分母
+	Unissued    int64  `json:"unissued"`    //调整后未发行的数量
+	Holders     int    `json:"holders"`     //调整的持仓数
+	Holds       int    `json:"holds"`       //调整的冻结数
+	Before      int64  `json:"before"`      //调整前帐户持有及冻结的总量
+	After       int64  `json:"after"`       //调整后帐户持有及冻结的总量
+	Fractional  int64  `json:"fractional"`  //按总量计算应得与逐条取整后合计的差额，即舍弃的数量
+	Vestings    int    `json:"vestings"`    //调整的归属计划数
+	Allowances  int    `json:"allowances"`  //调整的授权额度数
+	Pending     int    `json:"pending"`     //调整的待审批转移数
+}
+
+// 按比例拆分或合并资产，只有管理员可以操作，资产有未成交挂单或待交收成交时不能操作
+// 如2拆1为2、1，10合1为1、10；赎回价格按反比例调整
+// 参数：issuer、code、分子、分母
+func (c *SimpleChaincode) splitAsset(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== splitAsset ==========")
+	if len(args) < 4 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 4")
+	}
+
+	issuer := args[0]
+	code := args[1]
+	numerator, err1 := strconv.ParseInt(args[2], 10, 64)
+	denominator, err2 := strconv.ParseInt(args[3], 10, 64)
+	if issuer == "" || code == "" || err1 != nil || err2 != nil || numerator <= 0 || denominator <= 0 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "split asset arguments error: issuer and code can't be nil; numerator and denominator must be numbers and greater than 0.")
+	}
+	summary := SplitSummary{Issuer: issuer, Code: code, Numerator: numerator, Denominator: denominator}
+
+	err := assetcore.CheckAdmin(stub)
+	if err != nil {
+		return assetcore.Fail(assetcore.CodeUnauthorized, "Permission denied:%s", err)
+	}
+
+	_, asset, isExist, key, err := assetcore.CheckAsset(stub, issuer, code)
+	if err != nil {
+		return assetcore.Errorf("Check asset issuer=%s&code=%s error:%s", issuer, code, err)
+	} else if !isExist {
+		return assetcore.Error(assetcore.AssetNotFound(issuer, code))
+	}
+
+	// 挂单的价格与数量无法同时按比例精确调整，需要先撤单
+	bookIterator, err := stub.GetStateByPartialCompositeKey(OrderBookObjectType, []string{issuer, code})
+	if err != nil {
+		return assetcore.Errorf("GetStateByPartialCompositeKey error:%s", err)
+	}
+	hasOrders := bookIterator.HasNext()
+	bookIterator.Close()
+	if hasOrders {
+		return assetcore.Fail(assetcore.CodeFailedPrecondition, "Asset issuer=%s&code=%s has open orders, cancel them before split.", issuer, code)
+	}
+	// 待交收成交已按成交条款冻结资产和现金，需要先交收或撤销
+	hasTrades, err := c.hasPendingTrades(stub, issuer, code)
+	if err != nil {
+		return assetcore.Errorf("Check pending trades of issuer=%s&code=%s error:%s", issuer, code, err)
+	} else if hasTrades {
+		return assetcore.Fail(assetcore.CodeFailedPrecondition, "Asset issuer=%s&code=%s has pending trades, settle or cancel them before split.", issuer, code)
+	}
+
+	err = c.rescaleAsset(stub, &summary, &asset)
+	if err != nil {
+		return assetcore.Errorf("Split asset issuer=%s&code=%s error:%s", issuer, code, err)
+	}
+	err = assetcore.Save(stub, key, asset)
+	if err != nil {
+		return assetcore.Errorf("save asset=%+v error:%s", asset, err)
+	}
+
+	err = c.emit(stub, Event{Type: "SplitAsset", Issuer: issuer, Code: code, Amount: summary.After, Supply: asset.Amount, Split: &summary})
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+
+	b, err := json.Marshal(summary)
+	if err != nil {
+		return assetcore.Errorf("Marshal split summary error:%s", err)
+	}
+	return shim.Success(b)
+}
+
+// 调整资产记录、所有持仓、冻结、归属计划、授权额度、待审批转移及审批阈值中该资产的数量
+func (c *SimpleChaincode) rescaleAsset(stub shim.ChaincodeStubInterface, s *SplitSummary, asset *Asset) error {
+	scale := func(v int64) (int64, error) {
+		if mulOverflow(v, s.Numerator) {
+			return 0, assetcore.NewError(assetcore.CodeInvalidArgument, "amount=%v overflows after split", v)
+		}
+		return v * s.Numerator / s.Denominator, nil
+	}
+
+	// 持仓以AccountAsset~id~issuer~code为key，帐户在前，需要遍历后按issuer、code筛选
+	var err error
+	s.Holders, err = c.rescaleRecords(stub, AccountAssetObjectType, func(key string, b []byte) (interface{}, bool, error) {
+		_, parts, err := stub.SplitCompositeKey(key)
+		if err != nil || len(parts) < 3 || parts[1] != s.Issuer || parts[2] != s.Code {
+			return nil, false, err
+		}
+		count, err := assetcore.ParseHolding(b)
+		if err != nil {
+			return nil, false, err
+		}
+		amount, err := scale(count)
+		s.Before += count
+		s.After += amount
+		return Holding{DocType: HoldingDocType, ID: parts[0], Issuer: parts[1], Code: parts[2], Amount: amount}, true, err
+	})
+	if err != nil {
+		return err
+	}
+
+	s.Holds, err = c.rescaleRecords(stub, assetcore.HoldObjectType, func(key string, b []byte) (interface{}, bool, error) {
+		var h assetcore.Hold
+		err := json.Unmarshal(b, &h)
+		if err != nil || h.Issuer != s.Issuer || h.Code != s.Code {
+			return nil, false, err
+		}
+		amount, err := scale(h.Amount)
+		s.Before += h.Amount
+		s.After += amount
+		h.Amount = amount
+		return h, true, err
+	})
+	if err != nil {
+		return err
+	}
+
+	// 按总量计算应得的数量，与逐条取整后的合计比较
+	expected, err := scale(s.Before)
+	if err != nil {
+		return err
+	}
+	s.Fractional = expected - s.After
+
+	s.Vestings, err = c.rescaleRecords(stub, assetcore.VestingObjectType, func(key string, b []byte) (interface{}, bool, error) {
+		var v assetcore.Vesting
+		err := json.Unmarshal(b, &v)
+		if err != nil || v.Issuer != s.Issuer || v.Code != s.Code {
+			return nil, false, err
+		}
+		v.Total, err = scale(v.Total)
+		return v, true, err
+	})
+	if err != nil {
+		return err
+	}
+
+	s.Allowances, err = c.rescaleRecords(stub, assetcore.AllowanceObjectType, func(key string, b []byte) (interface{}, bool, error) {
+		var a assetcore.Allowance
+		err := json.Unmarshal(b, &a)
+		if err != nil || a.Issuer != s.Issuer || a.Code != s.Code {
+			return nil, false, err
+		}
+		a.Amount, err = scale(a.Amount)
+		return a, true, err
+	})
+	if err != nil {
+		return err
+	}
+
+	s.Pending, err = c.rescaleRecords(stub, assetcore.PendingTransferObjectType, func(key string, b []byte) (interface{}, bool, error) {
+		var t assetcore.PendingTransfer
+		err := json.Unmarshal(b, &t)
+		if err != nil || t.Issuer != s.Issuer || t.Code != s.Code {
+			return nil, false, err
+		}
+		t.Amount, err = scale(t.Amount)
+		return t, true, err
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = c.rescaleRecords(stub, assetcore.ApprovalPolicyObjectType, func(key string, b []byte) (interface{}, bool, error) {
+		var p assetcore.ApprovalPolicy
+		err := json.Unmarshal(b, &p)
+		if err != nil || p.Issuer != s.Issuer || p.Code != s.Code {
+			return nil, false, err
+		}
+		p.Threshold, err = scale(p.Threshold)
+		return p, true, err
+	})
+	if err != nil {
+		return err
+	}
+
+	// 按笔收取的手续费数量，不足1时保留1；按比例收取的不变
+	_, err = c.rescaleRecords(stub, assetcore.FeeObjectType, func(key string, b []byte) (interface{}, bool, error) {
+		var f assetcore.FeeSchedule
+		err := json.Unmarshal(b, &f)
+		if err != nil || f.Issuer != s.Issuer || f.Code != s.Code || f.Mode != assetcore.FeeModeFlat {
+			return nil, false, err
+		}
+		f.Rate, err = scale(f.Rate)
+		if err == nil && f.Rate == 0 {
+			f.Rate = 1
+		}
+		return f, true, err
+	})
+	if err != nil {
+		return err
+	}
+
+	// 资产记录：未发行及赎回数量按比例调整，发行数量按调整后的总量重新计算，舍弃的数量不再计入
+	asset.Amount, err = scale(asset.Amount)
+	if err != nil {
+		return err
+	}
+	asset.Redeemed, err = scale(asset.Redeemed)
+	if err != nil {
+		return err
+	}
+	asset.Issued = asset.Redeemed + asset.Amount + s.After
+	if asset.RedemptionPrice > 0 && mulOverflow(asset.RedemptionPrice, s.Denominator) {
+		return assetcore.NewError(assetcore.CodeInvalidArgument, "redemption price=%v overflows after split", asset.RedemptionPrice)
+	}
+	asset.RedemptionPrice = asset.RedemptionPrice * s.Denominator / s.Numerator
+	s.Unissued = asset.Amount
+	return nil
+}
+
+// 遍历某类复合key的所有记录，rescale返回需要保存的新记录
+func (c *SimpleChaincode) rescaleRecords(stub shim.ChaincodeStubInterface, objectType string, rescale func(string, []byte) (interface{}, bool, error)) (int, error) {
+	iterator, err := stub.GetStateByPartialCompositeKey(objectType, []string{})
+	if err != nil {
+		return 0, fmt.Errorf("GetStateByPartialCompositeKey error:%s", err)
+	}
+	defer iterator.Close()
+
+	count := 0
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return count, fmt.Errorf("Iterate %s error:%s", objectType, err)
+		}
+		v, changed, err := rescale(kv.Key, kv.Value)
+		if err != nil {
+			return count, fmt.Errorf("rescale %s error:%s", kv.Key, err)
+		} else if !changed {
+			continue
+		}
+		err = assetcore.Save(stub, kv.Key, v)
+		if err != nil {
+			return count, fmt.Errorf("save %s error:%s", kv.Key, err)
+		}
+		count++
+	}
+	return count, nil
+}
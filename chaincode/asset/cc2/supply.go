@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/ChainNova/samples/chaincode/asset/assetcore"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// SupplyAudit 一类资产的对账结果
+type SupplyAudit struct {
+	Issuer      string `json:"issuer"`      //资产发行机构
+	Code        string `json:"code"`        //资产代码
+	Issued      int64  `json:"issued"`      //累计发行数量
+	Redeemed    int64  `json:"redeemed"`    //累计赎回销毁数量
+	Unissued    int64  `json:"unissued"`    //未发行数量
+	Circulating int64  `json:"circulating"` //帐户持有数量
+	Held        int64  `json:"held"`        //冻结中的数量
+	Ordered     int64  `json:"ordered"`     //卖单冻结的数量
+	Traded      int64  `json:"traded"`      //待交收成交中卖方冻结的数量
+	Discrepancy int64  `json:"discrepancy"` //unissued+circulating+held+ordered+traded-(issued-redeemed)，不为0表示账实不符
+}
+
+// 资产总量对账
+// 遍历所有资产、帐户持有量、冻结、卖单及待交收成交，按发行机构和资产代码汇总，与发行总量记录核对
+func (c *SimpleChaincode) auditSupply(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== auditSupply ==========")
+
+	audits := map[string]*SupplyAudit{}
+	get := func(issuer, code string) *SupplyAudit {
+		k := issuer + "~" + code
+		if _, ok := audits[k]; !ok {
+			audits[k] = &SupplyAudit{Issuer: issuer, Code: code}
+		}
+		return audits[k]
+	}
+	iterate := func(objectType string, f func(parts []string, value []byte) error) error {
+		it, err := stub.GetStateByPartialCompositeKey(objectType, []string{})
+		if err != nil {
+			return err
+		}
+		defer it.Close()
+		for it.HasNext() {
+			kv, err := it.Next()
+			if err != nil {
+				return err
+			}
+			_, parts, err := stub.SplitCompositeKey(kv.Key)
+			if err != nil {
+				return err
+			}
+			err = f(parts, kv.Value)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	err := iterate(assetcore.AssetObjectType, func(parts []string, value []byte) error {
+		var asset Asset
+		err := json.Unmarshal(value, &asset)
+		if err != nil {
+			return fmt.Errorf("Unmarshal asset=%s error:%s", string(value), err)
+		}
+		a := get(asset.Issuer, asset.Code)
+		a.Issued = asset.Issued
+		a.Redeemed = asset.Redeemed
+		a.Unissued = asset.Amount
+		return nil
+	})
+	if err == nil {
+		err = iterate(AccountAssetObjectType, func(parts []string, value []byte) error {
+			count, err := assetcore.ParseHolding(value)
+			if err != nil {
+				return fmt.Errorf("Parse holding=%s error:%s", string(value), err)
+			}
+			get(parts[1], parts[2]).Circulating += count
+			return nil
+		})
+	}
+	if err == nil {
+		err = iterate(assetcore.HoldObjectType, func(parts []string, value []byte) error {
+			var h assetcore.Hold
+			err := json.Unmarshal(value, &h)
+			if err != nil {
+				return fmt.Errorf("Unmarshal hold=%s error:%s", string(value), err)
+			}
+			get(h.Issuer, h.Code).Held += h.Amount
+			return nil
+		})
+	}
+	if err == nil {
+		err = iterate(OrderBookObjectType, func(parts []string, value []byte) error {
+			var o Order
+			err := json.Unmarshal(value, &o)
+			if err != nil {
+				return fmt.Errorf("Unmarshal order=%s error:%s", string(value), err)
+			}
+			if o.Side == OrderSell {
+				get(o.Issuer, o.Code).Ordered += o.Remaining
+			}
+			return nil
+		})
+	}
+	if err == nil {
+		err = iterate(TradeObjectType, func(parts []string, value []byte) error {
+			var t Trade
+			err := json.Unmarshal(value, &t)
+			if err != nil {
+				return fmt.Errorf("Unmarshal trade=%s error:%s", string(value), err)
+			}
+			if t.Status == TradePending && t.SellerConfirmed {
+				get(t.Issuer, t.Code).Traded += t.Count
+			}
+			return nil
+		})
+	}
+	if err != nil {
+		return assetcore.Errorf("Audit supply error:%s", err)
+	}
+
+	report := struct {
+		Assets        []SupplyAudit `json:"assets"`
+		Discrepancies []SupplyAudit `json:"discrepancies"`
+	}{Assets: []SupplyAudit{}, Discrepancies: []SupplyAudit{}}
+
+	keys := []string{}
+	for k := range audits {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		a := audits[k]
+		a.Discrepancy = a.Unissued + a.Circulating + a.Held + a.Ordered + a.Traded - (a.Issued - a.Redeemed)
+		report.Assets = append(report.Assets, *a)
+		if a.Discrepancy != 0 {
+			report.Discrepancies = append(report.Discrepancies, *a)
+		}
+	}
+
+	b, err := json.Marshal(report)
+	if err != nil {
+		return assetcore.Errorf("Marshal report error:%s", err)
+	}
+	return shim.Success(b)
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/ChainNova/samples/chaincode/asset/assetcore"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// 按归属计划从未发行的资产中授予，只有发行机构可以操作
+// 参数：帐户、issuer、code、数量、锁定期（秒）、归属总时长（秒）、开始时间（秒，可选，默认当前交易时间）
+func (c *SimpleChaincode) grantVested(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== grantVested ==========")
+	if len(args) < 6 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 6")
+	}
+
+	v := assetcore.Vesting{
+		DocType: assetcore.VestingDocType,
+		ID:      args[0],
+		Issuer:  args[1],
+		Code:    args[2],
+	}
+	count, err1 := strconv.ParseInt(args[3], 10, 64)
+	cliff, err2 := strconv.ParseInt(args[4], 10, 64)
+	duration, err3 := strconv.ParseInt(args[5], 10, 64)
+	var err4 error
+	if len(args) > 6 {
+		v.Start, err4 = strconv.ParseInt(args[6], 10, 64)
+	}
+	if v.ID == "" || v.Issuer == "" || v.Code == "" || err1 != nil || err2 != nil || err3 != nil || err4 != nil || count <= 0 || duration <= 0 || cliff < 0 || cliff > duration {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "grant vested arguments error: account, issuer and code can't be nil; count and duration must be numbers and greater than 0; cliff must be between 0 and duration.")
+	}
+	v.Total = count
+	v.Cliff = cliff
+	v.Duration = duration
+
+	err := assetcore.CheckIssuer(stub, v.Issuer)
+	if err != nil {
+		return assetcore.Fail(assetcore.CodeUnauthorized, "Permission denied:%s", err)
+	}
+
+	err = assetcore.CheckActive(stub, v.Issuer, v.Code, v.ID)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+	// 接收帐户必须有资格持有该资产
+	err = assetcore.CheckEligible(stub, v.Issuer, v.Code, v.ID)
+	if err != nil {
+		return assetcore.Errorf("%s.", err)
+	}
+
+	_, _, isExist, err := assetcore.CheckCashAccount(stub, v.ID)
+	if err != nil {
+		return assetcore.Errorf("Check account=%s error:%s", v.ID, err)
+	} else if !isExist {
+		return assetcore.Error(assetcore.AccountNotFound(v.ID))
+	}
+
+	_, asset, isExist, assetKey, err := assetcore.CheckAsset(stub, v.Issuer, v.Code)
+	if err != nil {
+		return assetcore.Errorf("Check asset issuer=%s&code=%s error:%s", v.Issuer, v.Code, err)
+	} else if !isExist {
+		return assetcore.Error(assetcore.AssetNotFound(v.Issuer, v.Code))
+	}
+	if asset.Amount < count {
+		return assetcore.Fail(assetcore.CodeInsufficientFunds, "Asset amount=%v < grant count=%v.", asset.Amount, count)
+	}
+
+	now, err := assetcore.TxTime(stub)
+	if err != nil {
+		return assetcore.Errorf("GetTxTimestamp error:%s", err)
+	}
+	if v.Start == 0 {
+		v.Start = now
+	}
+
+	// 每个帐户的每类资产只有一个归属计划，原计划全部解锁后才能再次授予
+	old, key, err := assetcore.CheckVesting(stub, v.ID, v.Issuer, v.Code)
+	if err != nil {
+		return assetcore.Errorf("Check vesting of account=%s, asset issuer=%s&code=%s error:%s", v.ID, v.Issuer, v.Code, err)
+	} else if locked := old.Total - assetcore.VestedAmount(old, now); locked > 0 {
+		return assetcore.Fail(assetcore.CodeRestricted, "Account=%s issuer=%s&code=%s still has %v locked by vesting.", v.ID, v.Issuer, v.Code, locked)
+	}
+
+	asset.Amount = asset.Amount - count
+	err = assetcore.Save(stub, assetKey, asset)
+	if err != nil {
+		return assetcore.Errorf("save asset=%+v error:%s", asset, err)
+	}
+
+	sum, err := c.store.Holding(stub, v.ID, v.Issuer, v.Code)
+	if err != nil {
+		return assetcore.Errorf("Check account=%s, asset issuer=%s&code=%s error:%s", v.ID, v.Issuer, v.Code, err)
+	}
+	err = c.store.SetHolding(stub, v.ID, v.Issuer, v.Code, sum+count)
+	if err != nil {
+		return assetcore.Errorf("PutState error:%s", err)
+	}
+
+	err = assetcore.Save(stub, key, v)
+	if err != nil {
+		return assetcore.Errorf("save vesting=%+v error:%s", v, err)
+	}
+
+	err = c.emit(stub, Event{Type: "GrantVested", To: v.ID, Issuer: v.Issuer, Code: v.Code, Amount: count, ToBalance: sum + count, Supply: asset.Amount})
+	if err != nil {
+		return assetcore.Errorf("SetEvent error:%s", err)
+	}
+
+	return shim.Success(nil)
+}
+
+// 查询归属状态
+// 参数：帐户、issuer、code
+func (c *SimpleChaincode) vestingStatus(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	fmt.Println("=========== vestingStatus ==========")
+	if len(args) < 3 {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "Incorrect number of arguments. Expecting atleast 3")
+	}
+
+	id := args[0]
+	issuer := args[1]
+	code := args[2]
+	if id == "" || issuer == "" || code == "" {
+		return assetcore.Fail(assetcore.CodeInvalidArgument, "vesting status arguments error: account, issuer and code can't be nil.")
+	}
+
+	v, _, err := assetcore.CheckVesting(stub, id, issuer, code)
+	if err != nil {
+		return assetcore.Errorf("Check vesting of account=%s, asset issuer=%s&code=%s error:%s", id, issuer, code, err)
+	}
+	sum, err := c.store.Holding(stub, id, issuer, code)
+	if err != nil {
+		return assetcore.Errorf("Check account=%s, asset issuer=%s&code=%s error:%s", id, issuer, code, err)
+	}
+	now, err := assetcore.TxTime(stub)
+	if err != nil {
+		return assetcore.Errorf("GetTxTimestamp error:%s", err)
+	}
+
+	b, err := json.Marshal(assetcore.NewVestingStatus(v, sum, now))
+	if err != nil {
+		return assetcore.Errorf("Marshal vesting status error:%s", err)
+	}
+	return shim.Success(b)
+}
@@ -0,0 +1,487 @@
+// Package memstub 提供不依赖peer的内存ChaincodeStubInterface实现，用于测试chaincode
+//
+// 与shim.MockStub不同，Stub按交易提交写集：交易内的写入对本交易的读取不可见，
+// 返回错误（Status >= 400）的交易不修改状态，与peer上的行为一致。
+// 支持复合key、范围及部分复合key查询（含分页）、key历史、交易ID、交易时间、调用者身份及事件。
+// 不支持CouchDB富查询、私有数据及跨chaincode调用。
+package memstub
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/ledger/queryresult"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+const (
+	minUnicodeRuneValue   = rune(0)      //U+0000
+	maxUnicodeRuneValue   = utf8.MaxRune //U+10FFFF
+	compositeKeyNamespace = "\x00"
+	emptyKeySubstitute    = "\x01"
+)
+
+// ErrNotSupported 内存stub不支持的操作
+var ErrNotSupported = errors.New("memstub: not supported")
+
+// Stub 内存中的chaincode stub
+type Stub struct {
+	Name      string               //chaincode名称
+	ChannelID string               //通道名称
+	Now       time.Time            //下一笔交易的交易时间
+	Creator   []byte               //下一笔交易的提交者身份，见NewIdentity
+	Events    []*pb.ChaincodeEvent //已提交交易发出的事件
+
+	cc      shim.Chaincode
+	state   map[string][]byte
+	history map[string][]*queryresult.KeyModification
+	txCount int
+
+	// 当前交易
+	args      [][]byte
+	txID      string
+	txTime    *timestamp.Timestamp
+	writes    map[string][]byte //nil为删除
+	writeKeys []string
+	event     *pb.ChaincodeEvent
+}
+
+// New 创建内存stub
+func New(name string, cc shim.Chaincode) *Stub {
+	return &Stub{
+		Name:    name,
+		Now:     time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC),
+		cc:      cc,
+		state:   map[string][]byte{},
+		history: map[string][]*queryresult.KeyModification{},
+	}
+}
+
+// As 设置下一笔交易的提交者身份
+func (s *Stub) As(creator []byte) *Stub {
+	s.Creator = creator
+	return s
+}
+
+// Advance 交易时间前进d
+func (s *Stub) Advance(d time.Duration) {
+	s.Now = s.Now.Add(d)
+}
+
+// Init 以args为参数执行一笔Init交易
+func (s *Stub) Init(args ...string) pb.Response {
+	s.begin(args)
+	return s.end(s.cc.Init(s))
+}
+
+// Invoke 以args为参数执行一笔Invoke交易
+func (s *Stub) Invoke(args ...string) pb.Response {
+	s.begin(args)
+	return s.end(s.cc.Invoke(s))
+}
+
+// Upgrade 将chaincode升级为cc，并以args为参数执行一笔Init交易，已提交的状态保留
+func (s *Stub) Upgrade(cc shim.Chaincode, args ...string) pb.Response {
+	s.cc = cc
+	return s.Init(args...)
+}
+
+// State 获取已提交的状态，不存在时返回nil
+func (s *Stub) State(key string) []byte {
+	return s.state[key]
+}
+
+// LastEvent 获取最后一个已提交的事件，没有时返回nil
+func (s *Stub) LastEvent() *pb.ChaincodeEvent {
+	if len(s.Events) == 0 {
+		return nil
+	}
+	return s.Events[len(s.Events)-1]
+}
+
+func (s *Stub) begin(args []string) {
+	s.txCount++
+	s.txID = fmt.Sprintf("tx%06d", s.txCount)
+	s.txTime, _ = ptypes.TimestampProto(s.Now)
+	s.args = make([][]byte, len(args))
+	for i, a := range args {
+		s.args[i] = []byte(a)
+	}
+	s.writes = map[string][]byte{}
+	s.writeKeys = nil
+	s.event = nil
+}
+
+// 交易成功时提交写集及事件
+func (s *Stub) end(resp pb.Response) pb.Response {
+	if resp.Status >= shim.ERRORTHRESHOLD {
+		return resp
+	}
+	for _, k := range s.writeKeys {
+		v := s.writes[k]
+		if v == nil {
+			delete(s.state, k)
+		} else {
+			s.state[k] = v
+		}
+		s.history[k] = append(s.history[k], &queryresult.KeyModification{
+			TxId:      s.txID,
+			Value:     v,
+			Timestamp: s.txTime,
+			IsDelete:  v == nil,
+		})
+	}
+	if s.event != nil {
+		s.Events = append(s.Events, s.event)
+	}
+	return resp
+}
+
+// GetArgs ...
+func (s *Stub) GetArgs() [][]byte {
+	return s.args
+}
+
+// GetStringArgs ...
+func (s *Stub) GetStringArgs() []string {
+	strargs := make([]string, 0, len(s.args))
+	for _, a := range s.args {
+		strargs = append(strargs, string(a))
+	}
+	return strargs
+}
+
+// GetFunctionAndParameters ...
+func (s *Stub) GetFunctionAndParameters() (function string, params []string) {
+	allargs := s.GetStringArgs()
+	if len(allargs) >= 1 {
+		function = allargs[0]
+		params = allargs[1:]
+	}
+	return
+}
+
+// GetArgsSlice ...
+func (s *Stub) GetArgsSlice() ([]byte, error) {
+	res := []byte{}
+	for _, a := range s.args {
+		res = append(res, a...)
+	}
+	return res, nil
+}
+
+// GetTxID ...
+func (s *Stub) GetTxID() string {
+	return s.txID
+}
+
+// GetChannelID ...
+func (s *Stub) GetChannelID() string {
+	return s.ChannelID
+}
+
+// InvokeChaincode 不支持跨chaincode调用
+func (s *Stub) InvokeChaincode(chaincodeName string, args [][]byte, channel string) pb.Response {
+	return shim.Error(ErrNotSupported.Error())
+}
+
+// GetState 读取已提交的状态，本交易的写入不可见
+func (s *Stub) GetState(key string) ([]byte, error) {
+	return s.state[key], nil
+}
+
+// PutState ...
+func (s *Stub) PutState(key string, value []byte) error {
+	if key == "" {
+		return errors.New("key must not be an empty string")
+	}
+	if value == nil {
+		value = []byte{}
+	}
+	s.write(key, value)
+	return nil
+}
+
+// DelState ...
+func (s *Stub) DelState(key string) error {
+	s.write(key, nil)
+	return nil
+}
+
+func (s *Stub) write(key string, value []byte) {
+	if _, ok := s.writes[key]; !ok {
+		s.writeKeys = append(s.writeKeys, key)
+	}
+	s.writes[key] = value
+}
+
+// SetStateValidationParameter 不支持key级背书策略
+func (s *Stub) SetStateValidationParameter(key string, ep []byte) error {
+	return ErrNotSupported
+}
+
+// GetStateValidationParameter 不支持key级背书策略
+func (s *Stub) GetStateValidationParameter(key string) ([]byte, error) {
+	return nil, ErrNotSupported
+}
+
+// GetStateByRange 范围查询，startKey为空时不包含复合key
+func (s *Stub) GetStateByRange(startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	it, _, err := s.GetStateByRangeWithPagination(startKey, endKey, 0, "")
+	return it, err
+}
+
+// GetStateByRangeWithPagination 分页范围查询，书签为下一页的起始key
+func (s *Stub) GetStateByRangeWithPagination(startKey, endKey string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	if startKey == "" {
+		startKey = emptyKeySubstitute
+	}
+	if err := validateSimpleKeys(startKey, endKey); err != nil {
+		return nil, nil, err
+	}
+	return s.rangeQuery(startKey, endKey, pageSize, bookmark)
+}
+
+// GetStateByPartialCompositeKey ...
+func (s *Stub) GetStateByPartialCompositeKey(objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	it, _, err := s.GetStateByPartialCompositeKeyWithPagination(objectType, keys, 0, "")
+	return it, err
+}
+
+// GetStateByPartialCompositeKeyWithPagination ...
+func (s *Stub) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	partialKey, err := s.CreateCompositeKey(objectType, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.rangeQuery(partialKey, partialKey+string(maxUnicodeRuneValue), pageSize, bookmark)
+}
+
+// 按key顺序返回[startKey, endKey)中已提交的状态，endKey为空时不限制
+func (s *Stub) rangeQuery(startKey, endKey string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	if bookmark != "" && bookmark > startKey {
+		startKey = bookmark
+	}
+
+	keys := []string{}
+	for k := range s.state {
+		if k >= startKey && (endKey == "" || k < endKey) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	metadata := &pb.QueryResponseMetadata{}
+	if pageSize > 0 && len(keys) > int(pageSize) {
+		metadata.Bookmark = keys[pageSize]
+		keys = keys[:pageSize]
+	}
+	metadata.FetchedRecordsCount = int32(len(keys))
+
+	kvs := make([]*queryresult.KV, 0, len(keys))
+	for _, k := range keys {
+		kvs = append(kvs, &queryresult.KV{Namespace: s.Name, Key: k, Value: s.state[k]})
+	}
+	return &stateIterator{kvs: kvs}, metadata, nil
+}
+
+// CreateCompositeKey ...
+func (s *Stub) CreateCompositeKey(objectType string, attributes []string) (string, error) {
+	if err := validateCompositeKeyAttribute(objectType); err != nil {
+		return "", err
+	}
+	ck := compositeKeyNamespace + objectType + string(minUnicodeRuneValue)
+	for _, att := range attributes {
+		if err := validateCompositeKeyAttribute(att); err != nil {
+			return "", err
+		}
+		ck += att + string(minUnicodeRuneValue)
+	}
+	return ck, nil
+}
+
+// SplitCompositeKey ...
+func (s *Stub) SplitCompositeKey(compositeKey string) (string, []string, error) {
+	if !strings.HasPrefix(compositeKey, compositeKeyNamespace) {
+		return "", nil, fmt.Errorf("key=%q is not a composite key", compositeKey)
+	}
+	components := []string{}
+	componentIndex := 1
+	for i := 1; i < len(compositeKey); i++ {
+		if rune(compositeKey[i]) == minUnicodeRuneValue {
+			components = append(components, compositeKey[componentIndex:i])
+			componentIndex = i + 1
+		}
+	}
+	if len(components) == 0 {
+		return "", nil, fmt.Errorf("key=%q is not a composite key", compositeKey)
+	}
+	return components[0], components[1:], nil
+}
+
+// GetQueryResult 不支持CouchDB富查询
+func (s *Stub) GetQueryResult(query string) (shim.StateQueryIteratorInterface, error) {
+	return nil, ErrNotSupported
+}
+
+// GetQueryResultWithPagination 不支持CouchDB富查询
+func (s *Stub) GetQueryResultWithPagination(query string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	return nil, nil, ErrNotSupported
+}
+
+// GetHistoryForKey 按提交顺序返回key的历史
+func (s *Stub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+	return &historyIterator{mods: s.history[key]}, nil
+}
+
+// GetPrivateData 不支持私有数据
+func (s *Stub) GetPrivateData(collection, key string) ([]byte, error) {
+	return nil, ErrNotSupported
+}
+
+// PutPrivateData 不支持私有数据
+func (s *Stub) PutPrivateData(collection string, key string, value []byte) error {
+	return ErrNotSupported
+}
+
+// DelPrivateData 不支持私有数据
+func (s *Stub) DelPrivateData(collection, key string) error {
+	return ErrNotSupported
+}
+
+// SetPrivateDataValidationParameter 不支持私有数据
+func (s *Stub) SetPrivateDataValidationParameter(collection, key string, ep []byte) error {
+	return ErrNotSupported
+}
+
+// GetPrivateDataValidationParameter 不支持私有数据
+func (s *Stub) GetPrivateDataValidationParameter(collection, key string) ([]byte, error) {
+	return nil, ErrNotSupported
+}
+
+// GetPrivateDataByRange 不支持私有数据
+func (s *Stub) GetPrivateDataByRange(collection, startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	return nil, ErrNotSupported
+}
+
+// GetPrivateDataByPartialCompositeKey 不支持私有数据
+func (s *Stub) GetPrivateDataByPartialCompositeKey(collection, objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	return nil, ErrNotSupported
+}
+
+// GetPrivateDataQueryResult 不支持私有数据
+func (s *Stub) GetPrivateDataQueryResult(collection, query string) (shim.StateQueryIteratorInterface, error) {
+	return nil, ErrNotSupported
+}
+
+// GetCreator 返回As设置的提交者身份
+func (s *Stub) GetCreator() ([]byte, error) {
+	return s.Creator, nil
+}
+
+// GetTransient ...
+func (s *Stub) GetTransient() (map[string][]byte, error) {
+	return map[string][]byte{}, nil
+}
+
+// GetBinding ...
+func (s *Stub) GetBinding() ([]byte, error) {
+	return nil, nil
+}
+
+// GetDecorations ...
+func (s *Stub) GetDecorations() map[string][]byte {
+	return nil
+}
+
+// GetSignedProposal ...
+func (s *Stub) GetSignedProposal() (*pb.SignedProposal, error) {
+	return nil, ErrNotSupported
+}
+
+// GetTxTimestamp 返回交易开始时的Now
+func (s *Stub) GetTxTimestamp() (*timestamp.Timestamp, error) {
+	return s.txTime, nil
+}
+
+// SetEvent 设置交易的事件，每笔交易只保留最后一个
+func (s *Stub) SetEvent(name string, payload []byte) error {
+	if name == "" {
+		return errors.New("event name can not be nil string")
+	}
+	s.event = &pb.ChaincodeEvent{ChaincodeId: s.Name, TxId: s.txID, EventName: name, Payload: payload}
+	return nil
+}
+
+func validateCompositeKeyAttribute(str string) error {
+	if !utf8.ValidString(str) {
+		return fmt.Errorf("not a valid utf8 string: [%x]", str)
+	}
+	for index, runeValue := range str {
+		if runeValue == minUnicodeRuneValue || runeValue == maxUnicodeRuneValue {
+			return fmt.Errorf("input contains unicode %#U starting at position [%d]. %#U and %#U are not allowed in the input attribute of a composite key",
+				runeValue, index, minUnicodeRuneValue, maxUnicodeRuneValue)
+		}
+	}
+	return nil
+}
+
+func validateSimpleKeys(simpleKeys ...string) error {
+	for _, key := range simpleKeys {
+		if len(key) > 0 && key[0] == compositeKeyNamespace[0] {
+			return fmt.Errorf("first character of the key [%s] contains a null character which is not allowed", key)
+		}
+	}
+	return nil
+}
+
+// stateIterator 状态查询结果
+type stateIterator struct {
+	kvs []*queryresult.KV
+}
+
+func (it *stateIterator) HasNext() bool {
+	return len(it.kvs) > 0
+}
+
+func (it *stateIterator) Next() (*queryresult.KV, error) {
+	if len(it.kvs) == 0 {
+		return nil, errors.New("no more results")
+	}
+	kv := it.kvs[0]
+	it.kvs = it.kvs[1:]
+	return kv, nil
+}
+
+func (it *stateIterator) Close() error {
+	return nil
+}
+
+// historyIterator key历史查询结果
+type historyIterator struct {
+	mods []*queryresult.KeyModification
+}
+
+func (it *historyIterator) HasNext() bool {
+	return len(it.mods) > 0
+}
+
+func (it *historyIterator) Next() (*queryresult.KeyModification, error) {
+	if len(it.mods) == 0 {
+		return nil, errors.New("no more results")
+	}
+	m := it.mods[0]
+	it.mods = it.mods[1:]
+	return m, nil
+}
+
+func (it *historyIterator) Close() error {
+	return nil
+}
@@ -0,0 +1,171 @@
+package memstub
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+// kvChaincode 测试用chaincode：put k v、del k、get k、fail k v（写入后返回错误）
+type kvChaincode struct{}
+
+func (kvChaincode) Init(stub shim.ChaincodeStubInterface) pb.Response {
+	return shim.Success(nil)
+}
+
+func (kvChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
+	function, args := stub.GetFunctionAndParameters()
+	switch function {
+	case "put":
+		if err := stub.PutState(args[0], []byte(args[1])); err != nil {
+			return shim.Error(err.Error())
+		}
+		stub.SetEvent("put", []byte(args[0]))
+		return shim.Success(nil)
+	case "del":
+		stub.DelState(args[0])
+		return shim.Success(nil)
+	case "get":
+		b, _ := stub.GetState(args[0])
+		return shim.Success(b)
+	case "fail":
+		stub.PutState(args[0], []byte(args[1]))
+		return shim.Error("fail")
+	case "readback":
+		stub.PutState(args[0], []byte(args[1]))
+		b, _ := stub.GetState(args[0])
+		return shim.Success(b)
+	}
+	return shim.Error("unknown function " + function)
+}
+
+func TestTransactions(t *testing.T) {
+	s := New("kv", kvChaincode{})
+
+	tests := []struct {
+		name   string
+		args   []string
+		status int32
+		want   string
+	}{
+		{"put", []string{"put", "a", "1"}, shim.OK, ""},
+		{"get committed", []string{"get", "a"}, shim.OK, "1"},
+		{"failed tx is rolled back", []string{"fail", "a", "2"}, shim.ERROR, ""},
+		{"get after rollback", []string{"get", "a"}, shim.OK, "1"},
+		{"writes are not visible in the same tx", []string{"readback", "a", "3"}, shim.OK, "1"},
+		{"get after readback", []string{"get", "a"}, shim.OK, "3"},
+		{"del", []string{"del", "a"}, shim.OK, ""},
+		{"get deleted", []string{"get", "a"}, shim.OK, ""},
+	}
+	for _, tt := range tests {
+		resp := s.Invoke(tt.args...)
+		if resp.Status != tt.status || string(resp.Payload) != tt.want {
+			t.Errorf("%s: got status=%d payload=%q, want status=%d payload=%q", tt.name, resp.Status, resp.Payload, tt.status, tt.want)
+		}
+	}
+
+	it, err := s.GetHistoryForKey("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var values []string
+	for it.HasNext() {
+		m, _ := it.Next()
+		if m.IsDelete {
+			values = append(values, "<deleted>")
+		} else {
+			values = append(values, string(m.Value))
+		}
+	}
+	if got, want := len(values), 3; got != want {
+		t.Fatalf("history of a: got %v, want %d entries", values, want)
+	}
+	if values[0] != "1" || values[1] != "3" || values[2] != "<deleted>" {
+		t.Errorf("history of a: got %v", values)
+	}
+	if got := len(s.Events); got != 1 || s.LastEvent().EventName != "put" || s.LastEvent().TxId != "tx000001" {
+		t.Errorf("events: got %d, last %+v", got, s.LastEvent())
+	}
+}
+
+func TestCompositeKeys(t *testing.T) {
+	s := New("kv", kvChaincode{})
+	s.begin(nil)
+
+	key, err := s.CreateCompositeKey("Holding~id~code", []string{"xiaozhang", "A1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	objectType, attributes, err := s.SplitCompositeKey(key)
+	if err != nil || objectType != "Holding~id~code" || len(attributes) != 2 || attributes[0] != "xiaozhang" || attributes[1] != "A1" {
+		t.Fatalf("SplitCompositeKey(%q) = %q, %q, %v", key, objectType, attributes, err)
+	}
+	if _, err = s.CreateCompositeKey("Holding", []string{"a\x00b"}); err == nil {
+		t.Error("CreateCompositeKey accepted an attribute with U+0000")
+	}
+
+	for _, k := range [][]string{{"xiaozhang", "A1"}, {"xiaozhang", "B1"}, {"xiaowang", "A1"}} {
+		key, _ := s.CreateCompositeKey("Holding~id~code", k)
+		s.PutState(key, []byte(k[1]))
+	}
+	s.PutState("xiaozhang", []byte("account"))
+	s.end(shim.Success(nil))
+
+	count := func(it shim.StateQueryIteratorInterface, err error) int {
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := 0
+		for it.HasNext() {
+			it.Next()
+			n++
+		}
+		return n
+	}
+	if got := count(s.GetStateByPartialCompositeKey("Holding~id~code", []string{"xiaozhang"})); got != 2 {
+		t.Errorf("partial key xiaozhang: got %d, want 2", got)
+	}
+	if got := count(s.GetStateByPartialCompositeKey("Holding~id~code", []string{})); got != 3 {
+		t.Errorf("partial key all: got %d, want 3", got)
+	}
+	if got := count(s.GetStateByRange("", "")); got != 1 {
+		t.Errorf("range excluding composite keys: got %d, want 1", got)
+	}
+
+	// 分页
+	it, metadata, err := s.GetStateByPartialCompositeKeyWithPagination("Holding~id~code", []string{}, 2, "")
+	if got := count(it, err); got != 2 || metadata.FetchedRecordsCount != 2 || metadata.Bookmark == "" {
+		t.Fatalf("first page: got %d, metadata %+v", got, metadata)
+	}
+	it, metadata, err = s.GetStateByPartialCompositeKeyWithPagination("Holding~id~code", []string{}, 2, metadata.Bookmark)
+	if got := count(it, err); got != 1 || metadata.Bookmark != "" {
+		t.Errorf("second page: got %d, metadata %+v", got, metadata)
+	}
+}
+
+// 升级后已提交的状态保留，由新chaincode处理交易
+func TestUpgrade(t *testing.T) {
+	s := New("kv", kvChaincode{})
+	s.Invoke("put", "a", "1")
+
+	resp := s.Upgrade(upgradedChaincode{})
+	if resp.Status != shim.OK || string(resp.Payload) != "1" {
+		t.Errorf("Upgrade: got %d %q %q", resp.Status, resp.Message, resp.Payload)
+	}
+	if resp := s.Invoke("put", "a", "2"); resp.Status == shim.OK {
+		t.Errorf("put after upgrade: got status=%d, want error", resp.Status)
+	}
+}
+
+// upgradedChaincode Init时返回a的值，不支持任何调用
+type upgradedChaincode struct{}
+
+func (upgradedChaincode) Init(stub shim.ChaincodeStubInterface) pb.Response {
+	b, _ := stub.GetState("a")
+	return shim.Success(b)
+}
+
+func (upgradedChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
+	return shim.Error("not supported")
+}